@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+// diffTags writes one line per difference between a and b and returns how
+// many there were. Compounds and lists are compared entry by entry so that
+// a change deep inside a chunk is reported with its full path.
+func diffTags(w io.Writer, path string, a, b nbt.Tag) int {
+	if a.Type() != b.Type() {
+		fmt.Fprintf(w, "~ %s: %s -> %s\n", displayPath(path), nbt.FormatSNBT(a), nbt.FormatSNBT(b))
+		return 1
+	}
+
+	switch av := a.(type) {
+	case *nbt.Compound:
+		bv := b.(*nbt.Compound)
+		n := 0
+		for _, k := range av.Keys() {
+			child := joinPath(path, k)
+			if other := bv.Get(k); other != nil {
+				n += diffTags(w, child, av.Get(k), other)
+			} else {
+				fmt.Fprintf(w, "- %s: %s\n", child, nbt.FormatSNBT(av.Get(k)))
+				n++
+			}
+		}
+		for _, k := range bv.Keys() {
+			if av.Get(k) == nil {
+				fmt.Fprintf(w, "+ %s: %s\n", joinPath(path, k), nbt.FormatSNBT(bv.Get(k)))
+				n++
+			}
+		}
+		return n
+	case *nbt.List:
+		bv := b.(*nbt.List)
+		n := 0
+		for i := 0; i < av.Len() || i < bv.Len(); i++ {
+			child := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= bv.Len():
+				fmt.Fprintf(w, "- %s: %s\n", child, nbt.FormatSNBT(av.Get(i)))
+				n++
+			case i >= av.Len():
+				fmt.Fprintf(w, "+ %s: %s\n", child, nbt.FormatSNBT(bv.Get(i)))
+				n++
+			default:
+				n += diffTags(w, child, av.Get(i), bv.Get(i))
+			}
+		}
+		return n
+	}
+
+	if !nbt.Equal(a, b) {
+		fmt.Fprintf(w, "~ %s: %s -> %s\n", displayPath(path), nbt.FormatSNBT(a), nbt.FormatSNBT(b))
+		return 1
+	}
+
+	return 0
+}
+
+func joinPath(path, key string) string {
+	key = formatName(key)
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+
+	return path
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+const (
+	compressionNone = iota
+	compressionGzip
+	compressionZlib
+)
+
+var compressionNames = []string{"none", "gzip", "zlib"}
+
+type nbtFile struct {
+	path        string
+	compression int
+	name        string
+	root        nbt.Tag
+}
+
+// detectCompression looks at the magic bytes of data. level.dat and
+// playerdata are gzipped, region chunks are zlib and some tools write
+// raw NBT, which always starts with a compound tag.
+func detectCompression(data []byte) int {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		return compressionGzip
+	}
+
+	if len(data) >= 2 && data[0]&0x0f == 8 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0 {
+		return compressionZlib
+	}
+
+	return compressionNone
+}
+
+func loadFile(path string) (*nbtFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &nbtFile{path: path, compression: detectCompression(data)}
+
+	var r io.Reader = bytes.NewReader(data)
+	switch f.compression {
+	case compressionGzip:
+		if r, err = gzip.NewReader(r); err != nil {
+			return nil, err
+		}
+	case compressionZlib:
+		if r, err = zlib.NewReader(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.name, f.root, err = nbt.ReadTag(r); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// save writes the file back with its original compression. The data goes
+// to a temporary file first so that a failed write never truncates the
+// original.
+func (f *nbtFile) save() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	bw := bufio.NewWriter(tmp)
+	var w io.WriteCloser
+	switch f.compression {
+	case compressionGzip:
+		w = gzip.NewWriter(bw)
+	case compressionZlib:
+		w = zlib.NewWriter(bw)
+	default:
+		w = nopCloser{bw}
+	}
+
+	if err := nbt.WriteTag(w, f.name, f.root); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := bw.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(f.path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
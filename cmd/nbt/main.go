@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+const usage = `Usage: nbt <command> [arguments]
+
+Commands:
+  print [-snbt] [-json] <file>      print the whole file
+  get <file> <path>                 print the tag at path, e.g. Data.SpawnX
+  set <file> <path> <snbt value>    replace the tag at path and save
+  delete <file> <path>              remove the tag at path and save
+  diff <file> <file>                list the differences between two files
+
+Compression (gzip, zlib or none) is detected automatically and kept when
+a file is written back.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "print":
+		err = runPrint(args)
+	case "get":
+		err = runGet(args)
+	case "set":
+		err = runSet(args)
+	case "delete":
+		err = runDelete(args)
+	case "diff":
+		var same bool
+		same, err = runDiff(args)
+		if err == nil && !same {
+			os.Exit(1)
+		}
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "nbt: unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nbt:", err)
+		os.Exit(1)
+	}
+}
+
+func runPrint(args []string) error {
+	fs := flag.NewFlagSet("print", flag.ExitOnError)
+	snbt := fs.Bool("snbt", false, "print as indented SNBT")
+	asJSON := fs.Bool("json", false, "print as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("print takes exactly one file")
+	}
+
+	f, err := loadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *snbt:
+		fmt.Println(nbt.FormatSNBTIndent(f.root, "    "))
+	case *asJSON:
+		data, err := nbt.ToJSON(f.root, nbt.JSONPlain)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Printf("# %s compression\n", compressionNames[f.compression])
+		printTree(os.Stdout, formatName(f.name), f.root, 0)
+	}
+
+	return nil
+}
+
+func runGet(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: nbt get <file> <path>")
+	}
+
+	f, err := loadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	root, ok := f.root.(*nbt.Compound)
+	if !ok {
+		return fmt.Errorf("root tag is %s, not Compound", nbt.TagName(f.root.Type()))
+	}
+
+	t, err := root.Path(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch t.(type) {
+	case *nbt.Compound, *nbt.List:
+		fmt.Println(nbt.FormatSNBTIndent(t, "    "))
+	default:
+		fmt.Println(nbt.FormatSNBT(t))
+	}
+
+	return nil
+}
+
+func runSet(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: nbt set <file> <path> <snbt value>")
+	}
+
+	value, err := nbt.ParseSNBT(args[2])
+	if err != nil {
+		return err
+	}
+
+	return edit(args[0], func(root *nbt.Compound) error {
+		return root.SetPath(args[1], value)
+	})
+}
+
+func runDelete(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: nbt delete <file> <path>")
+	}
+
+	return edit(args[0], func(root *nbt.Compound) error {
+		return root.DeletePath(args[1])
+	})
+}
+
+func edit(path string, fn func(root *nbt.Compound) error) error {
+	f, err := loadFile(path)
+	if err != nil {
+		return err
+	}
+
+	root, ok := f.root.(*nbt.Compound)
+	if !ok {
+		return fmt.Errorf("root tag is %s, not Compound", nbt.TagName(f.root.Type()))
+	}
+
+	if err := fn(root); err != nil {
+		return err
+	}
+
+	return f.save()
+}
+
+func runDiff(args []string) (bool, error) {
+	if len(args) != 2 {
+		return false, fmt.Errorf("usage: nbt diff <file> <file>")
+	}
+
+	a, err := loadFile(args[0])
+	if err != nil {
+		return false, err
+	}
+
+	b, err := loadFile(args[1])
+	if err != nil {
+		return false, err
+	}
+
+	n := diffTags(os.Stdout, "", a.root, b.root)
+	if a.name != b.name {
+		fmt.Printf("~ (root name): %q -> %q\n", a.name, b.name)
+		n++
+	}
+
+	return n == 0, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+func testTree(t *testing.T, s string) *nbt.Compound {
+	tag, err := nbt.ParseSNBT(s)
+	if err != nil {
+		t.Fatalf("ParseSNBT failed: %s", err)
+	}
+
+	return tag.(*nbt.Compound)
+}
+
+func encode(t *testing.T, compression int, root nbt.Tag) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case compressionGzip:
+		w = gzip.NewWriter(&buf)
+	case compressionZlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		w = nopCloser{&buf}
+	}
+
+	if err := nbt.WriteTag(w, "", root); err != nil {
+		t.Fatalf("WriteTag failed: %s", err)
+	}
+	w.Close()
+
+	return buf.Bytes()
+}
+
+func TestDetectCompression(t *testing.T) {
+	root := testTree(t, `{a:1}`)
+
+	cases := []struct {
+		data []byte
+		want int
+	}{
+		{encode(t, compressionNone, root), compressionNone},
+		{encode(t, compressionGzip, root), compressionGzip},
+		{encode(t, compressionZlib, root), compressionZlib},
+		{nil, compressionNone},
+		{[]byte{0x1f}, compressionNone},
+	}
+
+	for i, c := range cases {
+		if got := detectCompression(c.data); got != c.want {
+			t.Errorf("case %d: detectCompression = %s, want %s", i, compressionNames[got], compressionNames[c.want])
+		}
+	}
+}
+
+func TestDiffTags(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		lines []string
+	}{
+		{`{a:1}`, `{a:1}`, nil},
+		{`{a:1}`, `{a:2}`, []string{"~ a: 1 -> 2"}},
+		{`{a:1}`, `{a:1b}`, []string{"~ a: 1 -> 1b"}},
+		{`{a:1}`, `{}`, []string{"- a: 1"}},
+		{`{}`, `{"b.c":1}`, []string{`+ "b.c": 1`}},
+		{`{l:[1,2]}`, `{l:[1,3,4]}`, []string{"~ l[1]: 2 -> 3", "+ l[2]: 4"}},
+		{`{x:{"a b":{y:1}}}`, `{x:{"a b":{y:2}}}`, []string{`~ x."a b".y: 1 -> 2`}},
+	}
+
+	for i, c := range cases {
+		var out bytes.Buffer
+		n := diffTags(&out, "", testTree(t, c.a), testTree(t, c.b))
+
+		var lines []string
+		if out.Len() > 0 {
+			lines = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		}
+		if n != len(c.lines) || strings.Join(lines, "\n") != strings.Join(c.lines, "\n") {
+			t.Errorf("case %d: diffTags = %d %q, want %q", i, n, lines, c.lines)
+		}
+	}
+}
+
+// paths shown by diff and print must work with get and set
+func TestDisplayedPathsParse(t *testing.T) {
+	keys := []string{"plain", "", "a.b", "a[0]", `say "hi"`, `back\slash`, "with space"}
+
+	for _, k := range keys {
+		root := nbt.NewCompound()
+		inner := nbt.NewCompound()
+		inner.Set(k, nbt.Int(7))
+		root.Set("x", inner)
+
+		path := joinPath(joinPath("", "x"), k)
+		v, err := root.Path(path)
+		if err != nil {
+			t.Fatalf("Path failed on %s: %s", path, err)
+		}
+		if v != nbt.Int(7) {
+			t.Errorf("unexpected value at %s: %v", path, v)
+		}
+	}
+}
+
+func TestPrintTree(t *testing.T) {
+	var out bytes.Buffer
+	printTree(&out, formatName(""), testTree(t, `{name:"Steve","a.b":[I;1,2],Pos:[1.5d,2.0d]}`), 0)
+
+	want := strings.Join([]string{
+		`"": Compound (3 entries)`,
+		`  name: String "Steve"`,
+		`  "a.b": IntArray [I;1,2]`,
+		`  Pos: List of Double (2 entries)`,
+		`    [0]: Double 1.5`,
+		`    [1]: Double 2`,
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Errorf("unexpected tree:\n%s", out.String())
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nbt")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, compression := range []int{compressionNone, compressionGzip, compressionZlib} {
+		path := filepath.Join(dir, compressionNames[compression]+".dat")
+		if err := ioutil.WriteFile(path, encode(t, compression, testTree(t, `{a:1}`)), 0600); err != nil {
+			t.Fatalf("WriteFile failed: %s", err)
+		}
+
+		f, err := loadFile(path)
+		if err != nil {
+			t.Fatalf("loadFile failed: %s", err)
+		}
+		if err := f.root.(*nbt.Compound).SetPath("b.c", nbt.String("x")); err != nil {
+			t.Fatalf("SetPath failed: %s", err)
+		}
+		if err := f.save(); err != nil {
+			t.Fatalf("save failed: %s", err)
+		}
+
+		saved, err := loadFile(path)
+		if err != nil {
+			t.Fatalf("loadFile failed: %s", err)
+		}
+		if saved.compression != compression || !nbt.Equal(saved.root, f.root) {
+			t.Errorf("%s round trip mismatch: %s", compressionNames[compression], nbt.FormatSNBT(saved.root))
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("%s save did not keep the file mode: %v", compressionNames[compression], info.Mode())
+		}
+	}
+
+	// no temporary files are left behind
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 3 {
+		t.Errorf("unexpected files after save: %d", len(files))
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+// printTree writes t as an indented tree, one tag per line.
+func printTree(w io.Writer, name string, t nbt.Tag, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch v := t.(type) {
+	case *nbt.Compound:
+		fmt.Fprintf(w, "%s%s: Compound (%d entries)\n", indent, name, v.Len())
+		for _, k := range v.Keys() {
+			printTree(w, formatName(k), v.Get(k), depth+1)
+		}
+	case *nbt.List:
+		fmt.Fprintf(w, "%s%s: List of %s (%d entries)\n", indent, name, nbt.TagName(v.ElemType()), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			printTree(w, "["+strconv.Itoa(i)+"]", v.Get(i), depth+1)
+		}
+	default:
+		fmt.Fprintf(w, "%s%s: %s %s\n", indent, name, nbt.TagName(t.Type()), formatLeaf(t))
+	}
+}
+
+// formatName quotes a name the way paths given to get and set do.
+func formatName(name string) string {
+	return nbt.QuotePathKey(name)
+}
+
+func formatLeaf(t nbt.Tag) string {
+	switch v := t.(type) {
+	case nbt.String:
+		return strconv.Quote(string(v))
+	case nbt.ByteArray, nbt.IntArray, nbt.LongArray:
+		return nbt.FormatSNBT(v)
+	}
+
+	return fmt.Sprint(t)
+}
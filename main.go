@@ -2,19 +2,120 @@ package main
 
 import (
 	"flag"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/skdltmxn/go-mine/net"
 	"github.com/skdltmxn/go-mine/server"
+	"github.com/skdltmxn/go-mine/server/console"
+	"github.com/skdltmxn/go-mine/world"
+	"github.com/skdltmxn/go-mine/world/gen"
 )
 
-func main() {
-	listener := net.NewListener()
-	listener.RegisterDispatcher(server.NewHandshakeServer())
-	listener.RegisterDispatcher(server.NewLoginServer())
-	listener.RegisterDispatcher(server.NewGameServer())
+// same as the vanilla server
+const autosaveInterval = 5 * time.Minute
 
+func main() {
 	portPtr := flag.Int("port", 25565, "Port number for server")
+	worldPtr := flag.String("world", "saves/world", "World directory")
+	generatorPtr := flag.String("generator", "default", "Generator of a new world (default, flat, void)")
+	layersPtr := flag.String("layers", gen.DefaultFlatLayers, "Layers of a new flat world")
+	seedPtr := flag.Int64("seed", 0, "Seed of a new world (random if 0)")
+	maxMovePtr := flag.Float64("max-move", 10, "Longest move in blocks a player may make at once")
+	maxAirPtr := flag.Int("max-air-ticks", 20, "Moves a survival player may hover in the air")
+	viewDistancePtr := flag.Int("view-distance", 10, "Most chunks around them players are sent")
+	whitelistPtr := flag.Bool("whitelist", false, "Only let players on the whitelist join")
+	chatFormatPtr := flag.String("chat-format", server.DefaultChatFormat, "Format of chat messages, with {name}, {message} and & codes")
 	flag.Parse()
 
+	seed := *seedPtr
+	if seed == 0 {
+		seed = rand.New(rand.NewSource(time.Now().UnixNano())).Int63()
+	}
+
+	w, err := world.Open(*worldPtr, world.Settings{
+		Name:      "world",
+		Seed:      seed,
+		Generator: *generatorPtr,
+		Layers:    *layersPtr,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	w.StartAutosave(autosaveInterval)
+
+	access, err := server.LoadAccess(".", *whitelistPtr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	con := console.New(os.Stdin, os.Stdout)
+	log.SetOutput(con.LogWriter(os.Stderr))
+
+	game := server.NewGameServer(w, access)
+	game.SetMovementChecks(
+		&server.SpeedCheck{MaxDistance: *maxMovePtr},
+		&server.NoClipCheck{},
+		&server.FlyCheck{MaxAirTicks: *maxAirPtr},
+	)
+	game.SetChatFormat(*chatFormatPtr)
+	game.SetViewDistance(*viewDistancePtr)
+
+	// both a signal and /stop may shut down, even at the same time
+	var shutdownOnce sync.Once
+	shutdown := func() {
+		shutdownOnce.Do(func() {
+			con.Close()
+			game.Stop()
+			log.Printf("Saving the world")
+			if err := w.Close(); err != nil {
+				log.Fatal(err)
+			}
+			os.Exit(0)
+		})
+	}
+	game.OnStop(shutdown)
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		shutdown()
+	}()
+
+	go readCommands(con, game)
+
+	login := server.NewLoginServer(w, access)
+	login.SetViewDistance(*viewDistancePtr)
+
+	listener := net.NewListener()
+	listener.RegisterDispatcher(server.NewHandshakeServer())
+	listener.RegisterDispatcher(login)
+	listener.RegisterDispatcher(game)
+
 	listener.Run(*portPtr)
 }
+
+// readCommands runs the commands typed into the console until its input
+// ends.
+func readCommands(con *console.Console, game *server.GameServer) {
+	src := server.NewConsoleSource(con.Println)
+
+	for {
+		line, err := con.ReadLine()
+		if err != nil {
+			log.Printf("Console input closed: %s", err)
+			return
+		}
+
+		if line = strings.TrimPrefix(strings.TrimSpace(line), "/"); line != "" {
+			game.RunCommand(src, line)
+		}
+	}
+}
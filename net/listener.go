@@ -27,7 +27,7 @@ func (l *Listener) Run(port int) {
 			log.Fatal("failed to accept client")
 		}
 
-		go l.handleClient(newSession(conn))
+		go l.handleClient(NewSession(conn))
 	}
 }
 
@@ -85,7 +85,7 @@ func (reader *Reader) ReadVarint() (int, error) {
 	}
 
 	reader.off += n
-	return int(v), nil
+	return int(int32(v)), nil
 }
 
 func (reader *Reader) ReadVarlong() (int64, error) {
@@ -61,8 +61,9 @@ func (w *Writer) WriteDouble(v float64) error {
 }
 
 func (w *Writer) WriteVarint(v int) error {
+	// negative values take all 5 bytes as in the protocol
 	b := make([]byte, 5)
-	n := binary.PutUvarint(b, uint64(v))
+	n := binary.PutUvarint(b, uint64(uint32(v)))
 	_, err := w.p.data.Write(b[:n])
 	return err
 }
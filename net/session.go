@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/cipher"
+	"log"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/skdltmxn/go-mine/net/packet"
 )
@@ -16,18 +18,34 @@ const (
 	SessionStateGame
 )
 
+const (
+	// a client that does not read for this long is dropped
+	writeTimeout = 10 * time.Second
+
+	// most bytes waiting to be sent before a client is dropped
+	maxQueueSize = 8 << 20
+)
+
 type SessionCryptor struct {
 	encrypter cipher.Stream
 	decrypter cipher.Stream
 }
 
 type Session struct {
-	conn    net.Conn
-	buffer  bytes.Buffer
-	eof     bool
-	m       sync.Mutex
-	state   int
-	cryptor *SessionCryptor
+	conn      net.Conn
+	buffer    bytes.Buffer
+	eof       bool
+	m         sync.Mutex
+	sendLock  sync.Mutex
+	state     int
+	cryptor   *SessionCryptor
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	queueLock sync.Mutex
+	queue     []byte
+	closing   bool          // close once the queue is sent
+	wake      chan struct{} // wakes the writer
 }
 
 func (sess *Session) SetCryptor(encrypter, decrypter cipher.Stream) {
@@ -46,24 +64,121 @@ func (sess *Session) SetState(newState int) {
 }
 
 func (sess *Session) Close() {
-	sess.conn.Close()
+	sess.closeOnce.Do(func() {
+		sess.conn.Close()
+		close(sess.closed)
+	})
 }
 
+// Done returns a channel that is closed when the session is closed.
+func (sess *Session) Done() <-chan struct{} {
+	return sess.closed
+}
+
+// SendPacket may be called from several goroutines; the encrypter is a
+// stream cipher so packets must go out in the order they are encrypted.
 func (sess *Session) SendPacket(p *packet.Packet) (int, error) {
 	data := p.Raw()
+
+	sess.sendLock.Lock()
+	defer sess.sendLock.Unlock()
+
+	return sess.write(data)
+}
+
+// write must be called with sess.sendLock held.
+func (sess *Session) write(data []byte) (int, error) {
 	if sess.cryptor != nil {
 		sess.cryptor.encrypter.XORKeyStream(data, data)
 	}
+
+	sess.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
 	return sess.conn.Write(data)
 }
 
-func newSession(conn net.Conn) *Session {
-	return &Session{
+// QueuePacket buffers a packet until the next Flush. A client that lets
+// too much pile up is disconnected.
+func (sess *Session) QueuePacket(p *packet.Packet) {
+	data := p.Raw()
+
+	sess.queueLock.Lock()
+	full := len(sess.queue)+len(data) > maxQueueSize
+	if !full && !sess.closing {
+		sess.queue = append(sess.queue, data...)
+	}
+	sess.queueLock.Unlock()
+
+	if full {
+		log.Printf("Send queue of %s is full", sess.conn.RemoteAddr())
+		sess.Close()
+	}
+}
+
+// Flush has the queued packets sent without waiting for the write.
+func (sess *Session) Flush() {
+	select {
+	case sess.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CloseAfterFlush sends the queued packets and then closes the session.
+// Packets queued after it are dropped.
+func (sess *Session) CloseAfterFlush() {
+	sess.queueLock.Lock()
+	sess.closing = true
+	sess.queueLock.Unlock()
+
+	sess.Flush()
+}
+
+// writeQueue sends the queued packets in a single write whenever the
+// session is flushed, so a slow client only blocks its own goroutine.
+func (sess *Session) writeQueue() {
+	for {
+		select {
+		case <-sess.closed:
+			return
+		case <-sess.wake:
+		}
+
+		sess.queueLock.Lock()
+		data := sess.queue
+		sess.queue = nil
+		closing := sess.closing
+		sess.queueLock.Unlock()
+
+		if len(data) > 0 {
+			sess.sendLock.Lock()
+			_, err := sess.write(data)
+			sess.sendLock.Unlock()
+
+			if err != nil {
+				sess.Close()
+				return
+			}
+		}
+
+		if closing {
+			sess.Close()
+			return
+		}
+	}
+}
+
+// NewSession wraps a connection. The listener makes one for every client.
+func NewSession(conn net.Conn) *Session {
+	sess := &Session{
 		conn:    conn,
 		eof:     false,
 		state:   SessionStateStatus,
 		cryptor: nil,
+		closed:  make(chan struct{}),
+		wake:    make(chan struct{}, 1),
 	}
+
+	go sess.writeQueue()
+	return sess
 }
 
 func (sess *Session) receiveData() {
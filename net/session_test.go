@@ -0,0 +1,43 @@
+package net
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+)
+
+func TestSessionQueueLimit(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	// the client never reads, so the first write blocks
+	sess := NewSession(server)
+	p := packet.NewPacket(0x00)
+	packet.NewWriter(p).Write(make([]byte, 1<<20))
+
+	sess.QueuePacket(p)
+	sess.Flush()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*maxQueueSize/(1<<20); i++ {
+			sess.QueuePacket(p)
+			sess.Flush()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("QueuePacket failed: blocked on a slow client")
+	}
+
+	select {
+	case <-sess.Done():
+	default:
+		t.Fatalf("QueuePacket failed: session over the limit is open")
+	}
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// files of the vanilla server
+const (
+	opsFile       = "ops.json"
+	bansFile      = "banned-players.json"
+	whitelistFile = "whitelist.json"
+)
+
+// level of new operators
+const defaultOpLevel = 4
+
+type profile struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// matches compares UUIDs if both are known and names otherwise, as
+// players banned while offline have no UUID.
+func (p *profile) matches(uuid, name string) bool {
+	if p.UUID != "" && uuid != "" {
+		return p.UUID == uuid
+	}
+
+	return strings.EqualFold(p.Name, name)
+}
+
+type opEntry struct {
+	profile
+	Level               int  `json:"level"`
+	BypassesPlayerLimit bool `json:"bypassesPlayerLimit"`
+}
+
+type banEntry struct {
+	profile
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+const banTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// Access holds the operators, banned players and the whitelist, which
+// are saved in JSON files like the vanilla server does.
+type Access struct {
+	m                sync.Mutex
+	dir              string
+	ops              []opEntry
+	bans             []banEntry
+	whitelist        []profile
+	whitelistEnabled bool
+}
+
+// LoadAccess loads the lists from dir. Missing files are empty lists.
+func LoadAccess(dir string, whitelistEnabled bool) (*Access, error) {
+	a := &Access{dir: dir, whitelistEnabled: whitelistEnabled}
+	if err := a.reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *Access) reload() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.ops, a.bans, a.whitelist = nil, nil, nil
+	if err := a.load(opsFile, &a.ops); err != nil {
+		return err
+	}
+	if err := a.load(bansFile, &a.bans); err != nil {
+		return err
+	}
+	return a.load(whitelistFile, &a.whitelist)
+}
+
+func (a *Access) load(name string, v interface{}) error {
+	data, err := ioutil.ReadFile(filepath.Join(a.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// save must be called with a.m held.
+func (a *Access) save(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(a.dir, name), data, 0644)
+}
+
+func (a *Access) opLevel(uuid, name string) int {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for _, op := range a.ops {
+		if op.matches(uuid, name) {
+			return op.Level
+		}
+	}
+
+	return 0
+}
+
+// op makes a player an operator and reports whether it was not one.
+func (a *Access) op(uuid, name string) (bool, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for _, op := range a.ops {
+		if op.matches(uuid, name) {
+			return false, nil
+		}
+	}
+
+	a.ops = append(a.ops, opEntry{profile: profile{uuid, name}, Level: defaultOpLevel})
+	return true, a.save(opsFile, a.ops)
+}
+
+func (a *Access) deop(uuid, name string) (bool, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for i, op := range a.ops {
+		if op.matches(uuid, name) {
+			a.ops = append(a.ops[:i], a.ops[i+1:]...)
+			return true, a.save(opsFile, a.ops)
+		}
+	}
+
+	return false, nil
+}
+
+// banned returns the ban of a player or nil.
+func (a *Access) banned(uuid, name string) *banEntry {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for i := range a.bans {
+		if a.bans[i].matches(uuid, name) {
+			ban := a.bans[i]
+			return &ban
+		}
+	}
+
+	return nil
+}
+
+func (a *Access) ban(uuid, name, source, reason string) (bool, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for _, ban := range a.bans {
+		if ban.matches(uuid, name) {
+			return false, nil
+		}
+	}
+
+	a.bans = append(a.bans, banEntry{
+		profile: profile{uuid, name},
+		Created: time.Now().Format(banTimeFormat),
+		Source:  source,
+		Expires: "forever",
+		Reason:  reason,
+	})
+	return true, a.save(bansFile, a.bans)
+}
+
+func (a *Access) pardon(name string) (bool, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for i, ban := range a.bans {
+		if strings.EqualFold(ban.Name, name) {
+			a.bans = append(a.bans[:i], a.bans[i+1:]...)
+			return true, a.save(bansFile, a.bans)
+		}
+	}
+
+	return false, nil
+}
+
+func (a *Access) bannedNames() []string {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	names := make([]string, len(a.bans))
+	for i, ban := range a.bans {
+		names[i] = ban.Name
+	}
+
+	return names
+}
+
+// allowed reports whether the whitelist lets a player in. Operators are
+// always let in.
+func (a *Access) allowed(uuid, name string) bool {
+	a.m.Lock()
+	enabled := a.whitelistEnabled
+	a.m.Unlock()
+
+	if !enabled || a.opLevel(uuid, name) > 0 {
+		return true
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for _, p := range a.whitelist {
+		if p.matches(uuid, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Access) setWhitelistEnabled(enabled bool) bool {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	changed := a.whitelistEnabled != enabled
+	a.whitelistEnabled = enabled
+	return changed
+}
+
+func (a *Access) whitelistAdd(uuid, name string) (bool, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for _, p := range a.whitelist {
+		if p.matches(uuid, name) {
+			return false, nil
+		}
+	}
+
+	a.whitelist = append(a.whitelist, profile{uuid, name})
+	return true, a.save(whitelistFile, a.whitelist)
+}
+
+func (a *Access) whitelistRemove(name string) (bool, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for i, p := range a.whitelist {
+		if strings.EqualFold(p.Name, name) {
+			a.whitelist = append(a.whitelist[:i], a.whitelist[i+1:]...)
+			return true, a.save(whitelistFile, a.whitelist)
+		}
+	}
+
+	return false, nil
+}
+
+func (a *Access) whitelistNames() []string {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	names := make([]string, len(a.whitelist))
+	for i, p := range a.whitelist {
+		names[i] = p.Name
+	}
+
+	return names
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestAccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "access")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, err := LoadAccess(dir, true)
+	if err != nil {
+		t.Fatalf("LoadAccess failed: %s", err)
+	}
+
+	const id = "069a79f4-44e9-4726-a5be-fca90e38aaf5"
+	if a.allowed(id, "Notch") {
+		t.Fatalf("allowed failed: player not on the whitelist is allowed")
+	}
+	if _, err := a.whitelistAdd("", "notch"); err != nil {
+		t.Fatalf("whitelistAdd failed: %s", err)
+	}
+	if !a.allowed(id, "Notch") {
+		t.Fatalf("allowed failed: whitelisted name is not allowed")
+	}
+
+	if _, err := a.op(id, "Notch"); err != nil {
+		t.Fatalf("op failed: %s", err)
+	}
+	if _, err := a.ban("", "Griefer", "Server", "griefing"); err != nil {
+		t.Fatalf("ban failed: %s", err)
+	}
+
+	// the lists survive a reload from disk
+	a, err = LoadAccess(dir, false)
+	if err != nil {
+		t.Fatalf("LoadAccess failed: %s", err)
+	}
+	if level := a.opLevel(id, "Someone"); level != defaultOpLevel {
+		t.Fatalf("opLevel failed: %d", level)
+	}
+	if ban := a.banned("", "griefer"); ban == nil || ban.Reason != "griefing" {
+		t.Fatalf("banned failed: %+v", ban)
+	}
+	if ok, _ := a.pardon("GRIEFER"); !ok || a.banned("", "Griefer") != nil {
+		t.Fatalf("pardon failed")
+	}
+}
@@ -0,0 +1,733 @@
+package server
+
+import (
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/server/command"
+	"github.com/skdltmxn/go-mine/util/chat"
+	"github.com/skdltmxn/go-mine/world/block"
+)
+
+// shown to clients and by /list
+const maxPlayers = 20
+
+// names of the game modes in the vanilla translations
+var gameModeNames = map[uint8]string{
+	GameModeSurvival:  "survival",
+	GameModeCreative:  "creative",
+	GameModeAdventure: "adventure",
+	GameModeSpectator: "spectator",
+}
+
+// times of day accepted by /time set
+var namedTimes = []struct {
+	name string
+	time int
+}{
+	{"day", 1000},
+	{"noon", 6000},
+	{"night", 13000},
+	{"midnight", 18000},
+}
+
+// Change Game State reasons
+const (
+	gameStateEndRain      = 1
+	gameStateBeginRain    = 2
+	gameStateRainLevel    = 7
+	gameStateThunderLevel = 8
+)
+
+func gameStatePacket(reason uint8, value float32) *packet.Packet {
+	p := packet.NewPacket(0x1f)
+	w := packet.NewWriter(p)
+	w.WriteUbyte(reason)
+	w.WriteFloat(value)
+	return p
+}
+
+// weatherPackets returns the packets that show the weather to a client.
+func weatherPackets(raining, thundering bool) []*packet.Packet {
+	if !raining {
+		return []*packet.Packet{
+			gameStatePacket(gameStateEndRain, 0),
+			gameStatePacket(gameStateRainLevel, 0),
+			gameStatePacket(gameStateThunderLevel, 0),
+		}
+	}
+
+	thunder := float32(0)
+	if thundering {
+		thunder = 1
+	}
+	return []*packet.Packet{
+		gameStatePacket(gameStateBeginRain, 0),
+		gameStatePacket(gameStateRainLevel, 1),
+		gameStatePacket(gameStateThunderLevel, thunder),
+	}
+}
+
+// sendWeather shows a joining player the weather if it is not clear.
+func (g *GameServer) sendWeather(player *GamePlayer) {
+	if raining, thundering := g.world.Weather(); raining {
+		for _, p := range weatherPackets(raining, thundering) {
+			player.sess.QueuePacket(p)
+		}
+	}
+}
+
+func (g *GameServer) broadcastWeather() {
+	for _, p := range weatherPackets(g.world.Weather()) {
+		for _, player := range g.players() {
+			player.sess.QueuePacket(p)
+		}
+	}
+}
+
+// sendOpLevel tells the client its permission level, which unlocks
+// things like the F3 game mode switcher.
+func (g *GameServer) sendOpLevel(player *GamePlayer) {
+	player.m.Lock()
+	level := player.permission
+	player.m.Unlock()
+
+	if level < command.PermissionAll {
+		level = command.PermissionAll
+	} else if level > command.PermissionOwner {
+		level = command.PermissionOwner
+	}
+
+	p := packet.NewPacket(0x1c)
+	w := packet.NewWriter(p)
+	w.WriteInt(player.eid)
+	w.WriteByte(int8(24 + level))
+	player.sess.QueuePacket(p)
+}
+
+// setPermission changes the permission of an online player and resends
+// the commands it may run.
+func (g *GameServer) setPermission(player *GamePlayer, level int) {
+	player.m.Lock()
+	player.permission = level
+	player.m.Unlock()
+
+	g.sendCommands(player)
+	g.sendOpLevel(player)
+}
+
+// teleportTo moves a player and sends the chunks around the destination.
+func (g *GameServer) teleportTo(player *GamePlayer, x, y, z float64) {
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	player.x, player.y, player.z = x, y, z
+	player.fallDistance = 0
+	player.airTicks = 0
+
+	g.teleport(player.sess, player)
+	if player.view != nil {
+		player.view.moveTo(x, z)
+	}
+}
+
+// sourcePlayer returns the player running a command.
+func sourcePlayer(src command.Source) (*GamePlayer, error) {
+	if ps, ok := src.(*playerSource); ok {
+		return ps.player, nil
+	}
+
+	return nil, command.Error("A player is required to run this command here")
+}
+
+// singlePlayer resolves a selector that must select one player.
+func (g *GameServer) singlePlayer(src command.Source, s command.Selector) (*GamePlayer, error) {
+	players, err := g.selectPlayers(src, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(players) > 1 {
+		return nil, command.Error("Only one player is allowed, but the provided selector allows more than one")
+	}
+
+	return players[0], nil
+}
+
+type gameProfile struct {
+	uuid, name string
+	player     *GamePlayer // nil if offline
+}
+
+// selectProfiles resolves a game profile argument. A name that matches no
+// online player is a player who is offline, whose UUID is not known.
+func (g *GameServer) selectProfiles(src command.Source, s command.Selector) ([]gameProfile, error) {
+	if s.Type == 0 && g.playerByName(s.Name) == nil {
+		return []gameProfile{{name: s.Name}}, nil
+	}
+
+	players, err := g.selectPlayers(src, s)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]gameProfile, len(players))
+	for i, player := range players {
+		profiles[i] = gameProfile{player.uuid.String(), player.name, player}
+	}
+
+	return profiles, nil
+}
+
+func numberText(v interface{}) *chat.Component {
+	switch v := v.(type) {
+	case int:
+		return chat.Text(strconv.Itoa(v))
+	case int64:
+		return chat.Text(strconv.FormatInt(v, 10))
+	case float64:
+		return chat.Text(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+
+	return chat.Text("")
+}
+
+func (g *GameServer) registerAdminCommands() {
+	g.registerGameMode()
+	g.registerTeleport()
+	g.registerGive()
+	g.registerAccessCommands()
+	g.registerTime()
+	g.registerWeather()
+	g.registerServerCommands()
+}
+
+func (g *GameServer) registerGameMode() {
+	set := func(ctx *command.Context, targets []*GamePlayer) error {
+		mode := ctx.GameMode("gamemode")
+		modeName := chat.Translate("gameMode." + gameModeNames[mode])
+
+		for _, target := range targets {
+			g.changeGameMode(target, mode)
+
+			if ps, ok := ctx.Source.(*playerSource); ok && ps.player == target {
+				ctx.Source.SendMessage(chat.Translate("commands.gamemode.success.self", modeName))
+			} else {
+				g.sendMessage(target, chat.Translate("gameMode.changed", modeName), ChatPositionSystem)
+				ctx.Source.SendMessage(chat.Translate("commands.gamemode.success.other", chat.Text(target.name), modeName))
+			}
+		}
+		return nil
+	}
+
+	g.commands.Register(command.Literal("gamemode").Requires(command.PermissionGameMaster).Then(
+		command.Argument("gamemode", command.GameMode()).Executes(func(ctx *command.Context) error {
+			player, err := sourcePlayer(ctx.Source)
+			if err != nil {
+				return err
+			}
+			return set(ctx, []*GamePlayer{player})
+		}).Then(
+			command.Argument("target", command.Entity(command.PlayersOnly, g.playerNames)).Executes(func(ctx *command.Context) error {
+				targets, err := g.selectPlayers(ctx.Source, ctx.Selector("target"))
+				if err != nil {
+					return err
+				}
+				return set(ctx, targets)
+			}),
+		),
+	))
+}
+
+func (g *GameServer) registerTeleport() {
+	toPlayer := func(ctx *command.Context, targets []*GamePlayer) error {
+		dest, err := g.singlePlayer(ctx.Source, ctx.Selector("destination"))
+		if err != nil {
+			return err
+		}
+
+		pos := dest.position()
+		for _, target := range targets {
+			g.teleportTo(target, pos.x, pos.y, pos.z)
+		}
+
+		if len(targets) == 1 {
+			ctx.Source.SendMessage(chat.Translate("commands.teleport.success.entity.single",
+				chat.Text(targets[0].name), chat.Text(dest.name)))
+		} else {
+			ctx.Source.SendMessage(chat.Translate("commands.teleport.success.entity.multiple",
+				numberText(len(targets)), chat.Text(dest.name)))
+		}
+		return nil
+	}
+
+	toLocation := func(ctx *command.Context, targets []*GamePlayer) error {
+		loc := ctx.Vec3("location")
+		for _, target := range targets {
+			g.teleportTo(target, loc.X, loc.Y, loc.Z)
+		}
+
+		x, y, z := numberText(loc.X), numberText(loc.Y), numberText(loc.Z)
+		if len(targets) == 1 {
+			ctx.Source.SendMessage(chat.Translate("commands.teleport.success.location.single",
+				chat.Text(targets[0].name), x, y, z))
+		} else {
+			ctx.Source.SendMessage(chat.Translate("commands.teleport.success.location.multiple",
+				numberText(len(targets)), x, y, z))
+		}
+		return nil
+	}
+
+	self := func(fn func(*command.Context, []*GamePlayer) error) func(*command.Context) error {
+		return func(ctx *command.Context) error {
+			player, err := sourcePlayer(ctx.Source)
+			if err != nil {
+				return err
+			}
+			return fn(ctx, []*GamePlayer{player})
+		}
+	}
+
+	selected := func(fn func(*command.Context, []*GamePlayer) error) func(*command.Context) error {
+		return func(ctx *command.Context) error {
+			targets, err := g.selectPlayers(ctx.Source, ctx.Selector("targets"))
+			if err != nil {
+				return err
+			}
+			return fn(ctx, targets)
+		}
+	}
+
+	players := func() command.Parser {
+		return command.Entity(command.PlayersOnly, g.playerNames)
+	}
+	single := func() command.Parser {
+		return command.Entity(command.PlayersOnly|command.SingleEntity, g.playerNames)
+	}
+
+	for _, name := range []string{"teleport", "tp"} {
+		g.commands.Register(command.Literal(name).Requires(command.PermissionGameMaster).Then(
+			command.Argument("location", command.Position()).Executes(self(toLocation)),
+			command.Argument("destination", single()).Executes(self(toPlayer)),
+			command.Argument("targets", players()).Then(
+				command.Argument("location", command.Position()).Executes(selected(toLocation)),
+				command.Argument("destination", single()).Executes(selected(toPlayer)),
+			),
+		))
+	}
+}
+
+func (g *GameServer) registerGive() {
+	give := func(ctx *command.Context) error {
+		targets, err := g.selectPlayers(ctx.Source, ctx.Selector("targets"))
+		if err != nil {
+			return err
+		}
+
+		name := ctx.String("item")
+		id, _ := block.Items.ID(name)
+		count := 1
+		if ctx.Has("count") {
+			count = ctx.Int("count")
+		}
+
+		for _, target := range targets {
+			if left := g.giveItem(target, id, count); left > 0 {
+				log.Printf("[GAME] %d of %s did not fit into the inventory of %s", left, name, target.name)
+			}
+		}
+
+		item := chat.Text("[" + strings.TrimPrefix(name, "minecraft:") + "]")
+		if len(targets) == 1 {
+			ctx.Source.SendMessage(chat.Translate("commands.give.success.single",
+				numberText(count), item, chat.Text(targets[0].name)))
+		} else {
+			ctx.Source.SendMessage(chat.Translate("commands.give.success.multiple",
+				numberText(count), item, numberText(len(targets))))
+		}
+		return nil
+	}
+
+	valid := func(name string) bool {
+		_, ok := block.Items.ID(name)
+		return ok && name != "minecraft:air"
+	}
+
+	g.commands.Register(command.Literal("give").Requires(command.PermissionGameMaster).Then(
+		command.Argument("targets", command.Entity(command.PlayersOnly, g.playerNames)).Then(
+			command.Argument("item", command.Item(valid)).Executes(give).Then(
+				command.Argument("count", command.Integer(1, 36*maxStackSize)).Executes(give),
+			),
+		),
+	))
+}
+
+func (g *GameServer) registerAccessCommands() {
+	kick := func(ctx *command.Context) error {
+		targets, err := g.selectPlayers(ctx.Source, ctx.Selector("targets"))
+		if err != nil {
+			return err
+		}
+
+		reason := chat.Translate("multiplayer.disconnect.kicked")
+		if ctx.Has("reason") {
+			reason = chat.Text(ctx.String("reason"))
+		}
+
+		for _, target := range targets {
+			g.kick(target, reason)
+			ctx.Source.SendMessage(chat.Translate("commands.kick.success", chat.Text(target.name), reason))
+		}
+		return nil
+	}
+
+	g.commands.Register(command.Literal("kick").Requires(command.PermissionAdmin).Then(
+		command.Argument("targets", command.Entity(command.PlayersOnly, g.playerNames)).Executes(kick).Then(
+			command.Argument("reason", command.String(command.GreedyPhrase)).Executes(kick),
+		),
+	))
+
+	ban := func(ctx *command.Context) error {
+		profiles, err := g.selectProfiles(ctx.Source, ctx.Selector("targets"))
+		if err != nil {
+			return err
+		}
+
+		reason := "Banned by an operator."
+		if ctx.Has("reason") {
+			reason = ctx.String("reason")
+		}
+
+		banned := 0
+		for _, profile := range profiles {
+			ok, err := g.access.ban(profile.uuid, profile.name, ctx.Source.Name(), reason)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			banned++
+			ctx.Source.SendMessage(chat.Translate("commands.ban.success", chat.Text(profile.name), chat.Text(reason)))
+			if profile.player != nil {
+				g.kick(profile.player, chat.Translate("multiplayer.disconnect.banned"))
+			}
+		}
+
+		if banned == 0 {
+			return command.Error("Nothing changed. The player is already banned")
+		}
+		return nil
+	}
+
+	g.commands.Register(command.Literal("ban").Requires(command.PermissionAdmin).Then(
+		command.Argument("targets", command.GameProfile(g.playerNames)).Executes(ban).Then(
+			command.Argument("reason", command.String(command.GreedyPhrase)).Executes(ban),
+		),
+	))
+
+	g.commands.Register(command.Literal("pardon").Requires(command.PermissionAdmin).Then(
+		command.Argument("targets", command.String(command.SingleWord)).Suggests(g.suggestAccess(g.access.bannedNames)).
+			Executes(func(ctx *command.Context) error {
+				name := ctx.String("targets")
+				ok, err := g.access.pardon(name)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return command.Error("Nothing changed. The player isn't banned")
+				}
+
+				ctx.Source.SendMessage(chat.Translate("commands.pardon.success", chat.Text(name)))
+				return nil
+			}),
+	))
+
+	op := func(grant bool) func(ctx *command.Context) error {
+		return func(ctx *command.Context) error {
+			profiles, err := g.selectProfiles(ctx.Source, ctx.Selector("targets"))
+			if err != nil {
+				return err
+			}
+
+			changed := 0
+			for _, profile := range profiles {
+				var ok bool
+				if grant {
+					ok, err = g.access.op(profile.uuid, profile.name)
+				} else {
+					ok, err = g.access.deop(profile.uuid, profile.name)
+				}
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+
+				changed++
+				if profile.player != nil {
+					g.setPermission(profile.player, g.access.opLevel(profile.uuid, profile.name))
+				}
+				if grant {
+					ctx.Source.SendMessage(chat.Translate("commands.op.success", chat.Text(profile.name)))
+				} else {
+					ctx.Source.SendMessage(chat.Translate("commands.deop.success", chat.Text(profile.name)))
+				}
+			}
+
+			if changed > 0 {
+				return nil
+			} else if grant {
+				return command.Error("Nothing changed. The player already is an operator")
+			}
+			return command.Error("Nothing changed. The player is not an operator")
+		}
+	}
+
+	g.commands.Register(command.Literal("op").Requires(command.PermissionAdmin).Then(
+		command.Argument("targets", command.GameProfile(g.playerNames)).Executes(op(true)),
+	))
+	g.commands.Register(command.Literal("deop").Requires(command.PermissionAdmin).Then(
+		command.Argument("targets", command.GameProfile(g.playerNames)).Executes(op(false)),
+	))
+
+	g.registerWhitelist()
+}
+
+// suggestAccess completes names from one of the access lists.
+func (g *GameServer) suggestAccess(names func() []string) func(ctx *command.Context, prefix string) []string {
+	return func(ctx *command.Context, prefix string) []string {
+		var matches []string
+		for _, name := range names() {
+			if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+				matches = append(matches, name)
+			}
+		}
+		return matches
+	}
+}
+
+func (g *GameServer) registerWhitelist() {
+	enable := func(enabled bool) func(ctx *command.Context) error {
+		return func(ctx *command.Context) error {
+			if !g.access.setWhitelistEnabled(enabled) {
+				if enabled {
+					return command.Error("Whitelist is already turned on")
+				}
+				return command.Error("Whitelist is already turned off")
+			}
+
+			if enabled {
+				ctx.Source.SendMessage(chat.Translate("commands.whitelist.enabled"))
+			} else {
+				ctx.Source.SendMessage(chat.Translate("commands.whitelist.disabled"))
+			}
+			return nil
+		}
+	}
+
+	add := func(ctx *command.Context) error {
+		profiles, err := g.selectProfiles(ctx.Source, ctx.Selector("targets"))
+		if err != nil {
+			return err
+		}
+
+		added := 0
+		for _, profile := range profiles {
+			ok, err := g.access.whitelistAdd(profile.uuid, profile.name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				added++
+				ctx.Source.SendMessage(chat.Translate("commands.whitelist.add.success", chat.Text(profile.name)))
+			}
+		}
+
+		if added == 0 {
+			return command.Error("Player is already whitelisted")
+		}
+		return nil
+	}
+
+	remove := func(ctx *command.Context) error {
+		name := ctx.String("targets")
+		ok, err := g.access.whitelistRemove(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return command.Error("Player is not whitelisted")
+		}
+
+		ctx.Source.SendMessage(chat.Translate("commands.whitelist.remove.success", chat.Text(name)))
+		return nil
+	}
+
+	list := func(ctx *command.Context) error {
+		names := g.access.whitelistNames()
+		if len(names) == 0 {
+			ctx.Source.SendMessage(chat.Translate("commands.whitelist.none"))
+			return nil
+		}
+
+		ctx.Source.SendMessage(chat.Translate("commands.whitelist.list",
+			numberText(len(names)), chat.Text(strings.Join(names, ", "))))
+		return nil
+	}
+
+	reload := func(ctx *command.Context) error {
+		if err := g.access.reload(); err != nil {
+			return err
+		}
+
+		ctx.Source.SendMessage(chat.Translate("commands.whitelist.reloaded"))
+		return nil
+	}
+
+	g.commands.Register(command.Literal("whitelist").Requires(command.PermissionAdmin).Then(
+		command.Literal("on").Executes(enable(true)),
+		command.Literal("off").Executes(enable(false)),
+		command.Literal("add").Then(
+			command.Argument("targets", command.GameProfile(g.playerNames)).Executes(add),
+		),
+		command.Literal("remove").Then(
+			command.Argument("targets", command.String(command.SingleWord)).
+				Suggests(g.suggestAccess(g.access.whitelistNames)).Executes(remove),
+		),
+		command.Literal("list").Executes(list),
+		command.Literal("reload").Executes(reload),
+	))
+}
+
+func (g *GameServer) registerTime() {
+	set := func(ctx *command.Context, dayTime int) error {
+		age, _ := g.world.Time()
+		g.world.SetTime(age, int64(dayTime))
+		g.sendTimeUpdates()
+
+		ctx.Source.SendMessage(chat.Translate("commands.time.set", numberText(dayTime)))
+		return nil
+	}
+
+	setNode := command.Literal("set").Then(
+		command.Argument("time", command.Integer(0, 1<<31-1)).Executes(func(ctx *command.Context) error {
+			return set(ctx, ctx.Int("time"))
+		}),
+	)
+	for _, t := range namedTimes {
+		t := t
+		setNode.Then(command.Literal(t.name).Executes(func(ctx *command.Context) error {
+			return set(ctx, t.time)
+		}))
+	}
+
+	query := func(value func(age, dayTime int64) int64) func(ctx *command.Context) error {
+		return func(ctx *command.Context) error {
+			age, dayTime := g.world.Time()
+			ctx.Source.SendMessage(chat.Translate("commands.time.query", numberText(value(age, dayTime))))
+			return nil
+		}
+	}
+
+	g.commands.Register(command.Literal("time").Requires(command.PermissionGameMaster).Then(
+		setNode,
+		command.Literal("add").Then(
+			command.Argument("time", command.Integer(0, 1<<31-1)).Executes(func(ctx *command.Context) error {
+				age, dayTime := g.world.Time()
+				dayTime += int64(ctx.Int("time"))
+				g.world.SetTime(age, dayTime)
+				g.sendTimeUpdates()
+
+				ctx.Source.SendMessage(chat.Translate("commands.time.set", numberText(dayTime%24000)))
+				return nil
+			}),
+		),
+		command.Literal("query").Then(
+			command.Literal("daytime").Executes(query(func(age, dayTime int64) int64 { return dayTime % 24000 })),
+			command.Literal("gametime").Executes(query(func(age, dayTime int64) int64 { return age })),
+			command.Literal("day").Executes(query(func(age, dayTime int64) int64 { return dayTime / 24000 })),
+		),
+	))
+}
+
+func (g *GameServer) registerWeather() {
+	set := func(raining, thundering bool, key string) func(ctx *command.Context) error {
+		return func(ctx *command.Context) error {
+			// like the vanilla server, 5 to 15 minutes if not given
+			duration := (300 + rand.Intn(600)) * 20
+			if ctx.Has("duration") {
+				duration = ctx.Int("duration") * 20
+			}
+
+			g.world.SetWeather(raining, thundering, duration)
+			g.broadcastWeather()
+
+			ctx.Source.SendMessage(chat.Translate(key))
+			return nil
+		}
+	}
+
+	weather := command.Literal("weather").Requires(command.PermissionGameMaster)
+	for _, w := range []struct {
+		name                string
+		raining, thundering bool
+	}{
+		{"clear", false, false},
+		{"rain", true, false},
+		{"thunder", true, true},
+	} {
+		fn := set(w.raining, w.thundering, "commands.weather.set."+w.name)
+		weather.Then(command.Literal(w.name).Executes(fn).Then(
+			command.Argument("duration", command.Integer(0, 1000000)).Executes(fn),
+		))
+	}
+	g.commands.Register(weather)
+}
+
+func (g *GameServer) registerServerCommands() {
+	g.commands.Register(command.Literal("say").Requires(command.PermissionGameMaster).Then(
+		command.Argument("message", command.String(command.GreedyPhrase)).Executes(func(ctx *command.Context) error {
+			g.Broadcast(chat.Translate("chat.type.announcement",
+				chat.Text(ctx.Source.Name()), chat.Text(ctx.String("message"))), ChatPositionChat)
+			return nil
+		}),
+	))
+
+	g.commands.Register(command.Literal("list").Executes(func(ctx *command.Context) error {
+		names := g.playerNames()
+		ctx.Source.SendMessage(chat.Translate("commands.list.players",
+			numberText(len(names)), numberText(maxPlayers), chat.Text(strings.Join(names, ", "))))
+		return nil
+	}))
+
+	g.commands.Register(command.Literal("save-all").Requires(command.PermissionOwner).Executes(func(ctx *command.Context) error {
+		ctx.Source.SendMessage(chat.Translate("commands.save.saving"))
+		if err := g.world.Save(); err != nil {
+			log.Printf("[GAME] Failed to save the world: %s", err)
+			return command.Error("Unable to save the game (is there enough disk space?)")
+		}
+
+		ctx.Source.SendMessage(chat.Translate("commands.save.success"))
+		return nil
+	}))
+
+	g.commands.Register(command.Literal("stop").Requires(command.PermissionOwner).Executes(func(ctx *command.Context) error {
+		g.m.RLock()
+		stop := g.stop
+		g.m.RUnlock()
+
+		if stop == nil {
+			return command.Error("The server cannot be stopped from here")
+		}
+
+		ctx.Source.SendMessage(chat.Translate("commands.stop.stopping"))
+		// the tick this runs on must end before the server stops
+		go stop()
+		return nil
+	}))
+}
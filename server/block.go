@@ -0,0 +1,90 @@
+package server
+
+import (
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+const (
+	diggingStarted = iota
+	diggingCancelled
+	diggingFinished
+)
+
+// readPosition decodes a block position packed into a long as x:26, z:26
+// and y:12 bits.
+func readPosition(r *packet.Reader) (x, y, z int, err error) {
+	v, err := r.ReadLong()
+	x = int(v >> 38)
+	y = int(v << 52 >> 52)
+	z = int(v << 26 >> 38)
+	return
+}
+
+func writePosition(w *packet.Writer, x, y, z int) error {
+	return w.WriteLong(int64(x&0x3ffffff)<<38 | int64(z&0x3ffffff)<<12 | int64(y&0xfff))
+}
+
+func (g *GameServer) handleDigging(p *packet.Packet) {
+	r := packet.NewReader(p)
+	status, _ := r.ReadVarint()
+	x, y, z, err := readPosition(r)
+	if err != nil {
+		return
+	}
+
+	// players are in creative mode, which breaks blocks at once
+	if status == diggingStarted {
+		g.setBlock(x, y, z, chunk.AirState)
+	}
+}
+
+// setBlock changes a block and sends the change and the new light to
+// every player who has the chunk loaded.
+func (g *GameServer) setBlock(x, y, z int, state int32) {
+	changed, ok := g.world.SetBlock(x, y, z, state)
+	if !ok {
+		return
+	}
+
+	p := packet.NewPacket(0x0c)
+	w := packet.NewWriter(p)
+	writePosition(w, x, y, z)
+	w.WriteVarint(int(state))
+
+	pos := chunkPos{int32(x >> 4), int32(z >> 4)}
+	for _, player := range g.players() {
+		if player.hasChunk(pos) {
+			player.sess.QueuePacket(p)
+		}
+	}
+
+	others := make([]chunkPos, len(changed))
+	for i, p := range changed {
+		others[i] = chunkPos{p.X, p.Z}
+	}
+	g.sendLightUpdates(others)
+}
+
+// sendLightUpdates sends the light of the given chunks to the players who
+// have them loaded.
+func (g *GameServer) sendLightUpdates(changed []chunkPos) {
+	players := g.players()
+
+	for _, pos := range changed {
+		var light *packet.Packet
+		for _, player := range players {
+			if !player.hasChunk(pos) {
+				continue
+			}
+			if light == nil {
+				if !g.world.LoadedChunk(pos.x, pos.z, func(c *chunk.Chunk) {
+					light = c.LightPacket()
+				}) {
+					break
+				}
+			}
+			player.sess.QueuePacket(light)
+		}
+	}
+}
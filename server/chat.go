@@ -0,0 +1,218 @@
+package server
+
+import (
+	"log"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/util/chat"
+)
+
+// where a message shows up on the client
+const (
+	ChatPositionChat = iota
+	ChatPositionSystem
+	ChatPositionGameInfo // above the hotbar
+)
+
+// chat modes of Client Settings
+const (
+	chatModeEnabled = iota
+	chatModeCommandsOnly
+	chatModeHidden
+)
+
+const (
+	maxChatLength = 256
+
+	// placeholders of the chat format
+	chatFormatName    = "{name}"
+	chatFormatMessage = "{message}"
+
+	DefaultChatFormat = "<{name}> {message}"
+)
+
+// ChatEvent is a chat message about to be broadcast. Handlers may change
+// the message or cancel it.
+type ChatEvent struct {
+	Player    string
+	Message   string
+	Cancelled bool
+}
+
+// OnChat registers a handler called with every chat message before it is
+// broadcast, in the order the handlers were registered.
+func (g *GameServer) OnChat(handler func(e *ChatEvent)) {
+	g.m.Lock()
+	g.chatHandlers = append(g.chatHandlers, handler)
+	g.m.Unlock()
+}
+
+// SetChatFormat sets the template of chat messages. Its {name} and
+// {message} are replaced, and it may use & formatting codes.
+func (g *GameServer) SetChatFormat(format string) {
+	g.m.Lock()
+	g.chatFormat = format
+	g.m.Unlock()
+}
+
+// validChat reports whether a message only has characters the client
+// lets players type.
+func validChat(message string) bool {
+	for _, r := range message {
+		if r < ' ' || r == 0x7f || r == '§' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (g *GameServer) handleChat(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+	message, err := r.ReadString()
+	if err != nil {
+		return
+	}
+
+	if len([]rune(message)) > maxChatLength || !validChat(message) {
+		g.kick(player, chat.Translate("multiplayer.disconnect.illegal_characters"))
+		return
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return
+	}
+
+	player.m.Lock()
+	chatMode := player.chatMode
+	player.m.Unlock()
+
+	if strings.HasPrefix(message, "/") {
+		if chatMode == chatModeHidden {
+			g.sendChatDisabled(player)
+			return
+		}
+		g.handleCommand(player, message[1:])
+		return
+	}
+
+	if chatMode != chatModeEnabled {
+		g.sendChatDisabled(player)
+		return
+	}
+
+	e := &ChatEvent{Player: player.name, Message: message}
+	g.m.RLock()
+	handlers := g.chatHandlers
+	format := g.chatFormat
+	g.m.RUnlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	if e.Cancelled {
+		return
+	}
+
+	log.Printf("[CHAT] <%s> %s", player.name, e.Message)
+	g.Broadcast(formatChat(format, player.name, e.Message), ChatPositionChat)
+}
+
+// sendChatDisabled tells a player why its message was not sent. It skips
+// the chat mode, which would hide it from players who hide chat.
+func (g *GameServer) sendChatDisabled(player *GamePlayer) {
+	c := chat.Translate("chat.cannotSend")
+	c.Color = chat.Red
+	player.sess.QueuePacket(chatPacket(c, ChatPositionSystem))
+}
+
+// formatChat fills the chat format. Formatting codes only apply to the
+// format, not to what players typed, which takes the style of the format
+// around it.
+func formatChat(format, name, message string) *chat.Component {
+	styled := chat.FromLegacy(format, '&')
+	parts := styled.Extra
+	if parts == nil {
+		parts = []*chat.Component{styled}
+	}
+
+	c := chat.Text("")
+	for _, part := range parts {
+		with := func(text string) *chat.Component {
+			child := *part
+			child.Text = text
+			return &child
+		}
+
+		text := part.Text
+		for text != "" {
+			i, placeholder := strings.Index(text, chatFormatName), chatFormatName
+			if j := strings.Index(text, chatFormatMessage); j >= 0 && (i < 0 || j < i) {
+				i, placeholder = j, chatFormatMessage
+			}
+			if i < 0 {
+				c.Append(with(text))
+				break
+			}
+
+			if i > 0 {
+				c.Append(with(text[:i]))
+			}
+			if placeholder == chatFormatName {
+				n := with(name)
+				n.Insertion = name
+				n.ClickEvent = &chat.ClickEvent{Action: "suggest_command", Value: "/tell " + name + " "}
+				c.Append(n)
+			} else {
+				c.Append(with(message))
+			}
+			text = text[i+len(placeholder):]
+		}
+	}
+
+	return c
+}
+
+func chatPacket(c *chat.Component, position byte) *packet.Packet {
+	p := packet.NewPacket(0x0f)
+	w := packet.NewWriter(p)
+	w.WriteString(c.String())
+	w.WriteUbyte(position)
+
+	return p
+}
+
+// sendMessage sends a message to a player unless its chat mode hides
+// it. Game info is always shown.
+func (g *GameServer) sendMessage(player *GamePlayer, c *chat.Component, position byte) {
+	player.m.Lock()
+	chatMode := player.chatMode
+	player.m.Unlock()
+
+	if (position == ChatPositionChat && chatMode != chatModeEnabled) ||
+		(position == ChatPositionSystem && chatMode == chatModeHidden) {
+		return
+	}
+
+	player.sess.QueuePacket(chatPacket(c, position))
+}
+
+// Broadcast sends a message to every player.
+func (g *GameServer) Broadcast(c *chat.Component, position byte) {
+	for _, player := range g.spawnedPlayers() {
+		g.sendMessage(player, c, position)
+	}
+}
+
+// SendMessage sends a message to the player with the given name.
+func (g *GameServer) SendMessage(name string, c *chat.Component, position byte) bool {
+	player := g.playerByName(name)
+	if player == nil {
+		return false
+	}
+
+	g.sendMessage(player, c, position)
+	return true
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	gonet "net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skdltmxn/go-mine/net"
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/util/chat"
+)
+
+func TestFormatChat(t *testing.T) {
+	c := formatChat("&7[{name}]&r {message} {x}", "Steve", "&chi")
+	expected := `{"text":"","extra":[{"text":"[","color":"gray"},{"text":"Steve","color":"gray","insertion":"Steve","clickEvent":{"action":"suggest_command","value":"/tell Steve "}},{"text":"]","color":"gray"},{"text":" "},{"text":"&chi"},{"text":" {x}"}]}`
+	if s := c.String(); s != expected {
+		t.Fatalf("unexpected component: %s", s)
+	}
+
+	if validChat("hi\x07") || validChat("§ahi") || !validChat("héllo ✓") {
+		t.Fatalf("validChat failed")
+	}
+}
+
+func TestChatHidden(t *testing.T) {
+	server, client := gonet.Pipe()
+	defer client.Close()
+
+	g := &GameServer{}
+	player := &GamePlayer{sess: net.NewSession(server), chatMode: chatModeHidden}
+
+	p := packet.NewPacket(0x03)
+	packet.NewWriter(p).WriteString("hello")
+
+	// hidden, so only the feedback of the chat message is sent
+	g.sendMessage(player, chat.Text("system"), ChatPositionSystem)
+	g.handleChat(player, p)
+	player.sess.Flush()
+
+	buf := make([]byte, 1024)
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("Read failed: %s", err)
+	}
+
+	res, _ := packet.ParsePacket(buf[:n])
+	if res == nil || res.Id() != 0x0f {
+		t.Fatalf("unexpected packet: %+v", res)
+	}
+	message, _ := packet.NewReader(res).ReadString()
+	if !strings.Contains(message, "chat.cannotSend") {
+		t.Fatalf("unexpected message: %s", message)
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"errors"
+	"math"
+
+	"github.com/skdltmxn/go-mine/world"
+	"github.com/skdltmxn/go-mine/world/block"
+)
+
+// size of the player bounding box
+const (
+	playerWidth  = 0.6
+	playerHeight = 1.8
+)
+
+// Move is a position change sent by a client, before it is applied.
+type Move struct {
+	FromX, FromY, FromZ float64
+	ToX, ToY, ToZ       float64
+
+	GameMode uint8
+	OnGround bool // as checked by the server
+
+	// consecutive moves, including this one, that ended with the player
+	// neither on the ground nor in a liquid or climbing
+	AirTicks int
+}
+
+func (m *Move) Distance() float64 {
+	dx, dy, dz := m.ToX-m.FromX, m.ToY-m.FromY, m.ToZ-m.FromZ
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// players cannot go past the world border at this distance
+const maxHorizontal = 3.0e7
+
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valid reports whether the destination and rotation are numbers the
+// checks can work with.
+func (m *Move) valid(yaw, pitch float32) bool {
+	return finite(m.ToX, m.ToY, m.ToZ, float64(yaw), float64(pitch)) &&
+		math.Abs(m.ToX) <= maxHorizontal && math.Abs(m.ToZ) <= maxHorizontal
+}
+
+// MovementCheck validates the moves of players. A rejected move sets the
+// player back to where it was.
+type MovementCheck interface {
+	Check(w *world.World, m *Move) error
+}
+
+var (
+	ErrMovedTooQuickly = errors.New("moved too quickly")
+	ErrMovedWrongly    = errors.New("moved wrongly")
+	ErrFlying          = errors.New("flying is not enabled")
+	ErrInvalidMove     = errors.New("sent an invalid move")
+)
+
+// SpeedCheck rejects moves longer than MaxDistance blocks.
+type SpeedCheck struct {
+	MaxDistance float64
+}
+
+func (c *SpeedCheck) Check(w *world.World, m *Move) error {
+	if m.Distance() > c.MaxDistance {
+		return ErrMovedTooQuickly
+	}
+
+	return nil
+}
+
+// NoClipCheck rejects moves through solid blocks. Blocks that fill only
+// part of their space, like slabs and doors, are not checked.
+type NoClipCheck struct{}
+
+// distance between the points of a move tested for collision
+const noClipStep = 0.25
+
+func (c *NoClipCheck) Check(w *world.World, m *Move) error {
+	if m.GameMode == GameModeSpectator {
+		return nil
+	}
+
+	// a player stuck in a block may move out of it
+	if collides(w, playerBox(m.FromX, m.FromY, m.FromZ)) {
+		return nil
+	}
+
+	steps := int(math.Ceil(m.Distance() / noClipStep))
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := m.FromX + (m.ToX-m.FromX)*t
+		y := m.FromY + (m.ToY-m.FromY)*t
+		z := m.FromZ + (m.ToZ-m.FromZ)*t
+		if collides(w, playerBox(x, y, z)) {
+			return ErrMovedWrongly
+		}
+	}
+
+	return nil
+}
+
+// FlyCheck rejects players of survival and adventure mode who stay in the
+// air without falling for more than MaxAirTicks moves.
+type FlyCheck struct {
+	MaxAirTicks int
+}
+
+func (c *FlyCheck) Check(w *world.World, m *Move) error {
+	if m.GameMode == GameModeCreative || m.GameMode == GameModeSpectator {
+		return nil
+	}
+
+	if m.AirTicks > c.MaxAirTicks && m.ToY >= m.FromY {
+		return ErrFlying
+	}
+
+	return nil
+}
+
+// DefaultMovementChecks returns the checks with the thresholds of the
+// vanilla server.
+func DefaultMovementChecks() []MovementCheck {
+	return []MovementCheck{
+		&SpeedCheck{MaxDistance: 10},
+		&NoClipCheck{},
+		&FlyCheck{MaxAirTicks: 20},
+	}
+}
+
+type box struct {
+	minX, minY, minZ float64
+	maxX, maxY, maxZ float64
+}
+
+// the box is shrunk a little so that touching a block is no collision
+const boxEpsilon = 1e-3
+
+func playerBox(x, y, z float64) box {
+	const r = playerWidth/2 - boxEpsilon
+	return box{x - r, y + boxEpsilon, z - r, x + r, y + playerHeight - boxEpsilon, z + r}
+}
+
+// forEachBlock calls fn with the blocks the box intersects until fn
+// returns true.
+func forEachBlock(w *world.World, b box, fn func(state int32) bool) bool {
+	for x := int(math.Floor(b.minX)); x <= int(math.Floor(b.maxX)); x++ {
+		for y := int(math.Floor(b.minY)); y <= int(math.Floor(b.maxY)); y++ {
+			for z := int(math.Floor(b.minZ)); z <= int(math.Floor(b.maxZ)); z++ {
+				if fn(w.Block(x, y, z)) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func collides(w *world.World, b box) bool {
+	return forEachBlock(w, b, block.Solid)
+}
+
+// onGround reports whether there is a block right under the feet. Any
+// block with a collision shape counts, since a player may stand on top of
+// slabs, stairs and carpets inside their space.
+func onGround(w *world.World, x, y, z float64) bool {
+	b := playerBox(x, y, z)
+	b.minY, b.maxY = y-0.05, y-boxEpsilon
+	if forEachBlock(w, b, block.Collides) {
+		return true
+	}
+
+	// fences and walls are one and a half blocks high
+	b.minY, b.maxY = y-0.55, y-0.45
+	return forEachBlock(w, b, block.Tall)
+}
+
+var (
+	liquidStates    = map[*block.Block]bool{}
+	climbableStates = map[*block.Block]bool{}
+)
+
+func init() {
+	for _, name := range []string{"water", "lava", "bubble_column"} {
+		if b := block.ByName(name); b != nil {
+			liquidStates[b] = true
+		}
+	}
+	for _, name := range []string{"ladder", "vine", "scaffolding"} {
+		if b := block.ByName(name); b != nil {
+			climbableStates[b] = true
+		}
+	}
+}
+
+// inLiquid reports whether the player is in a liquid, which stops falls.
+func inLiquid(w *world.World, x, y, z float64) bool {
+	return forEachBlock(w, playerBox(x, y, z), func(state int32) bool {
+		return liquidStates[block.ByState(state)]
+	})
+}
+
+// supported reports whether the player may stay at a height without
+// falling.
+func supported(w *world.World, x, y, z float64) bool {
+	if onGround(w, x, y, z) || inLiquid(w, x, y, z) {
+		return true
+	}
+
+	return forEachBlock(w, playerBox(x, y, z), func(state int32) bool {
+		return climbableStates[block.ByState(state)]
+	})
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+
+	"github.com/skdltmxn/go-mine/world"
+	"github.com/skdltmxn/go-mine/world/block"
+)
+
+func TestMovementChecks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "world")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// ground at y=4
+	w, err := world.Open(dir, world.Settings{Generator: "flat", Layers: "bedrock,2*dirt,grass_block"})
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	defer w.Close()
+	w.Retain(0, 0)
+	w.SetBlock(8, 4, 10, 1)
+	w.SetBlock(8, 5, 10, 1)
+
+	// an open door, stairs and a fence
+	door, _ := block.Parse("oak_door[half=lower,open=true]")
+	stairs, _ := block.Parse("oak_stairs[half=bottom]")
+	fence, _ := block.Parse("oak_fence")
+	w.SetBlock(4, 4, 10, door)
+	w.SetBlock(4, 4, 12, stairs)
+	w.SetBlock(2, 4, 2, fence)
+
+	speed := &SpeedCheck{MaxDistance: 10}
+	noClip := &NoClipCheck{}
+	fly := &FlyCheck{MaxAirTicks: 20}
+
+	cases := []struct {
+		check MovementCheck
+		move  Move
+		err   error
+	}{
+		{speed, Move{FromX: 8.5, FromY: 4, FromZ: 8.5, ToX: 8.5, ToY: 4, ToZ: 12.5}, nil},
+		{speed, Move{FromX: 0.5, FromY: 4, FromZ: 0.5, ToX: 15.5, ToY: 4, ToZ: 0.5}, ErrMovedTooQuickly},
+		{noClip, Move{FromX: 8.5, FromY: 4, FromZ: 8.5, ToX: 8.5, ToY: 4, ToZ: 12.5}, ErrMovedWrongly},
+		{noClip, Move{FromX: 8.5, FromY: 6, FromZ: 8.5, ToX: 8.5, ToY: 6, ToZ: 12.5}, nil},
+		{noClip, Move{FromX: 8.5, FromY: 4, FromZ: 8.5, ToX: 8.5, ToY: 3.5, ToZ: 8.5}, ErrMovedWrongly},
+		{noClip, Move{FromX: 4.5, FromY: 4, FromZ: 8.5, ToX: 4.5, ToY: 4, ToZ: 11.5}, nil},
+		{noClip, Move{FromX: 4.5, FromY: 4, FromZ: 11.5, ToX: 4.5, ToY: 4.5, ToZ: 12.5}, nil},
+		{fly, Move{FromY: 10, ToY: 10, AirTicks: 21}, ErrFlying},
+		{fly, Move{FromY: 10, ToY: 9, AirTicks: 21}, nil},
+		{fly, Move{FromY: 10, ToY: 10, AirTicks: 21, GameMode: GameModeCreative}, nil},
+	}
+
+	for i, c := range cases {
+		if err := c.check.Check(w, &c.move); err != c.err {
+			t.Fatalf("case %d: unexpected result: %v", i, err)
+		}
+	}
+
+	if !onGround(w, 0.5, 4, 0.5) || onGround(w, 0.5, 4.5, 0.5) {
+		t.Fatalf("onGround failed")
+	}
+	if !onGround(w, 4.5, 4.5, 12.5) || !onGround(w, 2.5, 5.5, 2.5) {
+		t.Fatalf("onGround failed on partial blocks")
+	}
+}
+
+func TestReadMove(t *testing.T) {
+	position := func(x, y, z float64) *packet.Packet {
+		p := packet.NewPacket(0x11)
+		w := packet.NewWriter(p)
+		w.WriteDouble(x)
+		w.WriteDouble(y)
+		w.WriteDouble(z)
+		w.WriteBool(true)
+		return p
+	}
+
+	cases := []struct {
+		p   *packet.Packet
+		err error
+	}{
+		{position(8.5, 4, 8.5), nil},
+		{position(math.NaN(), 4, 8.5), ErrInvalidMove},
+		{position(8.5, math.Inf(1), 8.5), ErrInvalidMove},
+		{position(8.5, 4, -3.1e7), ErrInvalidMove},
+		{packet.NewPacket(0x11), ErrInvalidMove},
+	}
+
+	for i, c := range cases {
+		var yaw, pitch float32
+		m := Move{}
+		if _, err := readMove(packet.NewReader(c.p), c.p.Id(), &m, &yaw, &pitch); err != c.err {
+			t.Fatalf("readMove failed on case %d: %v", i, err)
+		}
+	}
+
+	// a NaN rotation is as bad as a NaN position
+	p := packet.NewPacket(0x13)
+	w := packet.NewWriter(p)
+	w.WriteFloat(float32(math.NaN()))
+	w.WriteFloat(0)
+	w.WriteBool(true)
+
+	var yaw, pitch float32
+	m := Move{ToX: 8.5, ToY: 4, ToZ: 8.5}
+	if _, err := readMove(packet.NewReader(p), p.Id(), &m, &yaw, &pitch); err != ErrInvalidMove {
+		t.Fatalf("readMove failed on NaN yaw: %v", err)
+	}
+}
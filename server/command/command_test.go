@@ -0,0 +1,105 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/skdltmxn/go-mine/util/chat"
+)
+
+type testSource struct {
+	permission int
+}
+
+func (s *testSource) Name() string                  { return "test" }
+func (s *testSource) PermissionLevel() int          { return s.permission }
+func (s *testSource) SendMessage(c *chat.Component) {}
+func (s *testSource) Position() (x, y, z float64, ok bool) {
+	return 10.5, 64, -3.5, true
+}
+
+func TestDispatcher(t *testing.T) {
+	d := NewDispatcher()
+
+	var result string
+	d.Register(Literal("gamemode").Requires(PermissionGameMaster).Then(
+		Argument("mode", GameMode()).Executes(func(ctx *Context) error {
+			result = gameModes[ctx.GameMode("mode")]
+			return nil
+		}).Then(
+			Argument("target", Entity(PlayersOnly, func() []string { return []string{"Steve"} })).Executes(func(ctx *Context) error {
+				result = gameModes[ctx.GameMode("mode")] + " " + ctx.Selector("target").Name
+				return nil
+			}),
+		),
+	))
+	d.Register(Literal("setblock").Then(
+		Argument("pos", BlockPosition()).Then(
+			Argument("count", Integer(1, 64)).Executes(func(ctx *Context) error {
+				pos := ctx.BlockPos("pos")
+				if pos != (BlockPos{10, 60, -4}) || ctx.Int("count") != 3 {
+					t.Fatalf("unexpected arguments: %+v %d", pos, ctx.Int("count"))
+				}
+				result = "setblock"
+				return nil
+			}),
+		),
+	))
+
+	d.Register(Literal("tp").Then(
+		Argument("location", Position()).Executes(func(ctx *Context) error {
+			if v := ctx.Vec3("location"); v != (Vec3{1.5, 65, -2.25}) {
+				t.Fatalf("unexpected location: %+v", v)
+			}
+			result = "tp"
+			return nil
+		}),
+	))
+
+	op := &testSource{PermissionGameMaster}
+	user := &testSource{PermissionAll}
+
+	cases := []struct {
+		src    Source
+		input  string
+		result string
+		ok     bool
+	}{
+		{op, "gamemode creative", "creative", true},
+		{op, "gamemode spectator Steve", "spectator Steve", true},
+		{op, "gamemode flying", "", false},
+		{op, "gamemode creative @e", "", false},
+		{user, "gamemode creative", "", false},
+		{user, "setblock ~ ~-4 -4 3", "setblock", true},
+		{user, "setblock 1 2 3 100", "", false},
+		{user, "setblock 1 2", "", false},
+		{user, "unknown", "", false},
+		{user, "tp 1 ~1 -2.25", "tp", true},
+		{user, "tp 1 x 2", "", false},
+		{user, "tp NaN 0 0", "", false},
+		{user, "tp 0 ~Inf 0", "", false},
+		{user, "tp 0 -infinity 0", "", false},
+	}
+
+	for _, c := range cases {
+		result = ""
+		err := d.Execute(c.src, c.input)
+		if (err == nil) != c.ok || result != c.result {
+			t.Fatalf("Execute(%q) failed: %v / %q", c.input, err, result)
+		}
+	}
+
+	if start, s := d.Suggest(op, "gamemode s"); start != 9 || len(s) != 2 || s[0] != "spectator" || s[1] != "survival" {
+		t.Fatalf("unexpected suggestions: %d %v", start, s)
+	}
+	if start, s := d.Suggest(op, "gamemode creative S"); start != 18 || len(s) != 1 || s[0] != "Steve" {
+		t.Fatalf("unexpected suggestions: %d %v", start, s)
+	}
+	if _, s := d.Suggest(user, ""); len(s) != 2 || s[0] != "setblock" || s[1] != "tp" {
+		t.Fatalf("unexpected suggestions: %v", s)
+	}
+
+	err := d.Execute(op, "gamemode flying")
+	if e, ok := err.(*SyntaxError); !ok || e.Context() != "gamemode <--[HERE]" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
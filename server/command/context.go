@@ -0,0 +1,83 @@
+package command
+
+import "github.com/skdltmxn/go-mine/util/chat"
+
+// permission levels of the vanilla server
+const (
+	PermissionAll        = 0
+	PermissionModerator  = 1
+	PermissionGameMaster = 2
+	PermissionAdmin      = 3
+	PermissionOwner      = 4
+)
+
+// Source is who runs a command.
+type Source interface {
+	Name() string
+	PermissionLevel() int
+	SendMessage(c *chat.Component)
+
+	// Position returns where the source is, which relative coordinates
+	// are based on.
+	Position() (x, y, z float64, ok bool)
+}
+
+// Context is a command being run with its parsed arguments.
+type Context struct {
+	Source Source
+	Input  string
+	args   map[string]interface{}
+}
+
+func (ctx *Context) Has(name string) bool {
+	_, ok := ctx.args[name]
+	return ok
+}
+
+// Arg returns the value of an argument, nil if it was not given.
+func (ctx *Context) Arg(name string) interface{} {
+	return ctx.args[name]
+}
+
+func (ctx *Context) Int(name string) int {
+	v, _ := ctx.args[name].(int)
+	return v
+}
+
+func (ctx *Context) Float(name string) float64 {
+	v, _ := ctx.args[name].(float64)
+	return v
+}
+
+func (ctx *Context) String(name string) string {
+	v, _ := ctx.args[name].(string)
+	return v
+}
+
+func (ctx *Context) Selector(name string) Selector {
+	v, _ := ctx.args[name].(Selector)
+	return v
+}
+
+func (ctx *Context) BlockPos(name string) BlockPos {
+	v, _ := ctx.args[name].(BlockPos)
+	return v
+}
+
+func (ctx *Context) Vec3(name string) Vec3 {
+	v, _ := ctx.args[name].(Vec3)
+	return v
+}
+
+func (ctx *Context) GameMode(name string) uint8 {
+	v, _ := ctx.args[name].(uint8)
+	return v
+}
+
+// Error is the error of a command that failed; it is shown to the source
+// as is.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
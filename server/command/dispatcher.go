@@ -0,0 +1,267 @@
+// Package command parses and runs commands modeled on Brigadier, the
+// command library of the client, which it describes the commands to.
+package command
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+)
+
+type Dispatcher struct {
+	root *Node
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{root: &Node{}}
+}
+
+// Register adds a command, whose node must be a literal. A command
+// registered again replaces the previous one.
+func (d *Dispatcher) Register(n *Node) {
+	for i, child := range d.root.children {
+		if child.name == n.name {
+			d.root.children[i] = n
+			return
+		}
+	}
+
+	d.root.children = append(d.root.children, n)
+}
+
+// Commands returns the names of the commands a source may run.
+func (d *Dispatcher) Commands(src Source) []string {
+	var names []string
+	for _, child := range d.root.children {
+		if child.allowed(src) {
+			names = append(names, child.name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Execute parses and runs a command without the leading slash.
+func (d *Dispatcher) Execute(src Source, input string) error {
+	ctx := &Context{Source: src, Input: input, args: make(map[string]interface{})}
+	r := NewReader(input)
+
+	n, err := d.parse(d.root, r, ctx)
+	if err != nil {
+		return err
+	}
+	if n.executes == nil {
+		return r.Error("Unknown or incomplete command")
+	}
+
+	return n.executes(ctx)
+}
+
+// candidates returns the children to try at the reader. A literal that
+// matches wins over arguments.
+func candidates(n *Node, r *Reader) []*Node {
+	word := r.Remaining()
+	if i := strings.IndexByte(word, ' '); i >= 0 {
+		word = word[:i]
+	}
+
+	for _, child := range n.children {
+		if child.isLiteral() && child.name == word {
+			return []*Node{child}
+		}
+	}
+
+	var args []*Node
+	for _, child := range n.children {
+		if !child.isLiteral() {
+			args = append(args, child)
+		}
+	}
+
+	return args
+}
+
+// parse reads the input under a node and returns the node the input ends
+// at. Children are tried in order until one reads the rest.
+func (d *Dispatcher) parse(n *Node, r *Reader, ctx *Context) (*Node, error) {
+	var lastErr error
+
+	for _, child := range candidates(n, r) {
+		if !child.allowed(ctx.Source) {
+			continue
+		}
+
+		start := r.Cursor()
+		args := make(map[string]interface{}, len(ctx.args))
+		for k, v := range ctx.args {
+			args[k] = v
+		}
+		restore := func() {
+			r.pos = start
+			ctx.args = args
+		}
+
+		if err := child.consume(r, ctx); err != nil {
+			lastErr = err
+			restore()
+			continue
+		}
+
+		if !r.CanRead() {
+			return child, nil
+		}
+		if r.Peek() != ' ' {
+			lastErr = r.Error("Expected whitespace to end one argument, but found trailing data")
+			restore()
+			continue
+		}
+		r.Skip()
+
+		found, err := d.parse(child, r, ctx)
+		if err == nil {
+			return found, nil
+		}
+		lastErr = err
+		restore()
+	}
+
+	if lastErr == nil {
+		if n == d.root {
+			lastErr = r.Error("Unknown command")
+		} else {
+			lastErr = r.Error("Incorrect argument for command")
+		}
+	}
+
+	return nil, lastErr
+}
+
+// Suggest completes the last word of the input. It returns where the
+// completed word starts and the completions.
+func (d *Dispatcher) Suggest(src Source, input string) (int, []string) {
+	ctx := &Context{Source: src, Input: input, args: make(map[string]interface{})}
+
+	start := len(input)
+	var suggestions []string
+	d.suggest(d.root, NewReader(input), ctx, &start, &suggestions)
+
+	sort.Strings(suggestions)
+	return start, suggestions
+}
+
+func (d *Dispatcher) suggest(n *Node, r *Reader, ctx *Context, start *int, suggestions *[]string) {
+	rest := r.Remaining()
+
+	// only the last word is completed
+	if strings.IndexByte(rest, ' ') < 0 {
+		var found []string
+		for _, child := range n.children {
+			if !child.allowed(ctx.Source) {
+				continue
+			}
+
+			if child.isLiteral() {
+				found = append(found, filter([]string{child.name}, rest)...)
+			} else {
+				found = append(found, child.suggestions(ctx, rest)...)
+			}
+		}
+
+		if len(found) > 0 {
+			if len(*suggestions) == 0 || r.Cursor() > *start {
+				*start = r.Cursor()
+				*suggestions = nil
+			}
+			if r.Cursor() == *start {
+				*suggestions = append(*suggestions, found...)
+			}
+		}
+	}
+
+	for _, child := range candidates(n, r) {
+		if !child.allowed(ctx.Source) {
+			continue
+		}
+
+		pos := r.Cursor()
+		if child.consume(r, ctx) == nil && r.CanRead() && r.Peek() == ' ' {
+			r.Skip()
+			d.suggest(child, r, ctx, start, suggestions)
+		}
+		r.pos = pos
+	}
+}
+
+// flags of Declare Commands nodes
+const (
+	nodeRoot           = 0x00
+	nodeLiteral        = 0x01
+	nodeArgument       = 0x02
+	nodeExecutable     = 0x04
+	nodeHasSuggestions = 0x10
+)
+
+// DeclarePacket returns the Declare Commands packet with the commands a
+// source may run.
+func (d *Dispatcher) DeclarePacket(src Source) *packet.Packet {
+	// number the nodes breadth first
+	nodes := []*Node{d.root}
+	index := map[*Node]int{d.root: 0}
+	for i := 0; i < len(nodes); i++ {
+		for _, child := range nodes[i].children {
+			if _, ok := index[child]; !ok && child.allowed(src) {
+				index[child] = len(nodes)
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	p := packet.NewPacket(0x12)
+	w := packet.NewWriter(p)
+
+	w.WriteVarint(len(nodes))
+	for i, n := range nodes {
+		flags := uint8(nodeRoot)
+		if i > 0 {
+			if n.isLiteral() {
+				flags = nodeLiteral
+			} else {
+				flags = nodeArgument
+				if n.asksServer() {
+					flags |= nodeHasSuggestions
+				}
+			}
+		}
+		if n.executes != nil {
+			flags |= nodeExecutable
+		}
+		w.WriteUbyte(flags)
+
+		var children []int
+		for _, child := range n.children {
+			if j, ok := index[child]; ok {
+				children = append(children, j)
+			}
+		}
+		w.WriteVarint(len(children))
+		for _, j := range children {
+			w.WriteVarint(j)
+		}
+
+		if i > 0 {
+			w.WriteString(n.name)
+		}
+		if flags&nodeArgument != 0 {
+			w.WriteString(n.parser.ID())
+			n.parser.WriteProperties(w)
+		}
+		if flags&nodeHasSuggestions != 0 {
+			w.WriteString("minecraft:ask_server")
+		}
+	}
+	w.WriteVarint(0) // root
+
+	return p
+}
@@ -0,0 +1,104 @@
+package command
+
+// Node is a node of the command tree. Literals match a fixed word and
+// arguments parse a value; a command is a path from the root.
+type Node struct {
+	name       string
+	parser     Parser // nil for literals and the root
+	children   []*Node
+	executes   func(ctx *Context) error
+	permission int
+	suggests   func(ctx *Context, prefix string) []string
+}
+
+// Literal returns a node matching its name.
+func Literal(name string) *Node {
+	return &Node{name: name}
+}
+
+// Argument returns a node parsing a value stored in the context under
+// its name.
+func Argument(name string, parser Parser) *Node {
+	return &Node{name: name, parser: parser}
+}
+
+// Then adds children and returns the node.
+func (n *Node) Then(children ...*Node) *Node {
+	n.children = append(n.children, children...)
+	return n
+}
+
+// Executes makes the input ending at this node a complete command.
+func (n *Node) Executes(fn func(ctx *Context) error) *Node {
+	n.executes = fn
+	return n
+}
+
+// Requires hides the node and its children from sources below the given
+// permission level.
+func (n *Node) Requires(permission int) *Node {
+	n.permission = permission
+	return n
+}
+
+// Suggests completes the argument with fn instead of its parser.
+func (n *Node) Suggests(fn func(ctx *Context, prefix string) []string) *Node {
+	n.suggests = fn
+	return n
+}
+
+func (n *Node) Name() string {
+	return n.name
+}
+
+func (n *Node) isLiteral() bool {
+	return n.parser == nil
+}
+
+func (n *Node) allowed(src Source) bool {
+	return src.PermissionLevel() >= n.permission
+}
+
+// suggestions returns the completions of the argument or nil if it
+// cannot complete.
+func (n *Node) suggestions(ctx *Context, prefix string) []string {
+	if n.suggests != nil {
+		return n.suggests(ctx, prefix)
+	}
+	if s, ok := n.parser.(Suggester); ok {
+		return s.Suggest(ctx, prefix)
+	}
+
+	return nil
+}
+
+// asksServer reports whether the client should ask the server to
+// complete the argument.
+func (n *Node) asksServer() bool {
+	if n.suggests != nil {
+		return true
+	}
+
+	_, ok := n.parser.(Suggester)
+	return ok
+}
+
+// consume reads the literal or argument of the node.
+func (n *Node) consume(r *Reader, ctx *Context) error {
+	if n.isLiteral() {
+		start := r.Cursor()
+		if r.ReadWord() != n.name {
+			r.pos = start
+			return r.Error("Incorrect argument for command")
+		}
+		return nil
+	}
+
+	v, err := n.parser.Parse(r, ctx)
+	if err != nil {
+		return err
+	}
+	ctx.args[n.name] = v
+
+	return nil
+}
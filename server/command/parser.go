@@ -0,0 +1,453 @@
+package command
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+)
+
+// Parser reads the value of an argument. Its ID and properties tell the
+// client how to highlight and complete the argument.
+type Parser interface {
+	Parse(r *Reader, ctx *Context) (interface{}, error)
+	ID() string
+	WriteProperties(w *packet.Writer)
+}
+
+// Suggester is implemented by parsers that complete their values.
+// Arguments using them ask the server for suggestions.
+type Suggester interface {
+	Suggest(ctx *Context, prefix string) []string
+}
+
+type integerParser struct {
+	min, max int
+}
+
+// Integer parses an integer between min and max inclusive.
+func Integer(min, max int) Parser {
+	return &integerParser{min, max}
+}
+
+func (p *integerParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	start := r.Cursor()
+	v, err := r.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+
+	if v < p.min {
+		r.pos = start
+		return nil, r.Error("Integer must not be less than " + strconv.Itoa(p.min) + ", found " + strconv.Itoa(v))
+	}
+	if v > p.max {
+		r.pos = start
+		return nil, r.Error("Integer must not be more than " + strconv.Itoa(p.max) + ", found " + strconv.Itoa(v))
+	}
+
+	return v, nil
+}
+
+func (p *integerParser) ID() string {
+	return "brigadier:integer"
+}
+
+func (p *integerParser) WriteProperties(w *packet.Writer) {
+	flags := uint8(0)
+	if p.min > math.MinInt32 {
+		flags |= 0x01
+	}
+	if p.max < math.MaxInt32 {
+		flags |= 0x02
+	}
+
+	w.WriteUbyte(flags)
+	if flags&0x01 != 0 {
+		w.WriteInt(int32(p.min))
+	}
+	if flags&0x02 != 0 {
+		w.WriteInt(int32(p.max))
+	}
+}
+
+type floatParser struct {
+	min, max float64
+}
+
+// Float parses a number between min and max inclusive.
+func Float(min, max float64) Parser {
+	return &floatParser{min, max}
+}
+
+func (p *floatParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	start := r.Cursor()
+	v, err := r.ReadFloat()
+	if err != nil {
+		return nil, err
+	}
+
+	if v < p.min || v > p.max {
+		r.pos = start
+		return nil, r.Error("Float must be between " + formatFloat(p.min) + " and " + formatFloat(p.max) + ", found " + formatFloat(v))
+	}
+
+	return v, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (p *floatParser) ID() string {
+	return "brigadier:float"
+}
+
+func (p *floatParser) WriteProperties(w *packet.Writer) {
+	flags := uint8(0)
+	if p.min > -math.MaxFloat32 {
+		flags |= 0x01
+	}
+	if p.max < math.MaxFloat32 {
+		flags |= 0x02
+	}
+
+	w.WriteUbyte(flags)
+	if flags&0x01 != 0 {
+		w.WriteFloat(float32(p.min))
+	}
+	if flags&0x02 != 0 {
+		w.WriteFloat(float32(p.max))
+	}
+}
+
+// kinds of string arguments
+const (
+	SingleWord = iota
+	QuotablePhrase
+	GreedyPhrase
+)
+
+type stringParser struct {
+	kind int
+}
+
+// String parses a word, a phrase that may be quoted or the rest of the
+// input.
+func String(kind int) Parser {
+	return &stringParser{kind}
+}
+
+func (p *stringParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	switch p.kind {
+	case GreedyPhrase:
+		return r.ReadRemaining(), nil
+	case QuotablePhrase:
+		return r.ReadString()
+	}
+
+	return r.ReadWord(), nil
+}
+
+func (p *stringParser) ID() string {
+	return "brigadier:string"
+}
+
+func (p *stringParser) WriteProperties(w *packet.Writer) {
+	w.WriteVarint(p.kind)
+}
+
+// Selector is an entity selector like @a or a player name. It is resolved
+// by whoever runs the command.
+type Selector struct {
+	Type byte   // p, a, r, s or e; 0 for a name
+	Name string // of the player if Type is 0
+}
+
+// Single reports whether the selector selects at most one entity.
+func (s Selector) Single() bool {
+	return s.Type == 0 || s.Type == 'p' || s.Type == 'r' || s.Type == 's'
+}
+
+// flags of entity arguments
+const (
+	SingleEntity = 0x01
+	PlayersOnly  = 0x02
+)
+
+type entityParser struct {
+	flags uint8
+	names func() []string
+}
+
+// Entity parses an entity selector. Names suggests player names.
+func Entity(flags uint8, names func() []string) Parser {
+	return &entityParser{flags, names}
+}
+
+func (p *entityParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	start := r.Cursor()
+	word := r.ReadWord()
+
+	var s Selector
+	switch {
+	case word == "":
+		return nil, r.Error("Invalid name or UUID")
+	case word[0] == '@':
+		if len(word) != 2 || strings.IndexByte("parse", word[1]) < 0 {
+			r.pos = start
+			return nil, r.Error("Unknown selector type '" + word + "'")
+		}
+		s.Type = word[1]
+	default:
+		if len(word) > 16 {
+			r.pos = start
+			return nil, r.Error("Invalid name or UUID")
+		}
+		s.Name = word
+	}
+
+	if p.flags&SingleEntity != 0 && !s.Single() {
+		r.pos = start
+		return nil, r.Error("Only one entity is allowed, but the provided selector allows more than one")
+	}
+	if p.flags&PlayersOnly != 0 && s.Type == 'e' {
+		r.pos = start
+		return nil, r.Error("Only players may be affected by this command, but the provided selector includes entities")
+	}
+
+	return s, nil
+}
+
+func (p *entityParser) Suggest(ctx *Context, prefix string) []string {
+	var names []string
+	if p.names != nil {
+		names = p.names()
+	}
+
+	return filter(append([]string{"@p", "@a", "@r", "@s", "@e"}, names...), prefix)
+}
+
+func (p *entityParser) ID() string {
+	return "minecraft:entity"
+}
+
+func (p *entityParser) WriteProperties(w *packet.Writer) {
+	w.WriteUbyte(p.flags)
+}
+
+// BlockPos is the position of a block.
+type BlockPos struct {
+	X, Y, Z int
+}
+
+// Vec3 is a position in the world.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+// readCoordinates reads three coordinates, which may be relative to the
+// source with ~. Integer coordinates are at the center of a block if
+// centered is set.
+func readCoordinates(r *Reader, ctx *Context, integers, centered bool) ([3]float64, error) {
+	var coords [3]float64
+	for i := range coords {
+		if i > 0 {
+			if !r.CanRead() || r.Peek() != ' ' {
+				return coords, r.Error("Incomplete (expected 3 coordinates)")
+			}
+			r.Skip()
+		}
+
+		start := r.Cursor()
+		word := r.ReadWord()
+
+		relative := strings.HasPrefix(word, "~")
+		if relative {
+			word = word[1:]
+		}
+
+		offset := 0.0
+		if word != "" {
+			v, ok := parseFloat(word)
+			decimal := strings.IndexByte(word, '.') >= 0
+			if !ok || (integers && !relative && decimal) {
+				r.pos = start
+				if integers {
+					return coords, r.Error("Expected integer")
+				}
+				return coords, r.Error("Expected float")
+			}
+			offset = v
+
+			// x and z of a block are its center
+			if centered && !relative && !decimal && i != 1 {
+				offset += 0.5
+			}
+		}
+
+		if relative {
+			x, y, z, ok := ctx.Source.Position()
+			if !ok {
+				r.pos = start
+				return coords, r.Error("Relative coordinates need a position")
+			}
+			offset += [3]float64{x, y, z}[i]
+		}
+		coords[i] = offset
+	}
+
+	return coords, nil
+}
+
+type blockPosParser struct{}
+
+// BlockPosition parses the three integer coordinates of a block.
+func BlockPosition() Parser {
+	return blockPosParser{}
+}
+
+func (blockPosParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	c, err := readCoordinates(r, ctx, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return BlockPos{int(math.Floor(c[0])), int(math.Floor(c[1])), int(math.Floor(c[2]))}, nil
+}
+
+func (blockPosParser) ID() string {
+	return "minecraft:block_pos"
+}
+
+func (blockPosParser) WriteProperties(w *packet.Writer) {}
+
+type vec3Parser struct{}
+
+// Position parses three coordinates. Integer x and z are the center of a
+// block.
+func Position() Parser {
+	return vec3Parser{}
+}
+
+func (vec3Parser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	c, err := readCoordinates(r, ctx, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return Vec3{c[0], c[1], c[2]}, nil
+}
+
+func (vec3Parser) ID() string {
+	return "minecraft:vec3"
+}
+
+func (vec3Parser) WriteProperties(w *packet.Writer) {}
+
+type gameProfileParser struct {
+	names func() []string
+}
+
+// GameProfile parses a player selector or the name of a player who may
+// be offline.
+func GameProfile(names func() []string) Parser {
+	return &gameProfileParser{names}
+}
+
+func (p *gameProfileParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	return (&entityParser{PlayersOnly, nil}).Parse(r, ctx)
+}
+
+func (p *gameProfileParser) Suggest(ctx *Context, prefix string) []string {
+	return (&entityParser{PlayersOnly, p.names}).Suggest(ctx, prefix)
+}
+
+func (p *gameProfileParser) ID() string {
+	return "minecraft:game_profile"
+}
+
+func (p *gameProfileParser) WriteProperties(w *packet.Writer) {}
+
+type itemParser struct {
+	valid func(name string) bool
+}
+
+// Item parses the name of an item, which valid accepts. The value is the
+// name with its namespace.
+func Item(valid func(name string) bool) Parser {
+	return &itemParser{valid}
+}
+
+func (p *itemParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	start := r.Cursor()
+	name := r.ReadWord()
+	if strings.IndexByte(name, ':') < 0 {
+		name = "minecraft:" + name
+	}
+
+	if !p.valid(name) {
+		r.pos = start
+		return nil, r.Error("Unknown item '" + name + "'")
+	}
+
+	return name, nil
+}
+
+func (p *itemParser) ID() string {
+	return "minecraft:item_stack"
+}
+
+func (p *itemParser) WriteProperties(w *packet.Writer) {}
+
+// names of the game modes by their ID
+var gameModes = []string{"survival", "creative", "adventure", "spectator"}
+
+type gameModeParser struct{}
+
+// GameMode parses the name of a game mode into its ID. The protocol has
+// no type for it, so the client sees a word the server completes.
+func GameMode() Parser {
+	return gameModeParser{}
+}
+
+func (gameModeParser) Parse(r *Reader, ctx *Context) (interface{}, error) {
+	start := r.Cursor()
+	word := r.ReadWord()
+
+	for id, name := range gameModes {
+		if word == name {
+			return uint8(id), nil
+		}
+	}
+
+	r.pos = start
+	return nil, r.Error("Unknown game mode '" + word + "'")
+}
+
+func (gameModeParser) Suggest(ctx *Context, prefix string) []string {
+	return filter(gameModes, prefix)
+}
+
+func (gameModeParser) ID() string {
+	return "brigadier:string"
+}
+
+func (gameModeParser) WriteProperties(w *packet.Writer) {
+	w.WriteVarint(SingleWord)
+}
+
+// filter returns the values that start with the prefix, ignoring case.
+func filter(values []string, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+
+	var matches []string
+	for _, v := range values {
+		if strings.HasPrefix(strings.ToLower(v), prefix) {
+			matches = append(matches, v)
+		}
+	}
+
+	return matches
+}
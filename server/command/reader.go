@@ -0,0 +1,168 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is an error in the input of a command, at a cursor.
+type SyntaxError struct {
+	Message string
+	Input   string
+	Cursor  int
+}
+
+// how much input before the cursor an error shows
+const errorContext = 10
+
+func (e *SyntaxError) Error() string {
+	return e.Message + ": " + e.Context()
+}
+
+// Context returns the input up to the error, like the vanilla client
+// shows it.
+func (e *SyntaxError) Context() string {
+	start := e.Cursor - errorContext
+	prefix := "..."
+	if start <= 0 {
+		start = 0
+		prefix = ""
+	}
+
+	cursor := e.Cursor
+	if cursor > len(e.Input) {
+		cursor = len(e.Input)
+	}
+
+	return prefix + e.Input[start:cursor] + "<--[HERE]"
+}
+
+// Reader reads the arguments of a command.
+type Reader struct {
+	s   string
+	pos int
+}
+
+func NewReader(s string) *Reader {
+	return &Reader{s: s}
+}
+
+func (r *Reader) Cursor() int {
+	return r.pos
+}
+
+func (r *Reader) CanRead() bool {
+	return r.pos < len(r.s)
+}
+
+func (r *Reader) Peek() byte {
+	return r.s[r.pos]
+}
+
+func (r *Reader) Skip() {
+	r.pos++
+}
+
+func (r *Reader) Remaining() string {
+	return r.s[r.pos:]
+}
+
+func (r *Reader) Error(message string) *SyntaxError {
+	return &SyntaxError{message, r.s, r.pos}
+}
+
+// ReadWord reads up to the next space.
+func (r *Reader) ReadWord() string {
+	start := r.pos
+	for r.CanRead() && r.Peek() != ' ' {
+		r.pos++
+	}
+
+	return r.s[start:r.pos]
+}
+
+// ReadString reads a word or a string in double quotes, where \ escapes
+// quotes and itself.
+func (r *Reader) ReadString() (string, error) {
+	if !r.CanRead() || r.Peek() != '"' {
+		return r.ReadWord(), nil
+	}
+
+	start := r.pos
+	r.pos++
+
+	var b strings.Builder
+	for r.CanRead() {
+		c := r.Peek()
+		r.pos++
+
+		switch {
+		case c == '\\' && r.CanRead() && (r.Peek() == '"' || r.Peek() == '\\'):
+			b.WriteByte(r.Peek())
+			r.pos++
+		case c == '"':
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	r.pos = start
+	return "", r.Error("Unclosed quoted string")
+}
+
+// ReadRemaining reads the rest of the input.
+func (r *Reader) ReadRemaining() string {
+	s := r.s[r.pos:]
+	r.pos = len(r.s)
+	return s
+}
+
+func (r *Reader) ReadInt() (int, error) {
+	start := r.pos
+	word := r.ReadWord()
+	if word == "" {
+		return 0, r.Error("Expected integer")
+	}
+
+	v, err := strconv.Atoi(word)
+	if err != nil {
+		r.pos = start
+		return 0, r.Error("Invalid integer '" + word + "'")
+	}
+
+	return v, nil
+}
+
+func (r *Reader) ReadFloat() (float64, error) {
+	start := r.pos
+	word := r.ReadWord()
+	if word == "" {
+		return 0, r.Error("Expected float")
+	}
+
+	v, ok := parseFloat(word)
+	if !ok {
+		r.pos = start
+		return 0, r.Error("Invalid float '" + word + "'")
+	}
+
+	return v, nil
+}
+
+// parseFloat accepts only signs, digits and a dot like Brigadier, so
+// words like NaN and Inf are not numbers.
+func parseFloat(word string) (float64, bool) {
+	digits := strings.TrimLeft(word, "+-")
+	if len(word)-len(digits) > 1 || digits == "" || digits == "." {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		if (digits[i] < '0' || digits[i] > '9') && digits[i] != '.' {
+			return 0, false
+		}
+	}
+
+	v, err := strconv.ParseFloat(word, 64)
+	return v, err == nil
+}
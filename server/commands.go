@@ -0,0 +1,219 @@
+package server
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/server/command"
+	"github.com/skdltmxn/go-mine/util/chat"
+)
+
+// playerSource runs commands typed by a player.
+type playerSource struct {
+	g      *GameServer
+	player *GamePlayer
+}
+
+func (s *playerSource) Name() string {
+	return s.player.name
+}
+
+func (s *playerSource) PermissionLevel() int {
+	s.player.m.Lock()
+	defer s.player.m.Unlock()
+
+	return s.player.permission
+}
+
+func (s *playerSource) SendMessage(c *chat.Component) {
+	s.g.sendMessage(s.player, c, ChatPositionSystem)
+}
+
+func (s *playerSource) Position() (x, y, z float64, ok bool) {
+	pos := s.player.position()
+	return pos.x, pos.y, pos.z, true
+}
+
+// consoleSource runs commands typed into the server console.
+type consoleSource struct {
+	print func(s string)
+}
+
+// NewConsoleSource returns the source of commands from the console, which
+// may run every command. Its feedback is printed in English.
+func NewConsoleSource(print func(s string)) command.Source {
+	return &consoleSource{print}
+}
+
+func (s *consoleSource) Name() string {
+	return "Server"
+}
+
+func (s *consoleSource) PermissionLevel() int {
+	return command.PermissionOwner
+}
+
+func (s *consoleSource) SendMessage(c *chat.Component) {
+	s.print(c.Localized(chat.English))
+}
+
+func (s *consoleSource) Position() (x, y, z float64, ok bool) {
+	return 0, 0, 0, false
+}
+
+// Commands returns the dispatcher of the commands players and the
+// console can run.
+func (g *GameServer) Commands() *command.Dispatcher {
+	return g.commands
+}
+
+// RunCommand runs a command without the leading slash on the next tick.
+func (g *GameServer) RunCommand(src command.Source, input string) {
+	g.scheduler.RunLater(0, func() {
+		g.runCommand(src, input)
+	})
+}
+
+func (g *GameServer) runCommand(src command.Source, input string) {
+	log.Printf("[GAME] %s issued server command: /%s", src.Name(), input)
+
+	err := g.commands.Execute(src, input)
+	switch e := err.(type) {
+	case nil:
+	case *command.SyntaxError:
+		src.SendMessage(chat.Colored(e.Message, chat.Red))
+		src.SendMessage(chat.Colored(e.Context(), chat.Gray))
+	case command.Error:
+		src.SendMessage(chat.Colored(e.Error(), chat.Red))
+	default:
+		log.Printf("[GAME] Command /%s failed: %s", input, err)
+		src.SendMessage(chat.Colored("An unexpected error occurred trying to execute that command", chat.Red))
+	}
+}
+
+func (g *GameServer) handleCommand(player *GamePlayer, input string) {
+	g.runCommand(&playerSource{g, player}, input)
+}
+
+// sendCommands tells a player which commands it may run.
+func (g *GameServer) sendCommands(player *GamePlayer) {
+	player.sess.QueuePacket(g.commands.DeclarePacket(&playerSource{g, player}))
+}
+
+func (g *GameServer) handleTabComplete(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+	id, _ := r.ReadVarint()
+	text, err := r.ReadString()
+	if err != nil || !strings.HasPrefix(text, "/") {
+		return
+	}
+
+	start, suggestions := g.commands.Suggest(&playerSource{g, player}, text[1:])
+
+	res := packet.NewPacket(0x11)
+	w := packet.NewWriter(res)
+	w.WriteVarint(id)
+	w.WriteVarint(start + 1)
+	w.WriteVarint(len(text) - 1 - start)
+	w.WriteVarint(len(suggestions))
+	for _, s := range suggestions {
+		w.WriteString(s)
+		w.WriteBool(false) // no tooltip
+	}
+
+	player.sess.QueuePacket(res)
+}
+
+// playerNames returns the names of the players in the tab list.
+func (g *GameServer) playerNames() []string {
+	var names []string
+	for _, player := range g.spawnedPlayers() {
+		names = append(names, player.name)
+	}
+
+	return names
+}
+
+// selectPlayers resolves a selector to the players it selects.
+func (g *GameServer) selectPlayers(src command.Source, s command.Selector) ([]*GamePlayer, error) {
+	players := g.spawnedPlayers()
+
+	var selected []*GamePlayer
+	switch s.Type {
+	case 0:
+		if player := g.playerByName(s.Name); player != nil {
+			selected = []*GamePlayer{player}
+		}
+	case 'a', 'e':
+		selected = players
+	case 'r':
+		if len(players) > 0 {
+			selected = []*GamePlayer{players[rand.Intn(len(players))]}
+		}
+	case 's':
+		if ps, ok := src.(*playerSource); ok {
+			selected = []*GamePlayer{ps.player}
+		}
+	case 'p':
+		x, y, z, ok := src.Position()
+		if !ok {
+			break
+		}
+
+		var nearest *GamePlayer
+		best := 0.0
+		for _, player := range players {
+			pos := player.position()
+			dx, dy, dz := pos.x-x, pos.y-y, pos.z-z
+			if d := dx*dx + dy*dy + dz*dz; nearest == nil || d < best {
+				nearest, best = player, d
+			}
+		}
+		if nearest != nil {
+			selected = []*GamePlayer{nearest}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, command.Error("No player was found")
+	}
+
+	return selected, nil
+}
+
+func (g *GameServer) registerCommands() {
+	g.commands.Register(command.Literal("help").Executes(func(ctx *command.Context) error {
+		for _, name := range g.commands.Commands(ctx.Source) {
+			ctx.Source.SendMessage(chat.Text("/" + name))
+		}
+		return nil
+	}))
+
+	tell := func(ctx *command.Context) error {
+		targets, err := g.selectPlayers(ctx.Source, ctx.Selector("targets"))
+		if err != nil {
+			return err
+		}
+
+		message := chat.Text(ctx.String("message"))
+		for _, target := range targets {
+			incoming := chat.Translate("commands.message.display.incoming", chat.Text(ctx.Source.Name()), message)
+			incoming.Color, incoming.Italic = chat.Gray, true
+			g.sendMessage(target, incoming, ChatPositionChat)
+
+			outgoing := chat.Translate("commands.message.display.outgoing", chat.Text(target.name), message)
+			outgoing.Color, outgoing.Italic = chat.Gray, true
+			ctx.Source.SendMessage(outgoing)
+		}
+		return nil
+	}
+	for _, name := range []string{"tell", "msg", "w"} {
+		g.commands.Register(command.Literal(name).Then(
+			command.Argument("targets", command.Entity(command.PlayersOnly, g.playerNames)).Then(
+				command.Argument("message", command.String(command.GreedyPhrase)).Executes(tell),
+			),
+		))
+	}
+}
@@ -0,0 +1,308 @@
+// Package console reads operator commands from the terminal while the
+// server logs to it.
+package console
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultPrompt = "> "
+	maxHistory    = 100
+)
+
+// Console reads lines from stdin. When stdin and stdout are a terminal it
+// edits the line itself and keeps the prompt below the log lines;
+// otherwise it reads plain lines.
+type Console struct {
+	m       sync.Mutex
+	reader  *bufio.Reader
+	out     io.Writer
+	prompt  string
+	raw     bool
+	restore func()
+
+	line    []rune
+	cursor  int
+	history []string
+	histPos int    // len(history) while editing a new line
+	saved   []rune // the new line while browsing the history
+}
+
+// New returns a console reading in and writing its output to out.
+func New(in, out *os.File) *Console {
+	c := &Console{
+		reader: bufio.NewReader(in),
+		out:    out,
+		prompt: defaultPrompt,
+	}
+
+	if isTerminal(out.Fd()) {
+		if restore, err := makeRaw(in.Fd()); err == nil {
+			c.raw = true
+			c.restore = restore
+			c.redraw()
+		}
+	}
+
+	return c
+}
+
+// Close gives the terminal back its settings.
+func (c *Console) Close() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.raw {
+		io.WriteString(c.out, "\r\x1b[K")
+		c.restore()
+		c.raw = false
+	}
+}
+
+// Println writes a line of output above the prompt.
+func (c *Console) Println(s string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.clear()
+	io.WriteString(c.out, s+"\n")
+	c.redraw()
+}
+
+type logWriter struct {
+	c *Console
+	w io.Writer
+}
+
+// LogWriter wraps the log stream so that log lines are printed above the
+// prompt instead of through the line being typed.
+func (c *Console) LogWriter(w io.Writer) io.Writer {
+	return &logWriter{c, w}
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	l.c.m.Lock()
+	defer l.c.m.Unlock()
+
+	l.c.clear()
+	n, err := l.w.Write(p)
+	l.c.redraw()
+	return n, err
+}
+
+// clear erases the prompt line. Must be called with c.m held.
+func (c *Console) clear() {
+	if c.raw {
+		io.WriteString(c.out, "\r\x1b[K")
+	}
+}
+
+// redraw prints the prompt and the line with the cursor in place. Must be
+// called with c.m held.
+func (c *Console) redraw() {
+	if !c.raw {
+		return
+	}
+
+	s := "\r" + c.prompt + string(c.line) + "\x1b[K"
+	if back := len(c.line) - c.cursor; back > 0 {
+		s += "\x1b[" + strconv.Itoa(back) + "D"
+	}
+	io.WriteString(c.out, s)
+}
+
+// ReadLine returns the next line without its line break. It returns
+// io.EOF when the input ends or Ctrl-D is pressed on an empty line.
+func (c *Console) ReadLine() (string, error) {
+	if !c.raw {
+		line, err := c.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	for {
+		r, _, err := c.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		if line, done, err := c.key(r); done || err != nil {
+			return line, err
+		}
+	}
+}
+
+// key handles a key typed in raw mode and reports whether the line is
+// complete.
+func (c *Console) key(r rune) (string, bool, error) {
+	if r == 0x1b {
+		return "", false, c.escape()
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	switch r {
+	case '\r', '\n':
+		line := string(c.line)
+		c.addHistory(line)
+		c.line, c.cursor = nil, 0
+		io.WriteString(c.out, "\r\n")
+		c.redraw()
+		return line, true, nil
+	case 0x04: // Ctrl-D
+		if len(c.line) == 0 {
+			return "", false, io.EOF
+		}
+		c.delete(c.cursor)
+	case 0x7f, 0x08: // backspace
+		if c.cursor > 0 {
+			c.cursor--
+			c.delete(c.cursor)
+		}
+	case 0x01: // Ctrl-A
+		c.cursor = 0
+	case 0x05: // Ctrl-E
+		c.cursor = len(c.line)
+	case 0x02: // Ctrl-B
+		c.move(-1)
+	case 0x06: // Ctrl-F
+		c.move(1)
+	case 0x0b: // Ctrl-K
+		c.line = c.line[:c.cursor]
+	case 0x15: // Ctrl-U
+		c.line = append([]rune(nil), c.line[c.cursor:]...)
+		c.cursor = 0
+	case 0x17: // Ctrl-W
+		start := c.cursor
+		for start > 0 && c.line[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && c.line[start-1] != ' ' {
+			start--
+		}
+		c.line = append(c.line[:start], c.line[c.cursor:]...)
+		c.cursor = start
+	case 0x10: // Ctrl-P
+		c.browse(-1)
+	case 0x0e: // Ctrl-N
+		c.browse(1)
+	default:
+		if r < 0x20 {
+			return "", false, nil
+		}
+		c.line = append(c.line, 0)
+		copy(c.line[c.cursor+1:], c.line[c.cursor:])
+		c.line[c.cursor] = r
+		c.cursor++
+	}
+
+	c.redraw()
+	return "", false, nil
+}
+
+// escape handles the escape sequences of the arrow, home, end and delete
+// keys.
+func (c *Console) escape() error {
+	b, err := c.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != '[' && b != 'O' {
+		return nil
+	}
+
+	var param []byte
+	for {
+		b, err = c.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+		param = append(param, b)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	switch b {
+	case 'A':
+		c.browse(-1)
+	case 'B':
+		c.browse(1)
+	case 'C':
+		c.move(1)
+	case 'D':
+		c.move(-1)
+	case 'H':
+		c.cursor = 0
+	case 'F':
+		c.cursor = len(c.line)
+	case '~':
+		switch string(param) {
+		case "1", "7":
+			c.cursor = 0
+		case "4", "8":
+			c.cursor = len(c.line)
+		case "3":
+			c.delete(c.cursor)
+		}
+	}
+
+	c.redraw()
+	return nil
+}
+
+func (c *Console) move(d int) {
+	if cursor := c.cursor + d; cursor >= 0 && cursor <= len(c.line) {
+		c.cursor = cursor
+	}
+}
+
+func (c *Console) delete(i int) {
+	if i < len(c.line) {
+		c.line = append(c.line[:i], c.line[i+1:]...)
+	}
+}
+
+func (c *Console) addHistory(line string) {
+	if strings.TrimSpace(line) != "" && (len(c.history) == 0 || c.history[len(c.history)-1] != line) {
+		c.history = append(c.history, line)
+		if len(c.history) > maxHistory {
+			c.history = c.history[1:]
+		}
+	}
+
+	c.histPos = len(c.history)
+	c.saved = nil
+}
+
+// browse replaces the line with an older or newer one from the history.
+func (c *Console) browse(d int) {
+	pos := c.histPos + d
+	if pos < 0 || pos > len(c.history) {
+		return
+	}
+
+	if c.histPos == len(c.history) {
+		c.saved = c.line
+	}
+	c.histPos = pos
+
+	if pos == len(c.history) {
+		c.line = c.saved
+	} else {
+		c.line = []rune(c.history[pos])
+	}
+	c.cursor = len(c.line)
+}
@@ -0,0 +1,72 @@
+package console
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestConsole(input string, raw bool) (*Console, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &Console{
+		reader: bufio.NewReader(strings.NewReader(input)),
+		out:    out,
+		prompt: defaultPrompt,
+		raw:    raw,
+	}, out
+}
+
+func TestReadLinePlain(t *testing.T) {
+	c, _ := newTestConsole("say hi\r\nlist", false)
+
+	for _, expected := range []string{"say hi", "list"} {
+		line, err := c.ReadLine()
+		if err != nil {
+			t.Fatalf("ReadLine failed: %s", err)
+		}
+		if line != expected {
+			t.Fatalf("unexpected line: %q", line)
+		}
+	}
+
+	if _, err := c.ReadLine(); err != io.EOF {
+		t.Fatalf("ReadLine did not end: %v", err)
+	}
+}
+
+func TestReadLineRaw(t *testing.T) {
+	// typo fixed with left arrow and backspace, then the first line again
+	// from the history
+	c, _ := newTestConsole("lisst\x1b[D\x1b[D\x7f\r\x1b[A\r\x04", true)
+
+	for _, expected := range []string{"list", "list"} {
+		line, err := c.ReadLine()
+		if err != nil {
+			t.Fatalf("ReadLine failed: %s", err)
+		}
+		if line != expected {
+			t.Fatalf("unexpected line: %q", line)
+		}
+	}
+
+	if _, err := c.ReadLine(); err != io.EOF {
+		t.Fatalf("ReadLine did not end on Ctrl-D: %v", err)
+	}
+}
+
+func TestLogRedraw(t *testing.T) {
+	c, out := newTestConsole("", true)
+	c.line, c.cursor = []rune("tim"), 3
+
+	var log bytes.Buffer
+	io.WriteString(c.LogWriter(&log), "a log line\n")
+
+	if log.String() != "a log line\n" {
+		t.Fatalf("unexpected log: %q", log.String())
+	}
+	if !strings.HasSuffix(out.String(), "\r> tim\x1b[K") {
+		t.Fatalf("prompt not redrawn: %q", out.String())
+	}
+}
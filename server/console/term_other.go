@@ -0,0 +1,15 @@
+// +build !linux
+
+package console
+
+import "errors"
+
+// raw mode is only implemented on linux; other systems read plain lines
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("console: raw mode is not supported")
+}
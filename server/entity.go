@@ -1,9 +1,252 @@
 package server
 
-import "sync/atomic"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/world/block"
+)
 
 var currentEntityId int32 = -1
 
 func getNextEntityId() int32 {
 	return atomic.AddInt32(&currentEntityId, 1)
 }
+
+type uuid [16]byte
+
+// parseUUID parses a UUID with or without dashes.
+func parseUUID(s string) (id uuid, ok bool) {
+	b, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil || len(b) != len(id) {
+		return id, false
+	}
+
+	copy(id[:], b)
+	return id, true
+}
+
+// randomUUID returns a version 4 UUID.
+func randomUUID() (id uuid) {
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return
+}
+
+// String returns the UUID with dashes.
+func (id uuid) String() string {
+	s := hex.EncodeToString(id[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func writeUUID(w *packet.Writer, id uuid) {
+	w.Write(id[:])
+}
+
+// entityPosition is where an entity is and where it looks.
+type entityPosition struct {
+	x, y, z    float64
+	yaw, pitch float32
+	onGround   bool
+}
+
+// entity is anything the tracker shows to players.
+type entity interface {
+	entityId() int32
+	entityType() string
+	position() entityPosition
+
+	// spawnPacket returns the packet that makes the entity appear.
+	spawnPacket() *packet.Packet
+
+	// writeMetadata writes the metadata entries of the entity, without
+	// the terminating index.
+	writeMetadata(w *packet.Writer)
+}
+
+// types of metadata values
+const (
+	metadataByte    = 0
+	metadataBoolean = 7
+)
+
+const metadataEnd = 0xff
+
+// entities spawned with Spawn Entity instead of Spawn Living Entity
+var objectTypes = map[string]bool{
+	"minecraft:area_effect_cloud": true,
+	"minecraft:arrow":             true,
+	"minecraft:boat":              true,
+	"minecraft:egg":               true,
+	"minecraft:ender_pearl":       true,
+	"minecraft:experience_bottle": true,
+	"minecraft:eye_of_ender":      true,
+	"minecraft:falling_block":     true,
+	"minecraft:fireball":          true,
+	"minecraft:firework_rocket":   true,
+	"minecraft:item":              true,
+	"minecraft:item_frame":        true,
+	"minecraft:minecart":          true,
+	"minecraft:potion":            true,
+	"minecraft:small_fireball":    true,
+	"minecraft:snowball":          true,
+	"minecraft:tnt":               true,
+	"minecraft:trident":           true,
+}
+
+// mob is an entity driven by the server. Mobs have no behavior yet; they
+// stay where they are spawned.
+type mob struct {
+	id     int32
+	uuid   uuid
+	typ    string
+	typeId int32
+
+	m   sync.Mutex
+	pos entityPosition
+}
+
+func newMob(typ string, x, y, z float64) (*mob, bool) {
+	if strings.IndexByte(typ, ':') < 0 {
+		typ = "minecraft:" + typ
+	}
+
+	typeId, ok := block.Entities.ID(typ)
+	if !ok || typ == "minecraft:player" {
+		return nil, false
+	}
+
+	return &mob{
+		id:     getNextEntityId(),
+		uuid:   randomUUID(),
+		typ:    typ,
+		typeId: typeId,
+		pos:    entityPosition{x: x, y: y, z: z},
+	}, true
+}
+
+func (e *mob) entityId() int32 {
+	return e.id
+}
+
+func (e *mob) entityType() string {
+	return e.typ
+}
+
+func (e *mob) position() entityPosition {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return e.pos
+}
+
+func (e *mob) spawnPacket() *packet.Packet {
+	pos := e.position()
+
+	if objectTypes[e.typ] {
+		p := packet.NewPacket(0x00)
+		w := packet.NewWriter(p)
+		w.WriteVarint(int(e.id))
+		writeUUID(w, e.uuid)
+		w.WriteVarint(int(e.typeId))
+		w.WriteDouble(pos.x)
+		w.WriteDouble(pos.y)
+		w.WriteDouble(pos.z)
+		w.WriteUbyte(toAngle(pos.pitch))
+		w.WriteUbyte(toAngle(pos.yaw))
+		w.WriteInt(0) // data
+		w.WriteShort(0)
+		w.WriteShort(0)
+		w.WriteShort(0)
+		return p
+	}
+
+	p := packet.NewPacket(0x03)
+	w := packet.NewWriter(p)
+	w.WriteVarint(int(e.id))
+	writeUUID(w, e.uuid)
+	w.WriteVarint(int(e.typeId))
+	w.WriteDouble(pos.x)
+	w.WriteDouble(pos.y)
+	w.WriteDouble(pos.z)
+	w.WriteUbyte(toAngle(pos.yaw))
+	w.WriteUbyte(toAngle(pos.pitch))
+	w.WriteUbyte(toAngle(pos.yaw)) // head
+	w.WriteShort(0)
+	w.WriteShort(0)
+	w.WriteShort(0)
+	return p
+}
+
+func (e *mob) writeMetadata(w *packet.Writer) {
+	// no gravity, as mobs do not move yet
+	w.WriteUbyte(5)
+	w.WriteVarint(metadataBoolean)
+	w.WriteBool(true)
+}
+
+func (player *GamePlayer) entityId() int32 {
+	return player.eid
+}
+
+func (player *GamePlayer) entityType() string {
+	return "minecraft:player"
+}
+
+func (player *GamePlayer) position() entityPosition {
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	return entityPosition{player.x, player.y, player.z, player.yaw, player.pitch, player.onGround}
+}
+
+func (player *GamePlayer) spawnPacket() *packet.Packet {
+	pos := player.position()
+
+	p := packet.NewPacket(0x05)
+	w := packet.NewWriter(p)
+	w.WriteVarint(int(player.eid))
+	writeUUID(w, player.uuid)
+	w.WriteDouble(pos.x)
+	w.WriteDouble(pos.y)
+	w.WriteDouble(pos.z)
+	w.WriteUbyte(toAngle(pos.yaw))
+	w.WriteUbyte(toAngle(pos.pitch))
+
+	return p
+}
+
+// metadata indexes of players
+const (
+	metadataSkinParts = 16
+	metadataMainHand  = 17
+)
+
+func (player *GamePlayer) writeMetadata(w *packet.Writer) {
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	w.WriteUbyte(metadataSkinParts)
+	w.WriteVarint(metadataByte)
+	w.WriteUbyte(player.skinParts)
+
+	w.WriteUbyte(metadataMainHand)
+	w.WriteVarint(metadataByte)
+	w.WriteUbyte(player.mainHand)
+}
+
+func metadataPacket(e entity) *packet.Packet {
+	p := packet.NewPacket(0x44)
+	w := packet.NewWriter(p)
+
+	w.WriteVarint(int(e.entityId()))
+	e.writeMetadata(w)
+	w.WriteUbyte(metadataEnd)
+
+	return p
+}
@@ -3,44 +3,247 @@ package server
 import (
 	"encoding/hex"
 	"log"
+	"strings"
+	"sync"
 
 	"github.com/skdltmxn/go-mine/net"
 	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/server/command"
+	"github.com/skdltmxn/go-mine/server/tick"
+	"github.com/skdltmxn/go-mine/util/chat"
+	"github.com/skdltmxn/go-mine/world"
 )
 
+// used if there is no ground at the spawn point
+const defaultSpawnY = 100
+
 type GamePlayer struct {
+	sess *net.Session
 	name string
+	uuid uuid
 	eid  int32
+
+	m          sync.Mutex
+	x, y, z    float64
+	yaw, pitch float32
+	onGround   bool // as sent by the client
+	teleportId int
+	gameMode   uint8
+
+	health       float32
+	fallDistance float64
+	airTicks     int
+
+	viewDistance int
+	view         *chunkView // nil until spawned
+	skinParts    uint8
+	mainHand     uint8
+	properties   []authProperties
+	displayName  *chat.Component // nil for the name
+	chatMode     int
+	permission   int
+	inventory    [inventorySize]itemStack
+
+	keepAliveId int64 // 0 if answered
+	ping        int   // ms
+
+	// packets received since the last tick
+	inboxLock sync.Mutex
+	inbox     []*packet.Packet
 }
 
 type GameServer struct {
-	sessMap map[*net.Session]*GamePlayer
+	m         sync.RWMutex
+	sessMap   map[*net.Session]*GamePlayer
+	world     *world.World
+	loop      *tick.Loop
+	scheduler *tick.Scheduler
+	checks    []MovementCheck
+	distance  int // view distance limit of players
+	tracker   *entityTracker
+
+	tabHeader *chat.Component
+	tabFooter *chat.Component
+
+	chatFormat   string
+	chatHandlers []func(e *ChatEvent)
+
+	commands *command.Dispatcher
+	access   *Access
+	stop     func()
 }
 
-func NewGameServer() *GameServer {
-	g := &GameServer{make(map[*net.Session]*GamePlayer)}
+func NewGameServer(w *world.World, access *Access) *GameServer {
+	g := &GameServer{
+		sessMap:   make(map[*net.Session]*GamePlayer),
+		world:     w,
+		access:    access,
+		scheduler: tick.NewScheduler(),
+		checks:    DefaultMovementChecks(),
+		distance:  defaultViewDistance,
+		tracker:   newEntityTracker(),
+
+		chatFormat: DefaultChatFormat,
+		commands:   command.NewDispatcher(),
+	}
+	g.registerCommands()
+	g.registerAdminCommands()
+	g.loop = tick.NewLoop(g.tick)
+	g.scheduler.RunRepeating(keepAliveInterval, keepAliveInterval, g.sendKeepAlives)
+	g.scheduler.RunRepeating(timeUpdateInterval, timeUpdateInterval, g.sendTimeUpdates)
+	g.scheduler.RunRepeating(latencyUpdateInterval, latencyUpdateInterval, g.sendLatencies)
+
 	go g.waitForDataFromLoginServer()
+	go g.loop.Run()
 	return g
 }
 
+// Stop stops ticking once the running tick is over and disconnects every
+// player.
+func (g *GameServer) Stop() {
+	g.loop.Stop()
+
+	for _, player := range g.players() {
+		g.kick(player, chat.Translate("multiplayer.disconnect.server_shutdown"))
+	}
+}
+
+// OnStop sets what /stop does, which should stop the whole server.
+func (g *GameServer) OnStop(stop func()) {
+	g.m.Lock()
+	g.stop = stop
+	g.m.Unlock()
+}
+
+// SetViewDistance limits how many chunks around them players get, whatever
+// their client asks for.
+func (g *GameServer) SetViewDistance(distance int) {
+	g.m.Lock()
+	g.distance = clampViewDistance(distance)
+	g.m.Unlock()
+}
+
+func (g *GameServer) viewDistance() int {
+	g.m.RLock()
+	defer g.m.RUnlock()
+
+	return g.distance
+}
+
+// SetMovementChecks replaces the checks run on every move of a player.
+func (g *GameServer) SetMovementChecks(checks ...MovementCheck) {
+	g.m.Lock()
+	g.checks = checks
+	g.m.Unlock()
+}
+
+func (g *GameServer) movementChecks() []MovementCheck {
+	g.m.RLock()
+	defer g.m.RUnlock()
+
+	return g.checks
+}
+
+// Scheduler returns the scheduler whose tasks run on the game tick.
+func (g *GameServer) Scheduler() *tick.Scheduler {
+	return g.scheduler
+}
+
+// TPS returns the recent ticks per second.
+func (g *GameServer) TPS() float64 {
+	return g.loop.TPS()
+}
+
+// MSPT returns the recent milliseconds spent per tick.
+func (g *GameServer) MSPT() float64 {
+	return g.loop.MSPT()
+}
+
+// hasChunk reports whether the chunk was sent to the player.
+func (player *GamePlayer) hasChunk(pos chunkPos) bool {
+	player.m.Lock()
+	view := player.view
+	player.m.Unlock()
+
+	return view != nil && view.isLoaded(pos)
+}
+
+func (g *GameServer) players() []*GamePlayer {
+	g.m.RLock()
+	defer g.m.RUnlock()
+
+	players := make([]*GamePlayer, 0, len(g.sessMap))
+	for _, player := range g.sessMap {
+		players = append(players, player)
+	}
+
+	return players
+}
+
+func (g *GameServer) playerByName(name string) *GamePlayer {
+	for _, player := range g.players() {
+		if strings.EqualFold(player.name, name) {
+			return player
+		}
+	}
+
+	return nil
+}
+
+func (g *GameServer) player(sess *net.Session) *GamePlayer {
+	g.m.RLock()
+	defer g.m.RUnlock()
+
+	return g.sessMap[sess]
+}
+
 func (g *GameServer) Dispatch(sess *net.Session, p *packet.Packet) bool {
 	if sess.State() != net.SessionStateGame {
 		return false
 	}
 
+	player := g.player(sess)
+	if player == nil {
+		// the login server has not handed the player over yet
+		return true
+	}
+
+	// handled on the next tick
+	player.inboxLock.Lock()
+	player.inbox = append(player.inbox, p)
+	player.inboxLock.Unlock()
+
+	return true
+}
+
+func (g *GameServer) handlePacket(player *GamePlayer, p *packet.Packet) {
 	switch p.Id() {
+	case 0x00:
+		g.confirmTeleport(player, p)
+	case 0x03:
+		g.handleChat(player, p)
+	case 0x04:
+		g.handleClientStatus(player, p)
 	case 0x05:
-		g.saveClientSetting(sess, p)
+		g.saveClientSetting(player, p)
+	case 0x06:
+		g.handleTabComplete(player, p)
 	case 0x0b:
 		g.handlePluginMessage(p)
+	case 0x0f:
+		g.handleKeepAlive(player, p)
+	case 0x11, 0x12, 0x13, 0x14:
+		g.handleMovement(player, p)
+	case 0x1a:
+		g.handleDigging(p)
+	case 0x26:
+		g.handleCreativeInventory(player, p)
 	default:
 		log.Printf("[GAME] Unknown packet ID: %d / %+v", p.Id(), hex.EncodeToString(p.Data()))
 	}
-
-	return true
 }
 
-func (g *GameServer) saveClientSetting(sess *net.Session, p *packet.Packet) {
+func (g *GameServer) saveClientSetting(player *GamePlayer, p *packet.Packet) {
 	r := packet.NewReader(p)
 	locale, _ := r.ReadString()
 	distance, _ := r.ReadByte()
@@ -58,7 +261,17 @@ func (g *GameServer) saveClientSetting(sess *net.Session, p *packet.Packet) {
 		mainHand,
 	)
 
-	// TODO: actually save the settings
+	player.m.Lock()
+	player.viewDistance = min(int(distance), g.viewDistance())
+	player.skinParts = displaySkinParts
+	player.mainHand = uint8(mainHand)
+	player.chatMode = chatMode
+	if player.view != nil {
+		player.view.setDistance(player.viewDistance)
+	}
+	player.m.Unlock()
+
+	g.tracker.updateMetadata(player)
 }
 
 func (g *GameServer) handlePluginMessage(p *packet.Packet) {
@@ -78,17 +291,123 @@ func (g *GameServer) sendServerBrand(sess *net.Session) {
 	w.WriteString("minecraft:brand")
 	w.WriteString("go-mine")
 
-	sess.SendPacket(p)
+	sess.QueuePacket(p)
 }
 
 func (g *GameServer) waitForDataFromLoginServer() {
-	ch := getTunnelReceiver()
-	select {
-	case data := <-ch:
-		g.sessMap[data.sess] = &GamePlayer{
-			data.name,
-			data.eid,
+	for data := range getTunnelReceiver() {
+		id, ok := parseUUID(data.uuid)
+		if !ok {
+			log.Printf("[GAME] Invalid UUID of %s: %s", data.name, data.uuid)
+			data.sess.Close()
+			continue
+		}
+
+		x, y, z := g.spawnPoint()
+		player := &GamePlayer{
+			sess:       data.sess,
+			name:       data.name,
+			uuid:       id,
+			eid:        data.eid,
+			x:          x,
+			y:          y,
+			z:          z,
+			gameMode:   GameModeCreative,
+			health:     maxHealth,
+			mainHand:   1, // right
+			skinParts:  0x7f,
+			properties: data.properties,
+
+			viewDistance: min(defaultViewDistance, g.viewDistance()),
 		}
-		go g.sendServerBrand(data.sess)
+
+		// spawned on the next tick
+		g.m.Lock()
+		g.sessMap[data.sess] = player
+		g.m.Unlock()
 	}
 }
+
+// spawnPoint returns the center of the spawn block.
+func (g *GameServer) spawnPoint() (x, y, z float64) {
+	spawnX, spawnY, spawnZ := g.world.Spawn()
+	if spawnY <= 0 {
+		spawnY = defaultSpawnY
+	}
+
+	return float64(spawnX) + 0.5, float64(spawnY), float64(spawnZ) + 0.5
+}
+
+func (g *GameServer) spawnPlayer(player *GamePlayer) {
+	g.sendServerBrand(player.sess)
+	g.sendCommands(player)
+	g.sendOpLevel(player)
+	g.sendWeather(player)
+	g.addToTabList(player)
+
+	player.m.Lock()
+	player.view = newChunkView(player.sess, g.world, player.x, player.z, player.viewDistance)
+	g.teleport(player.sess, player)
+	player.m.Unlock()
+
+	g.tracker.add(player)
+
+	joined := chat.Translate("multiplayer.player.joined", chat.Text(player.name))
+	joined.Color = chat.Yellow
+	g.Broadcast(joined, ChatPositionSystem)
+}
+
+// kick disconnects a player with a reason.
+func (g *GameServer) kick(player *GamePlayer, reason *chat.Component) {
+	log.Printf("[GAME] Kicked %s: %s", player.name, reason.Plain())
+
+	p := packet.NewPacket(0x1b)
+	w := packet.NewWriter(p)
+	w.WriteString(reason.String())
+
+	player.sess.QueuePacket(p)
+	player.sess.CloseAfterFlush()
+}
+
+func (g *GameServer) removePlayer(player *GamePlayer) {
+	g.m.Lock()
+	delete(g.sessMap, player.sess)
+	g.m.Unlock()
+
+	g.tracker.remove(player.eid)
+	g.tracker.removeViewer(player)
+	if player.view != nil {
+		g.removeFromTabList(player)
+		player.view.releaseAll()
+
+		left := chat.Translate("multiplayer.player.left", chat.Text(player.name))
+		left.Color = chat.Yellow
+		g.Broadcast(left, ChatPositionSystem)
+	}
+}
+
+// SpawnEntity spawns an entity of the given type on the next tick and
+// returns its ID.
+func (g *GameServer) SpawnEntity(typ string, x, y, z float64) (int32, bool) {
+	e, ok := newMob(typ, x, y, z)
+	if !ok {
+		return 0, false
+	}
+
+	g.scheduler.RunLater(0, func() {
+		g.tracker.add(e)
+	})
+	return e.id, true
+}
+
+// RemoveEntity removes an entity spawned with SpawnEntity on the next
+// tick.
+func (g *GameServer) RemoveEntity(id int32) {
+	g.scheduler.RunLater(0, func() {
+		if entry, ok := g.tracker.entries[id]; ok {
+			if _, ok := entry.entity.(*mob); ok {
+				g.tracker.remove(id)
+			}
+		}
+	})
+}
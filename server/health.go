@@ -0,0 +1,75 @@
+package server
+
+import (
+	"log"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+)
+
+const maxHealth = 20
+
+// damage hurts a player. Must be called with player.m held.
+func (g *GameServer) damage(player *GamePlayer, amount float32) {
+	if player.health <= 0 {
+		return
+	}
+
+	player.health -= amount
+	if player.health <= 0 {
+		player.health = 0
+		log.Printf("[GAME] %s died", player.name)
+	}
+
+	g.sendHealth(player)
+}
+
+func (g *GameServer) sendHealth(player *GamePlayer) {
+	p := packet.NewPacket(0x49)
+	w := packet.NewWriter(p)
+
+	w.WriteFloat(player.health)
+	w.WriteVarint(20) // food
+	w.WriteFloat(5)   // saturation
+
+	player.sess.QueuePacket(p)
+}
+
+const clientStatusRespawn = 0
+
+func (g *GameServer) handleClientStatus(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+	action, _ := r.ReadVarint()
+
+	if action == clientStatusRespawn {
+		g.respawn(player)
+	}
+}
+
+// respawn brings a dead player back at the spawn point.
+func (g *GameServer) respawn(player *GamePlayer) {
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	if player.health > 0 {
+		return
+	}
+
+	p := packet.NewPacket(0x3b)
+	w := packet.NewWriter(p)
+	w.WriteInt(GameDimensionOverworld)
+	w.WriteLong(hashSeed(g.world.Seed()))
+	w.WriteUbyte(player.gameMode)
+	w.WriteString(g.world.LevelType())
+	player.sess.QueuePacket(p)
+
+	player.x, player.y, player.z = g.spawnPoint()
+	player.health = maxHealth
+	player.fallDistance = 0
+	player.airTicks = 0
+
+	g.sendHealth(player)
+	g.teleport(player.sess, player)
+	if player.view != nil {
+		player.view.moveTo(player.x, player.z)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"github.com/skdltmxn/go-mine/net/packet"
+)
+
+const (
+	// slots of the player inventory window
+	inventorySize  = 46
+	mainSlotStart  = 9
+	hotbarStart    = 36
+	hotbarEnd      = 45
+	maxStackSize   = 64
+	playerWindowId = 0
+)
+
+type itemStack struct {
+	id    int32
+	count int8
+}
+
+func (s itemStack) empty() bool {
+	return s.count <= 0
+}
+
+func writeSlot(w *packet.Writer, s itemStack) {
+	if s.empty() {
+		w.WriteBool(false)
+		return
+	}
+
+	w.WriteBool(true)
+	w.WriteVarint(int(s.id))
+	w.WriteByte(s.count)
+	w.WriteByte(0) // no NBT
+}
+
+// readSlot reads a slot without its NBT, which the server does not keep.
+func readSlot(r *packet.Reader) (itemStack, error) {
+	present, err := r.ReadBoolean()
+	if err != nil || !present {
+		return itemStack{}, err
+	}
+
+	id, err := r.ReadVarint()
+	if err != nil {
+		return itemStack{}, err
+	}
+	count, err := r.ReadByte()
+	return itemStack{int32(id), count}, err
+}
+
+func (g *GameServer) sendSlot(player *GamePlayer, slot int, s itemStack) {
+	p := packet.NewPacket(0x17)
+	w := packet.NewWriter(p)
+	w.WriteByte(playerWindowId)
+	w.WriteShort(int16(slot))
+	writeSlot(w, s)
+
+	player.sess.QueuePacket(p)
+}
+
+// handleCreativeInventory keeps track of the items a creative player
+// takes from the creative menu.
+func (g *GameServer) handleCreativeInventory(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+	slot, err := r.ReadShort()
+	if err != nil {
+		return
+	}
+	s, err := readSlot(r)
+	if err != nil || slot < 0 || slot >= inventorySize {
+		return
+	}
+
+	player.m.Lock()
+	if player.gameMode == GameModeCreative {
+		player.inventory[slot] = s
+	}
+	player.m.Unlock()
+}
+
+// giveItem puts items into the hotbar and then the main inventory,
+// filling stacks of the same item first. It returns how many did not fit.
+func (g *GameServer) giveItem(player *GamePlayer, id int32, count int) int {
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	order := make([]int, 0, hotbarEnd-mainSlotStart)
+	for i := hotbarStart; i < hotbarEnd; i++ {
+		order = append(order, i)
+	}
+	for i := mainSlotStart; i < hotbarStart; i++ {
+		order = append(order, i)
+	}
+
+	// fill stacks of the item, then empty slots
+	for pass := 0; pass < 2 && count > 0; pass++ {
+		for _, slot := range order {
+			if count == 0 {
+				break
+			}
+
+			s := &player.inventory[slot]
+			if pass == 0 && (s.empty() || s.id != id) || pass == 1 && !s.empty() {
+				continue
+			}
+
+			n := maxStackSize - int(s.count)
+			if s.empty() {
+				n = maxStackSize
+			}
+			if n > count {
+				n = count
+			}
+			if n <= 0 {
+				continue
+			}
+
+			if s.empty() {
+				*s = itemStack{id, 0}
+			}
+			s.count += int8(n)
+			count -= n
+			g.sendSlot(player, slot, *s)
+		}
+	}
+
+	return count
+}
@@ -5,14 +5,17 @@ import (
 	"crypto/rand"
 	crand "crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/binary"
 	"encoding/hex"
 	"log"
-	mrand "math/rand"
 
 	"github.com/skdltmxn/go-mine/net"
 	"github.com/skdltmxn/go-mine/net/crypto"
 	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/util/chat"
+	"github.com/skdltmxn/go-mine/world"
 )
 
 type LoginPlayer struct {
@@ -20,20 +23,47 @@ type LoginPlayer struct {
 	pubKey  *rsa.PublicKey
 	name    string
 	uuid    string
+
+	// skin textures, signed by Mojang
+	properties []authProperties
 }
 
 type LoginServer struct {
 	sessMap map[*net.Session]*LoginPlayer
 	tunnel  chan<- *DataTunnel
+	world   *world.World
+	access  *Access
+
+	// sent in Join Game; set before the server runs
+	viewDistance int
 }
 
-func NewLoginServer() *LoginServer {
+func NewLoginServer(w *world.World, access *Access) *LoginServer {
 	return &LoginServer{
 		make(map[*net.Session]*LoginPlayer),
 		getTunnelSender(),
+		w,
+		access,
+		defaultViewDistance,
 	}
 }
 
+// SetViewDistance sets the view distance clients are told, which should
+// be the limit of the game server.
+func (d *LoginServer) SetViewDistance(distance int) {
+	d.viewDistance = clampViewDistance(distance)
+}
+
+// hashSeed returns the first 8 bytes of the SHA-256 of the seed, which
+// is all the client gets to compute biome colors. Like Guava's hashLong
+// and asLong in vanilla, both ends are little-endian.
+func hashSeed(seed int64) int64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(seed))
+	sum := sha256.Sum256(b[:])
+	return int64(binary.LittleEndian.Uint64(sum[:8]))
+}
+
 func (d *LoginServer) Dispatch(sess *net.Session, p *packet.Packet) bool {
 	if sess.State() != net.SessionStateLogin {
 		return false
@@ -43,7 +73,7 @@ func (d *LoginServer) Dispatch(sess *net.Session, p *packet.Packet) bool {
 	case 0:
 		d.requestEncryption(sess, p)
 	case 1:
-		if d.authenticate(sess, p) {
+		if d.authenticate(sess, p) && d.checkAccess(sess) {
 			d.loginSuccess(sess)
 			d.joinGame(sess)
 		}
@@ -54,6 +84,32 @@ func (d *LoginServer) Dispatch(sess *net.Session, p *packet.Packet) bool {
 	return true
 }
 
+// checkAccess disconnects banned players and the ones not on the
+// whitelist.
+func (d *LoginServer) checkAccess(sess *net.Session) bool {
+	ctx := d.sessMap[sess]
+
+	var reason *chat.Component
+	if ban := d.access.banned(ctx.uuid, ctx.name); ban != nil {
+		reason = chat.Translate("multiplayer.disconnect.banned.reason", chat.Text(ban.Reason))
+	} else if !d.access.allowed(ctx.uuid, ctx.name) {
+		reason = chat.Translate("multiplayer.disconnect.not_whitelisted")
+	} else {
+		return true
+	}
+
+	log.Printf("[LOGIN] Refused %s: %s", ctx.name, reason.Plain())
+
+	p := packet.NewPacket(0)
+	w := packet.NewWriter(p)
+	w.WriteString(reason.String())
+	sess.SendPacket(p)
+	sess.Close()
+
+	delete(d.sessMap, sess)
+	return false
+}
+
 func (d *LoginServer) loginSuccess(sess *net.Session) {
 	ctx := d.sessMap[sess]
 
@@ -66,7 +122,6 @@ func (d *LoginServer) loginSuccess(sess *net.Session) {
 
 func (d *LoginServer) joinGame(sess *net.Session) {
 	newEid := getNextEntityId()
-	d.tunnel <- newDataTunnel(sess, d.sessMap[sess].name, newEid)
 
 	joinGamePacket := packet.NewPacket(0x26)
 	w := packet.NewWriter(joinGamePacket)
@@ -74,15 +129,20 @@ func (d *LoginServer) joinGame(sess *net.Session) {
 	w.WriteInt(newEid) // entity id
 	w.WriteUbyte(GameModeCreative)
 	w.WriteInt(GameDimensionOverworld)
-	w.WriteLong(mrand.Int63())
-	w.WriteUbyte(0)
-	w.WriteString(GameLevelDefault)
-	w.WriteVarint(32)  // render distance
-	w.WriteBool(false) // reduced debug info
-	w.WriteBool(true)  // enable respawn screen
+	w.WriteLong(hashSeed(d.world.Seed()))
+	w.WriteUbyte(maxPlayers)
+	w.WriteString(d.world.LevelType())
+	w.WriteVarint(d.viewDistance) // render distance
+	w.WriteBool(false)            // reduced debug info
+	w.WriteBool(true)             // enable respawn screen
 
 	sess.SetState(net.SessionStateGame)
 	sess.SendPacket(joinGamePacket)
+
+	// hand over after Join Game so the game server never sends anything
+	// before it
+	ctx := d.sessMap[sess]
+	d.tunnel <- newDataTunnel(sess, ctx.name, ctx.uuid, ctx.properties, newEid)
 }
 
 func (d *LoginServer) requestEncryption(sess *net.Session, p *packet.Packet) {
@@ -111,6 +171,7 @@ func (d *LoginServer) requestEncryption(sess *net.Session, p *packet.Packet) {
 		rsaPubKey,
 		name,
 		"",
+		nil,
 	}
 
 	req := packet.NewPacket(1)
@@ -153,6 +214,7 @@ func (d *LoginServer) authenticate(sess *net.Session, p *packet.Packet) bool {
 	}
 
 	ctx.uuid = authResult.Id
+	ctx.properties = authResult.Properties
 
 	// RSA key pair is no longer used
 	ctx.privKey = nil
@@ -0,0 +1,20 @@
+package server
+
+import "testing"
+
+func TestHashSeed(t *testing.T) {
+	// Hashing.sha256().hashLong(seed).asLong() of vanilla
+	cases := []struct {
+		seed, hash int64
+	}{
+		{0, 8794265229978523055},
+		{1, -6467378160175308932},
+		{-4172144997902289642, 2159143436479834350},
+	}
+
+	for _, c := range cases {
+		if h := hashSeed(c.seed); h != c.hash {
+			t.Fatalf("hashSeed(%d) = %d, expected %d", c.seed, h, c.hash)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/server/tick"
+)
+
+const (
+	// in ticks, like the vanilla server
+	keepAliveInterval  = 15 * tick.TPS
+	timeUpdateInterval = tick.TPS
+
+	keepAliveTimeout = 30 * time.Second
+)
+
+// tick runs on the tick loop. Everything a player does is handled here,
+// in the order the packets were received.
+func (g *GameServer) tick() {
+	for _, player := range g.players() {
+		select {
+		case <-player.sess.Done():
+			g.removePlayer(player)
+			continue
+		default:
+		}
+
+		if player.view == nil {
+			g.spawnPlayer(player)
+		}
+
+		player.inboxLock.Lock()
+		inbox := player.inbox
+		player.inbox = nil
+		player.inboxLock.Unlock()
+
+		for _, p := range inbox {
+			g.handlePacket(player, p)
+		}
+	}
+
+	g.updateTime()
+	if g.world.TickWeather() {
+		g.broadcastWeather()
+	}
+	g.tracker.update(g.players())
+
+	for _, player := range g.players() {
+		if player.view != nil {
+			g.sendLightUpdates(player.view.sendPending())
+		}
+	}
+
+	g.scheduler.Run()
+
+	// written by the session, so a slow client does not hold up the tick
+	for _, player := range g.players() {
+		player.sess.Flush()
+	}
+}
+
+func (g *GameServer) updateTime() {
+	age, dayTime := g.world.Time()
+	if cycle, _ := g.world.GameRule("doDaylightCycle"); cycle != "false" {
+		dayTime++
+	}
+	g.world.SetTime(age+1, dayTime)
+}
+
+func (g *GameServer) sendTimeUpdates() {
+	age, dayTime := g.world.Time()
+
+	// a negative time of day stops the sun on the client
+	if cycle, _ := g.world.GameRule("doDaylightCycle"); cycle == "false" {
+		dayTime = -dayTime
+		if dayTime == 0 {
+			dayTime = -1
+		}
+	}
+
+	p := packet.NewPacket(0x4f)
+	w := packet.NewWriter(p)
+	w.WriteLong(age)
+	w.WriteLong(dayTime)
+
+	for _, player := range g.players() {
+		player.sess.QueuePacket(p)
+	}
+}
+
+// sendKeepAlives pings every player and drops the ones that did not
+// answer the last ping in time. Like the vanilla server, the id of a ping
+// is the time it was sent in milliseconds.
+func (g *GameServer) sendKeepAlives() {
+	now := millis()
+
+	for _, player := range g.players() {
+		player.m.Lock()
+		if player.keepAliveId != 0 {
+			if now-player.keepAliveId >= int64(keepAliveTimeout/time.Millisecond) {
+				log.Printf("[GAME] %s timed out", player.name)
+				player.sess.Close()
+			}
+			player.m.Unlock()
+			continue
+		}
+		player.keepAliveId = now
+		player.m.Unlock()
+
+		p := packet.NewPacket(0x21)
+		w := packet.NewWriter(p)
+		w.WriteLong(now)
+
+		player.sess.QueuePacket(p)
+	}
+}
+
+func (g *GameServer) handleKeepAlive(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+	id, err := r.ReadLong()
+	if err != nil {
+		return
+	}
+
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	if id != 0 && id == player.keepAliveId {
+		player.ping = int(millis() - id)
+		player.keepAliveId = 0
+	}
+}
+
+func millis() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
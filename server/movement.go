@@ -0,0 +1,152 @@
+package server
+
+import (
+	"log"
+	"math"
+
+	"github.com/skdltmxn/go-mine/net"
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/util/chat"
+)
+
+// teleport sends the player position to the client. Must be called with
+// player.m held.
+func (g *GameServer) teleport(sess *net.Session, player *GamePlayer) {
+	player.teleportId++
+
+	p := packet.NewPacket(0x36)
+	w := packet.NewWriter(p)
+
+	w.WriteDouble(player.x)
+	w.WriteDouble(player.y)
+	w.WriteDouble(player.z)
+	w.WriteFloat(player.yaw)
+	w.WriteFloat(player.pitch)
+	w.WriteByte(0) // all absolute
+	w.WriteVarint(player.teleportId)
+
+	sess.QueuePacket(p)
+}
+
+func (g *GameServer) confirmTeleport(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+	id, _ := r.ReadVarint()
+
+	player.m.Lock()
+	if id == player.teleportId {
+		player.teleportId = 0
+	}
+	player.m.Unlock()
+}
+
+func (g *GameServer) handleMovement(player *GamePlayer, p *packet.Packet) {
+	r := packet.NewReader(p)
+
+	player.m.Lock()
+	defer player.m.Unlock()
+
+	// positions sent before the client confirmed a teleport are stale
+	if player.teleportId != 0 || player.health <= 0 {
+		return
+	}
+
+	m := Move{
+		FromX: player.x, FromY: player.y, FromZ: player.z,
+		ToX: player.x, ToY: player.y, ToZ: player.z,
+		GameMode: player.gameMode,
+	}
+	yaw, pitch := player.yaw, player.pitch
+
+	clientOnGround, err := readMove(r, p.Id(), &m, &yaw, &pitch)
+	if err != nil {
+		// the vanilla server kicks for this too
+		log.Printf("[GAME] %s %s", player.name, err)
+		g.kick(player, chat.Translate("multiplayer.disconnect.invalid_player_movement"))
+		return
+	}
+	player.onGround = clientOnGround
+
+	// the client is not trusted to tell whether it is on the ground
+	m.OnGround = onGround(g.world, m.ToX, m.ToY, m.ToZ)
+	m.AirTicks = player.airTicks + 1
+	if supported(g.world, m.ToX, m.ToY, m.ToZ) {
+		m.AirTicks = 0
+	}
+
+	if m.ToX != m.FromX || m.ToY != m.FromY || m.ToZ != m.FromZ {
+		for _, check := range g.movementChecks() {
+			if err := check.Check(g.world, &m); err != nil {
+				log.Printf("[GAME] %s %s! (%.2f, %.2f, %.2f)", player.name, err, m.ToX-m.FromX, m.ToY-m.FromY, m.ToZ-m.FromZ)
+				g.teleport(player.sess, player)
+				return
+			}
+		}
+	}
+
+	player.x, player.y, player.z = m.ToX, m.ToY, m.ToZ
+	player.yaw, player.pitch = yaw, pitch
+	player.airTicks = m.AirTicks
+	g.updateFall(player, &m)
+
+	if player.view != nil {
+		player.view.moveTo(player.x, player.z)
+	}
+}
+
+// readMove reads the destination and rotation of a movement packet into
+// m, yaw and pitch, which hold the current ones. It fails unless all of
+// them are finite and inside the world.
+func readMove(r *packet.Reader, id int, m *Move, yaw, pitch *float32) (onGround bool, err error) {
+	if id == 0x11 || id == 0x12 {
+		if m.ToX, err = r.ReadDouble(); err != nil {
+			return false, ErrInvalidMove
+		}
+		if m.ToY, err = r.ReadDouble(); err != nil {
+			return false, ErrInvalidMove
+		}
+		if m.ToZ, err = r.ReadDouble(); err != nil {
+			return false, ErrInvalidMove
+		}
+	}
+	if id == 0x12 || id == 0x13 {
+		if *yaw, err = r.ReadFloat(); err != nil {
+			return false, ErrInvalidMove
+		}
+		if *pitch, err = r.ReadFloat(); err != nil {
+			return false, ErrInvalidMove
+		}
+	}
+	if onGround, err = r.ReadBoolean(); err != nil {
+		return false, ErrInvalidMove
+	}
+
+	if !m.valid(*yaw, *pitch) {
+		return false, ErrInvalidMove
+	}
+
+	return onGround, nil
+}
+
+// players fall this many blocks before taking damage
+const safeFallDistance = 3
+
+// updateFall tracks how far a player fell and hurts it on landing. Must
+// be called with player.m held.
+func (g *GameServer) updateFall(player *GamePlayer, m *Move) {
+	if m.GameMode == GameModeCreative || m.GameMode == GameModeSpectator ||
+		inLiquid(g.world, m.ToX, m.ToY, m.ToZ) {
+		player.fallDistance = 0
+		return
+	}
+
+	if dy := m.ToY - m.FromY; dy < 0 {
+		player.fallDistance -= dy
+	}
+
+	if m.OnGround {
+		if damage := math.Ceil(player.fallDistance - safeFallDistance); damage > 0 {
+			g.damage(player, float32(damage))
+		}
+		player.fallDistance = 0
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/server/tick"
+	"github.com/skdltmxn/go-mine/util/chat"
+)
+
+// actions of Player Info
+const (
+	playerInfoAdd = iota
+	playerInfoGameMode
+	playerInfoLatency
+	playerInfoDisplayName
+	playerInfoRemove
+)
+
+// like the vanilla server
+const latencyUpdateInterval = 30 * tick.TPS
+
+func playerInfoPacket(action int, players []*GamePlayer) *packet.Packet {
+	p := packet.NewPacket(0x34)
+	w := packet.NewWriter(p)
+
+	w.WriteVarint(action)
+	w.WriteVarint(len(players))
+	for _, player := range players {
+		writeUUID(w, player.uuid)
+
+		player.m.Lock()
+		switch action {
+		case playerInfoAdd:
+			w.WriteString(player.name)
+			w.WriteVarint(len(player.properties))
+			for _, prop := range player.properties {
+				w.WriteString(prop.Name)
+				w.WriteString(prop.Value)
+				w.WriteBool(prop.Signature != "")
+				if prop.Signature != "" {
+					w.WriteString(prop.Signature)
+				}
+			}
+			w.WriteVarint(int(player.gameMode))
+			w.WriteVarint(player.ping)
+			writeDisplayName(w, player.displayName)
+		case playerInfoGameMode:
+			w.WriteVarint(int(player.gameMode))
+		case playerInfoLatency:
+			w.WriteVarint(player.ping)
+		case playerInfoDisplayName:
+			writeDisplayName(w, player.displayName)
+		}
+		player.m.Unlock()
+	}
+
+	return p
+}
+
+func writeDisplayName(w *packet.Writer, name *chat.Component) {
+	w.WriteBool(name != nil)
+	if name != nil {
+		w.WriteString(name.String())
+	}
+}
+
+// spawnedPlayers returns the players who are in the tab list.
+func (g *GameServer) spawnedPlayers() []*GamePlayer {
+	var players []*GamePlayer
+	for _, player := range g.players() {
+		player.m.Lock()
+		spawned := player.view != nil
+		player.m.Unlock()
+
+		if spawned {
+			players = append(players, player)
+		}
+	}
+
+	return players
+}
+
+func (g *GameServer) broadcastPacket(p *packet.Packet) {
+	for _, player := range g.spawnedPlayers() {
+		player.sess.QueuePacket(p)
+	}
+}
+
+// addToTabList shows a player who joined to everyone and everyone to the
+// player. It must run before the player is spawned for the others, as
+// clients ignore players they have no info on.
+func (g *GameServer) addToTabList(player *GamePlayer) {
+	players := g.spawnedPlayers()
+
+	add := playerInfoPacket(playerInfoAdd, []*GamePlayer{player})
+	for _, other := range players {
+		other.sess.QueuePacket(add)
+	}
+
+	player.sess.QueuePacket(playerInfoPacket(playerInfoAdd, append(players, player)))
+
+	g.m.RLock()
+	header, footer := g.tabHeader, g.tabFooter
+	g.m.RUnlock()
+	if header != nil || footer != nil {
+		player.sess.QueuePacket(tabListPacket(header, footer))
+	}
+}
+
+func (g *GameServer) removeFromTabList(player *GamePlayer) {
+	g.broadcastPacket(playerInfoPacket(playerInfoRemove, []*GamePlayer{player}))
+}
+
+func (g *GameServer) sendLatencies() {
+	if players := g.spawnedPlayers(); len(players) > 0 {
+		g.broadcastPacket(playerInfoPacket(playerInfoLatency, players))
+	}
+}
+
+// changeGameMode sets the game mode of a player and shows it to
+// everyone.
+func (g *GameServer) changeGameMode(player *GamePlayer, mode uint8) {
+	player.m.Lock()
+	player.gameMode = mode
+	player.m.Unlock()
+
+	p := packet.NewPacket(0x1f)
+	w := packet.NewWriter(p)
+	w.WriteUbyte(3) // change game mode
+	w.WriteFloat(float32(mode))
+	player.sess.QueuePacket(p)
+
+	g.broadcastPacket(playerInfoPacket(playerInfoGameMode, []*GamePlayer{player}))
+}
+
+// SetDisplayName changes the name shown for a player in the tab list.
+// A nil name restores the player name.
+func (g *GameServer) SetDisplayName(name string, displayName *chat.Component) bool {
+	player := g.playerByName(name)
+	if player == nil {
+		return false
+	}
+
+	player.m.Lock()
+	player.displayName = displayName
+	player.m.Unlock()
+
+	g.broadcastPacket(playerInfoPacket(playerInfoDisplayName, []*GamePlayer{player}))
+	return true
+}
+
+// SetTabList sets the header and footer of the tab list for everyone.
+func (g *GameServer) SetTabList(header, footer *chat.Component) {
+	g.m.Lock()
+	g.tabHeader, g.tabFooter = header, footer
+	g.m.Unlock()
+
+	g.broadcastPacket(tabListPacket(header, footer))
+}
+
+func tabListPacket(header, footer *chat.Component) *packet.Packet {
+	// an empty translation clears the text
+	if header == nil {
+		header = chat.Translate("")
+	}
+	if footer == nil {
+		footer = chat.Translate("")
+	}
+
+	p := packet.NewPacket(0x54)
+	w := packet.NewWriter(p)
+	w.WriteString(header.String())
+	w.WriteString(footer.String())
+
+	return p
+}
@@ -0,0 +1,135 @@
+// Package tick drives the game at a fixed rate of 20 ticks per second.
+package tick
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	TPS      = 20
+	Interval = time.Second / TPS
+
+	// a loop this far behind gives up on the missed ticks instead of
+	// running them back to back
+	maxLag = 2 * time.Second
+
+	// number of ticks TPS and MSPT are averaged over
+	sampleCount = 100
+)
+
+// Loop calls a function every tick. Ticks that overrun are caught up by
+// running the next ones without sleeping.
+type Loop struct {
+	tick func()
+
+	m         sync.Mutex
+	durations [sampleCount]time.Duration
+	starts    [sampleCount]time.Time
+	count     int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewLoop(tick func()) *Loop {
+	return &Loop{
+		tick: tick,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Run ticks until Stop is called.
+func (l *Loop) Run() {
+	defer close(l.done)
+
+	next := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		default:
+		}
+
+		start := time.Now()
+		l.tick()
+		l.record(start, time.Since(start))
+
+		next = next.Add(Interval)
+		now := time.Now()
+		if lag := now.Sub(next); lag > maxLag {
+			log.Printf("[TICK] Can't keep up! Running %dms or %d ticks behind",
+				lag/time.Millisecond, lag/Interval)
+			next = now
+		} else if lag < 0 {
+			select {
+			case <-l.stop:
+				return
+			case <-time.After(-lag):
+			}
+		}
+	}
+}
+
+// Stop stops the loop and waits for the running tick to finish.
+func (l *Loop) Stop() {
+	close(l.stop)
+	<-l.done
+}
+
+func (l *Loop) record(start time.Time, d time.Duration) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	i := l.count % sampleCount
+	l.starts[i] = start
+	l.durations[i] = d
+	l.count++
+}
+
+// TPS returns the ticks per second over the last ticks.
+func (l *Loop) TPS() float64 {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	n := l.count
+	if n > sampleCount {
+		n = sampleCount
+	}
+	if n < 2 {
+		return TPS
+	}
+
+	last := l.starts[(l.count-1)%sampleCount]
+	first := l.starts[(l.count-n)%sampleCount]
+	tps := float64(n-1) / last.Sub(first).Seconds()
+	if tps > TPS {
+		tps = TPS
+	}
+
+	return tps
+}
+
+// MSPT returns the average milliseconds spent per tick over the last
+// ticks.
+func (l *Loop) MSPT() float64 {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	n := l.count
+	if n > sampleCount {
+		n = sampleCount
+	}
+	if n == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for i := 0; i < n; i++ {
+		total += l.durations[i]
+	}
+
+	return float64(total) / float64(n) / float64(time.Millisecond)
+}
@@ -0,0 +1,127 @@
+package tick
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// Task is a function scheduled to run on a later tick.
+type Task struct {
+	due       int64
+	period    int64
+	seq       uint64
+	fn        func()
+	cancelled bool
+	s         *Scheduler
+}
+
+// Cancel stops the task from running again. It may be called from the
+// task itself.
+func (t *Task) Cancel() {
+	t.s.m.Lock()
+	t.cancelled = true
+	t.s.m.Unlock()
+}
+
+type taskHeap []*Task
+
+func (h taskHeap) Len() int { return len(h) }
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].due != h[j].due {
+		return h[i].due < h[j].due
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(*Task)) }
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
+
+// Scheduler runs tasks on the tick they are due. Tasks may be scheduled
+// from any goroutine but always run on the one calling Run, in the order
+// they were scheduled when due on the same tick.
+type Scheduler struct {
+	m     sync.Mutex
+	tick  int64
+	seq   uint64
+	tasks taskHeap
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Tick returns the number of ticks run so far.
+func (s *Scheduler) Tick() int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.tick
+}
+
+// RunLater runs fn once after delay ticks. A delay below 1 means the next
+// tick.
+func (s *Scheduler) RunLater(delay int, fn func()) *Task {
+	return s.schedule(delay, 0, fn)
+}
+
+// RunRepeating runs fn after delay ticks and then every period ticks
+// until the task is cancelled.
+func (s *Scheduler) RunRepeating(delay, period int, fn func()) *Task {
+	if period < 1 {
+		period = 1
+	}
+
+	return s.schedule(delay, period, fn)
+}
+
+func (s *Scheduler) schedule(delay, period int, fn func()) *Task {
+	if delay < 1 {
+		delay = 1
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.seq++
+	t := &Task{
+		due:    s.tick + int64(delay),
+		period: int64(period),
+		seq:    s.seq,
+		fn:     fn,
+		s:      s,
+	}
+	heap.Push(&s.tasks, t)
+
+	return t
+}
+
+// Run advances the scheduler by one tick and runs the tasks due.
+func (s *Scheduler) Run() {
+	s.m.Lock()
+	s.tick++
+	tick := s.tick
+
+	for len(s.tasks) > 0 && s.tasks[0].due <= tick {
+		t := heap.Pop(&s.tasks).(*Task)
+		if t.cancelled {
+			continue
+		}
+
+		s.m.Unlock()
+		t.fn()
+		s.m.Lock()
+
+		if t.period > 0 && !t.cancelled {
+			t.due = tick + t.period
+			s.seq++
+			t.seq = s.seq
+			heap.Push(&s.tasks, t)
+		}
+	}
+	s.m.Unlock()
+}
@@ -0,0 +1,37 @@
+package tick
+
+import "testing"
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler()
+
+	var order []string
+	s.RunLater(2, func() { order = append(order, "later") })
+	s.RunLater(0, func() { order = append(order, "next") })
+
+	count := 0
+	var repeating *Task
+	repeating = s.RunRepeating(1, 2, func() {
+		count++
+		if count == 3 {
+			repeating.Cancel()
+		}
+	})
+
+	cancelled := s.RunLater(1, func() { t.Fatalf("cancelled task ran") })
+	cancelled.Cancel()
+
+	for i := 0; i < 10; i++ {
+		s.Run()
+	}
+
+	if len(order) != 2 || order[0] != "next" || order[1] != "later" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	if count != 3 {
+		t.Fatalf("unexpected repeat count: %d", count)
+	}
+	if tick := s.Tick(); tick != 10 {
+		t.Fatalf("unexpected tick: %d", tick)
+	}
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"math"
+
+	"github.com/skdltmxn/go-mine/net/packet"
+)
+
+// tracking ranges in chunks by entity type, like the vanilla server
+var trackingRanges = map[string]int{
+	"minecraft:player":            32,
+	"minecraft:item":              6,
+	"minecraft:experience_orb":    6,
+	"minecraft:arrow":             4,
+	"minecraft:trident":           4,
+	"minecraft:falling_block":     10,
+	"minecraft:tnt":               10,
+	"minecraft:item_frame":        10,
+	"minecraft:ender_dragon":      10,
+	"minecraft:zombie":            8,
+	"minecraft:skeleton":          8,
+	"minecraft:creeper":           8,
+	"minecraft:spider":            8,
+	"minecraft:enderman":          8,
+	"minecraft:villager":          10,
+	"minecraft:cow":               10,
+	"minecraft:pig":               10,
+	"minecraft:sheep":             10,
+	"minecraft:chicken":           10,
+	"minecraft:horse":             10,
+	"minecraft:wolf":              10,
+	"minecraft:area_effect_cloud": 10,
+}
+
+const defaultTrackingRange = 5
+
+// moves larger than this in any axis do not fit in a relative move
+const maxRelativeMove = 8
+
+// sentPosition is the position of an entity as last sent to its viewers,
+// in the units of the movement packets.
+type sentPosition struct {
+	x, y, z    int64 // 1/4096 block
+	yaw, pitch uint8
+}
+
+func fixedPoint(v float64) int64 {
+	return int64(math.Floor(v * 4096))
+}
+
+func toAngle(degrees float32) uint8 {
+	return uint8(int32(math.Floor(float64(degrees) * 256 / 360)))
+}
+
+func toSentPosition(pos entityPosition) sentPosition {
+	return sentPosition{
+		fixedPoint(pos.x), fixedPoint(pos.y), fixedPoint(pos.z),
+		toAngle(pos.yaw), toAngle(pos.pitch),
+	}
+}
+
+type trackerEntry struct {
+	entity  entity
+	sent    sentPosition
+	viewers map[*GamePlayer]bool
+}
+
+// entityTracker shows entities to the players in their range and tells
+// them how the entities move. It is only used on the tick loop.
+type entityTracker struct {
+	entries map[int32]*trackerEntry
+}
+
+func newEntityTracker() *entityTracker {
+	return &entityTracker{
+		entries: make(map[int32]*trackerEntry),
+	}
+}
+
+func (t *entityTracker) add(e entity) {
+	t.entries[e.entityId()] = &trackerEntry{
+		entity:  e,
+		sent:    toSentPosition(e.position()),
+		viewers: make(map[*GamePlayer]bool),
+	}
+}
+
+// remove destroys an entity for the players who see it.
+func (t *entityTracker) remove(id int32) {
+	entry, ok := t.entries[id]
+	if !ok {
+		return
+	}
+
+	delete(t.entries, id)
+	for viewer := range entry.viewers {
+		viewer.sess.QueuePacket(destroyPacket([]int32{id}))
+	}
+}
+
+// removeViewer forgets what a player who left was shown.
+func (t *entityTracker) removeViewer(player *GamePlayer) {
+	for _, entry := range t.entries {
+		delete(entry.viewers, player)
+	}
+}
+
+// updateMetadata sends the metadata of an entity to its viewers again.
+func (t *entityTracker) updateMetadata(e entity) {
+	entry, ok := t.entries[e.entityId()]
+	if !ok || len(entry.viewers) == 0 {
+		return
+	}
+
+	p := metadataPacket(e)
+	for viewer := range entry.viewers {
+		viewer.sess.QueuePacket(p)
+	}
+}
+
+// inRange reports whether a player should see an entity. An entity is
+// never further than the view distance of the player.
+func (t *entityTracker) inRange(entry *trackerEntry, pos entityPosition, viewer *GamePlayer) bool {
+	if viewer.view == nil || viewer == entry.entity {
+		return false
+	}
+
+	r, ok := trackingRanges[entry.entity.entityType()]
+	if !ok {
+		r = defaultTrackingRange
+	}
+
+	viewer.m.Lock()
+	if r > viewer.viewDistance {
+		r = viewer.viewDistance
+	}
+	dx, dz := math.Abs(viewer.x-pos.x), math.Abs(viewer.z-pos.z)
+	viewer.m.Unlock()
+
+	limit := float64(r * 16)
+	return dx <= limit && dz <= limit && viewer.hasChunk(chunkPos{toChunk(pos.x), toChunk(pos.z)})
+}
+
+// update sends the movement of every entity to its viewers, then spawns
+// and destroys entities for the players who came in or went out of
+// their range.
+func (t *entityTracker) update(players []*GamePlayer) {
+	destroyed := make(map[*GamePlayer][]int32)
+
+	for id, entry := range t.entries {
+		pos := entry.entity.position()
+
+		t.sendMovement(entry, pos)
+
+		for _, player := range players {
+			visible := t.inRange(entry, pos, player)
+			if visible && !entry.viewers[player] {
+				entry.viewers[player] = true
+				player.sess.QueuePacket(entry.entity.spawnPacket())
+				player.sess.QueuePacket(metadataPacket(entry.entity))
+				if !objectTypes[entry.entity.entityType()] {
+					player.sess.QueuePacket(headLookPacket(id, entry.sent.yaw))
+				}
+			} else if !visible && entry.viewers[player] {
+				delete(entry.viewers, player)
+				destroyed[player] = append(destroyed[player], id)
+			}
+		}
+	}
+
+	for player, ids := range destroyed {
+		player.sess.QueuePacket(destroyPacket(ids))
+	}
+}
+
+// sendMovement tells the viewers of an entity how it moved since the
+// last tick, relative to what they were last told.
+func (t *entityTracker) sendMovement(entry *trackerEntry, pos entityPosition) {
+	last := entry.sent
+	cur := toSentPosition(pos)
+	if cur == last {
+		return
+	}
+	entry.sent = cur
+
+	if len(entry.viewers) == 0 {
+		return
+	}
+
+	id := int(entry.entity.entityId())
+	moved := cur.x != last.x || cur.y != last.y || cur.z != last.z
+	rotated := cur.yaw != last.yaw || cur.pitch != last.pitch
+	dx, dy, dz := cur.x-last.x, cur.y-last.y, cur.z-last.z
+
+	var packets []*packet.Packet
+	switch {
+	case abs(dx) >= maxRelativeMove*4096 || abs(dy) >= maxRelativeMove*4096 || abs(dz) >= maxRelativeMove*4096:
+		p := packet.NewPacket(0x57)
+		w := packet.NewWriter(p)
+		w.WriteVarint(id)
+		w.WriteDouble(pos.x)
+		w.WriteDouble(pos.y)
+		w.WriteDouble(pos.z)
+		w.WriteUbyte(cur.yaw)
+		w.WriteUbyte(cur.pitch)
+		w.WriteBool(pos.onGround)
+		packets = append(packets, p)
+	case moved && rotated:
+		p := packet.NewPacket(0x2a)
+		w := packet.NewWriter(p)
+		w.WriteVarint(id)
+		w.WriteShort(int16(dx))
+		w.WriteShort(int16(dy))
+		w.WriteShort(int16(dz))
+		w.WriteUbyte(cur.yaw)
+		w.WriteUbyte(cur.pitch)
+		w.WriteBool(pos.onGround)
+		packets = append(packets, p)
+	case moved:
+		p := packet.NewPacket(0x29)
+		w := packet.NewWriter(p)
+		w.WriteVarint(id)
+		w.WriteShort(int16(dx))
+		w.WriteShort(int16(dy))
+		w.WriteShort(int16(dz))
+		w.WriteBool(pos.onGround)
+		packets = append(packets, p)
+	case rotated:
+		p := packet.NewPacket(0x2b)
+		w := packet.NewWriter(p)
+		w.WriteVarint(id)
+		w.WriteUbyte(cur.yaw)
+		w.WriteUbyte(cur.pitch)
+		w.WriteBool(pos.onGround)
+		packets = append(packets, p)
+	}
+
+	// the head turns with the body
+	if cur.yaw != last.yaw {
+		packets = append(packets, headLookPacket(int32(id), cur.yaw))
+	}
+
+	for viewer := range entry.viewers {
+		for _, p := range packets {
+			viewer.sess.QueuePacket(p)
+		}
+	}
+}
+
+func headLookPacket(id int32, yaw uint8) *packet.Packet {
+	p := packet.NewPacket(0x3c)
+	w := packet.NewWriter(p)
+	w.WriteVarint(int(id))
+	w.WriteUbyte(yaw)
+	return p
+}
+
+func destroyPacket(ids []int32) *packet.Packet {
+	p := packet.NewPacket(0x38)
+	w := packet.NewWriter(p)
+	w.WriteVarint(len(ids))
+	for _, id := range ids {
+		w.WriteVarint(int(id))
+	}
+	return p
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -7,7 +7,10 @@ var tunnel = make(chan *DataTunnel)
 type DataTunnel struct {
 	sess *net.Session
 	name string
+	uuid string
 	eid  int32
+
+	properties []authProperties
 }
 
 func getTunnelReceiver() <-chan *DataTunnel {
@@ -18,6 +21,6 @@ func getTunnelSender() chan<- *DataTunnel {
 	return tunnel
 }
 
-func newDataTunnel(sess *net.Session, name string, eid int32) *DataTunnel {
-	return &DataTunnel{sess, name, eid}
+func newDataTunnel(sess *net.Session, name, uuid string, properties []authProperties, eid int32) *DataTunnel {
+	return &DataTunnel{sess, name, uuid, eid, properties}
 }
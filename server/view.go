@@ -0,0 +1,236 @@
+package server
+
+import (
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/skdltmxn/go-mine/net"
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/world"
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+const (
+	defaultViewDistance = 10
+	maxViewDistance     = 32
+
+	// at most this many chunks are sent per tick so that joining does not
+	// flood the connection
+	chunksPerTick = 8
+)
+
+type chunkPos struct {
+	x, z int32
+}
+
+// chunkView keeps track of the chunks a client has loaded and streams the
+// ones around it nearest first.
+type chunkView struct {
+	m        sync.Mutex
+	sess     *net.Session
+	world    *world.World
+	distance int
+	centerX  int32
+	centerZ  int32
+	loaded   map[chunkPos]bool
+	pending  []chunkPos
+}
+
+func newChunkView(sess *net.Session, w *world.World, x, z float64, distance int) *chunkView {
+	v := &chunkView{
+		sess:     sess,
+		world:    w,
+		distance: clampViewDistance(distance),
+		centerX:  toChunk(x),
+		centerZ:  toChunk(z),
+		loaded:   make(map[chunkPos]bool),
+	}
+
+	v.m.Lock()
+	v.sendViewPosition()
+	v.update()
+	v.m.Unlock()
+
+	return v
+}
+
+func toChunk(v float64) int32 {
+	return int32(math.Floor(v / 16))
+}
+
+func clampViewDistance(distance int) int {
+	if distance < 2 {
+		return 2
+	} else if distance > maxViewDistance {
+		return maxViewDistance
+	}
+
+	return distance
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (v *chunkView) setDistance(distance int) {
+	distance = clampViewDistance(distance)
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if distance != v.distance {
+		v.distance = distance
+		v.update()
+	}
+}
+
+// moveTo recenters the view when the player crosses a chunk border.
+func (v *chunkView) moveTo(x, z float64) {
+	cx, cz := toChunk(x), toChunk(z)
+
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if cx == v.centerX && cz == v.centerZ {
+		return
+	}
+
+	v.centerX, v.centerZ = cx, cz
+	v.sendViewPosition()
+	v.update()
+}
+
+func (v *chunkView) inRange(pos chunkPos) bool {
+	dx, dz := pos.x-v.centerX, pos.z-v.centerZ
+	d := int32(v.distance)
+	return dx >= -d && dx <= d && dz >= -d && dz <= d
+}
+
+// update unloads the chunks that left the view and queues the missing
+// ones. Must be called with v.m held.
+func (v *chunkView) update() {
+	for pos := range v.loaded {
+		if !v.inRange(pos) {
+			v.sendUnload(pos)
+			v.world.Release(pos.x, pos.z)
+			delete(v.loaded, pos)
+		}
+	}
+
+	v.pending = v.pending[:0]
+	d := int32(v.distance)
+	for x := v.centerX - d; x <= v.centerX+d; x++ {
+		for z := v.centerZ - d; z <= v.centerZ+d; z++ {
+			if pos := (chunkPos{x, z}); !v.loaded[pos] {
+				v.pending = append(v.pending, pos)
+			}
+		}
+	}
+
+	sort.Slice(v.pending, func(i, j int) bool {
+		return v.distanceSq(v.pending[i]) < v.distanceSq(v.pending[j])
+	})
+}
+
+func (v *chunkView) distanceSq(pos chunkPos) int64 {
+	dx, dz := int64(pos.x-v.centerX), int64(pos.z-v.centerZ)
+	return dx*dx + dz*dz
+}
+
+// sendPending sends the nearest pending chunks and returns the other
+// chunks whose light changed as they were loaded.
+func (v *chunkView) sendPending() []chunkPos {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	n := chunksPerTick
+	if n > len(v.pending) {
+		n = len(v.pending)
+	}
+
+	var changed []chunkPos
+	for _, pos := range v.pending[:n] {
+		others, err := v.sendChunk(pos)
+		if err != nil {
+			log.Printf("[GAME] Failed to send chunk %d, %d: %s", pos.x, pos.z, err)
+			continue
+		}
+		v.loaded[pos] = true
+		changed = append(changed, others...)
+	}
+	v.pending = v.pending[n:]
+
+	return changed
+}
+
+func (v *chunkView) sendChunk(pos chunkPos) ([]chunkPos, error) {
+	changed, err := v.world.Retain(pos.x, pos.z)
+	if err != nil {
+		return nil, err
+	}
+
+	var data, light *packet.Packet
+	v.world.LoadedChunk(pos.x, pos.z, func(c *chunk.Chunk) {
+		data, err = c.DataPacket()
+		light = c.LightPacket()
+	})
+	if err != nil {
+		v.world.Release(pos.x, pos.z)
+		return nil, err
+	}
+
+	v.sess.QueuePacket(light)
+	v.sess.QueuePacket(data)
+
+	others := make([]chunkPos, len(changed))
+	for i, p := range changed {
+		others[i] = chunkPos{p.X, p.Z}
+	}
+
+	return others, nil
+}
+
+// releaseAll lets the world unload the chunks of a closed session.
+func (v *chunkView) releaseAll() {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	for pos := range v.loaded {
+		v.world.Release(pos.x, pos.z)
+		delete(v.loaded, pos)
+	}
+	v.pending = nil
+}
+
+// isLoaded reports whether the client has the chunk.
+func (v *chunkView) isLoaded(pos chunkPos) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return v.loaded[pos]
+}
+
+func (v *chunkView) sendUnload(pos chunkPos) {
+	p := packet.NewPacket(0x1e)
+	w := packet.NewWriter(p)
+
+	w.WriteInt(pos.x)
+	w.WriteInt(pos.z)
+
+	v.sess.QueuePacket(p)
+}
+
+func (v *chunkView) sendViewPosition() {
+	p := packet.NewPacket(0x41)
+	w := packet.NewWriter(p)
+
+	w.WriteVarint(int(v.centerX))
+	w.WriteVarint(int(v.centerZ))
+
+	v.sess.QueuePacket(p)
+}
@@ -0,0 +1,207 @@
+// Package chat builds the JSON text components the client shows in chat,
+// the tab list and everywhere else text is sent.
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Colors of the client palette.
+const (
+	Black       = "black"
+	DarkBlue    = "dark_blue"
+	DarkGreen   = "dark_green"
+	DarkAqua    = "dark_aqua"
+	DarkRed     = "dark_red"
+	DarkPurple  = "dark_purple"
+	Gold        = "gold"
+	Gray        = "gray"
+	DarkGray    = "dark_gray"
+	Blue        = "blue"
+	Green       = "green"
+	Aqua        = "aqua"
+	Red         = "red"
+	LightPurple = "light_purple"
+	Yellow      = "yellow"
+	White       = "white"
+)
+
+type ClickEvent struct {
+	Action string `json:"action"`
+	Value  string `json:"value"`
+}
+
+type HoverEvent struct {
+	Action string     `json:"action"`
+	Value  *Component `json:"value"`
+}
+
+// Component is a piece of text with its style. Children in Extra inherit
+// the style of their parent.
+type Component struct {
+	Text      string       `json:"text"`
+	Translate string       `json:"translate,omitempty"`
+	With      []*Component `json:"with,omitempty"`
+
+	Color         string `json:"color,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underlined    bool   `json:"underlined,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Obfuscated    bool   `json:"obfuscated,omitempty"`
+
+	Insertion  string      `json:"insertion,omitempty"`
+	ClickEvent *ClickEvent `json:"clickEvent,omitempty"`
+	HoverEvent *HoverEvent `json:"hoverEvent,omitempty"`
+
+	Extra []*Component `json:"extra,omitempty"`
+}
+
+func Text(text string) *Component {
+	return &Component{Text: text}
+}
+
+// Translate returns a component the client translates with the given
+// key, filling its placeholders with the arguments.
+func Translate(key string, with ...*Component) *Component {
+	return &Component{Translate: key, With: with}
+}
+
+// Colored returns a text component of the given color.
+func Colored(text, color string) *Component {
+	return &Component{Text: text, Color: color}
+}
+
+// Append adds children to the component and returns it.
+func (c *Component) Append(children ...*Component) *Component {
+	c.Extra = append(c.Extra, children...)
+	return c
+}
+
+// String returns the JSON form sent to the client.
+func (c *Component) String() string {
+	var b strings.Builder
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	e.Encode(c)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// MarshalJSON leaves out the text of translated components. The client
+// reads text before translate, so an empty text would hide the
+// translation.
+func (c *Component) MarshalJSON() ([]byte, error) {
+	type component Component
+	v := struct {
+		Text *string `json:"text,omitempty"`
+		*component
+	}{nil, (*component)(c)}
+	if c.Translate == "" {
+		v.Text = &c.Text
+	}
+
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// Plain returns the text without style. Translated components give their
+// arguments, or their key if they have none.
+func (c *Component) Plain() string {
+	var b strings.Builder
+	c.writePlain(&b)
+	return b.String()
+}
+
+func (c *Component) writePlain(b *strings.Builder) {
+	b.WriteString(c.Text)
+	if c.Translate != "" && len(c.With) == 0 {
+		b.WriteString(c.Translate)
+	}
+	for i, arg := range c.With {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		arg.writePlain(b)
+	}
+	for _, child := range c.Extra {
+		child.writePlain(b)
+	}
+}
+
+var legacyColors = map[byte]string{
+	'0': Black, '1': DarkBlue, '2': DarkGreen, '3': DarkAqua,
+	'4': DarkRed, '5': DarkPurple, '6': Gold, '7': Gray,
+	'8': DarkGray, '9': Blue, 'a': Green, 'b': Aqua,
+	'c': Red, 'd': LightPurple, 'e': Yellow, 'f': White,
+}
+
+// FromLegacy converts text using legacy formatting codes, such as "&aHi"
+// when the code character is '&'. A color code resets the formatting
+// before it like it does in the client.
+func FromLegacy(text string, code byte) *Component {
+	root := Text("")
+	cur := &Component{}
+
+	flush := func(end int, start *int) {
+		if end > *start {
+			c := *cur
+			c.Text = text[*start:end]
+			root.Extra = append(root.Extra, &c)
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(text)-1; i++ {
+		if text[i] != code {
+			continue
+		}
+
+		f := text[i+1]
+		if f >= 'A' && f <= 'Z' {
+			f += 'a' - 'A'
+		}
+
+		next := *cur
+		if color, ok := legacyColors[f]; ok {
+			next = Component{Color: color}
+		} else {
+			switch f {
+			case 'k':
+				next.Obfuscated = true
+			case 'l':
+				next.Bold = true
+			case 'm':
+				next.Strikethrough = true
+			case 'n':
+				next.Underlined = true
+			case 'o':
+				next.Italic = true
+			case 'r':
+				next = Component{}
+			default:
+				continue
+			}
+		}
+
+		flush(i, &start)
+		*cur = next
+		i++
+		start = i + 1
+	}
+	flush(len(text), &start)
+
+	if len(root.Extra) == 1 {
+		return root.Extra[0]
+	}
+
+	return root
+}
@@ -0,0 +1,43 @@
+package chat
+
+import "testing"
+
+func TestComponentString(t *testing.T) {
+	c := Translate("chat.type.text", Text("Steve"), Colored("hi", Red))
+	expected := `{"translate":"chat.type.text","with":[{"text":"Steve"},{"text":"hi","color":"red"}]}`
+	if s := c.String(); s != expected {
+		t.Fatalf("unexpected JSON: %s", s)
+	}
+
+	if s := c.Plain(); s != "Steve hi" {
+		t.Fatalf("unexpected plain text: %s", s)
+	}
+}
+
+func TestFromLegacy(t *testing.T) {
+	c := FromLegacy("&aGreen &lbold&r plain &zkept", '&')
+	expected := `{"text":"","extra":[{"text":"Green ","color":"green"},{"text":"bold","color":"green","bold":true},{"text":" plain &zkept"}]}`
+	if s := c.String(); s != expected {
+		t.Fatalf("unexpected JSON: %s", s)
+	}
+
+	if s := FromLegacy("no codes", '&').String(); s != `{"text":"no codes"}` {
+		t.Fatalf("unexpected JSON: %s", s)
+	}
+}
+
+func TestLocalized(t *testing.T) {
+	c := Translate("commands.kick.success", Text("Steve"), Translate("multiplayer.disconnect.kicked"))
+	if s := c.Localized(English); s != "Kicked Steve: Kicked by an operator" {
+		t.Fatalf("unexpected text: %s", s)
+	}
+
+	lang := map[string]string{"swap": "%2$s and %1$s, 100%%"}
+	if s := Translate("swap", Text("a"), Text("b")).Localized(lang); s != "b and a, 100%" {
+		t.Fatalf("unexpected text: %s", s)
+	}
+
+	if s := Translate("unknown.key").Localized(English); s != "unknown.key" {
+		t.Fatalf("unexpected text: %s", s)
+	}
+}
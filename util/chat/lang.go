@@ -0,0 +1,133 @@
+package chat
+
+import (
+	"strconv"
+	"strings"
+)
+
+// English has the vanilla en_us text of the keys the server sends, for
+// places the client does not translate such as the console.
+var English = map[string]string{
+	"chat.cannotSend":        "Cannot send chat message",
+	"chat.type.announcement": "[%s] %s",
+	"chat.type.text":         "<%s> %s",
+
+	"commands.ban.success":                        "Banned %s: %s",
+	"commands.deop.success":                       "Made %s no longer a server operator",
+	"commands.gamemode.success.other":             "Set %s's game mode to %s",
+	"commands.gamemode.success.self":              "Set own game mode to %s",
+	"commands.give.success.multiple":              "Gave %s %s to %s players",
+	"commands.give.success.single":                "Gave %s %s to %s",
+	"commands.kick.success":                       "Kicked %s: %s",
+	"commands.list.players":                       "There are %s of a max of %s players online: %s",
+	"commands.message.display.incoming":           "%s whispers to you: %s",
+	"commands.message.display.outgoing":           "You whisper to %s: %s",
+	"commands.op.success":                         "Made %s a server operator",
+	"commands.pardon.success":                     "Unbanned %s",
+	"commands.save.saving":                        "Saving the game (this may take a moment!)",
+	"commands.save.success":                       "Saved the game",
+	"commands.stop.stopping":                      "Stopping the server",
+	"commands.teleport.success.entity.multiple":   "Teleported %s entities to %s",
+	"commands.teleport.success.entity.single":     "Teleported %s to %s",
+	"commands.teleport.success.location.multiple": "Teleported %s entities to %s, %s, %s",
+	"commands.teleport.success.location.single":   "Teleported %s to %s, %s, %s",
+	"commands.time.query":                         "The time is %s",
+	"commands.time.set":                           "Set the time to %s",
+	"commands.weather.set.clear":                  "Set the weather to clear",
+	"commands.weather.set.rain":                   "Set the weather to rain",
+	"commands.weather.set.thunder":                "Set the weather to rain & thunder",
+	"commands.whitelist.add.success":              "Added %s to the whitelist",
+	"commands.whitelist.disabled":                 "Whitelist is now turned off",
+	"commands.whitelist.enabled":                  "Whitelist is now turned on",
+	"commands.whitelist.list":                     "There are %s whitelisted players: %s",
+	"commands.whitelist.none":                     "There are no whitelisted players",
+	"commands.whitelist.reloaded":                 "Reloaded the whitelist",
+	"commands.whitelist.remove.success":           "Removed %s from the whitelist",
+
+	"gameMode.adventure": "Adventure Mode",
+	"gameMode.changed":   "Your game mode has been updated to %s",
+	"gameMode.creative":  "Creative Mode",
+	"gameMode.spectator": "Spectator Mode",
+	"gameMode.survival":  "Survival Mode",
+
+	"multiplayer.disconnect.banned":             "You are banned from this server.",
+	"multiplayer.disconnect.banned.reason":      "You are banned from this server.\nReason: %s",
+	"multiplayer.disconnect.illegal_characters": "Illegal characters in chat",
+	"multiplayer.disconnect.kicked":             "Kicked by an operator",
+	"multiplayer.disconnect.not_whitelisted":    "You are not white-listed on this server!",
+	"multiplayer.disconnect.server_shutdown":    "Server closed",
+	"multiplayer.player.joined":                 "%s joined the game",
+	"multiplayer.player.left":                   "%s left the game",
+}
+
+// Localized returns the text without style, translating with the given
+// language. Keys it lacks are written like Plain does.
+func (c *Component) Localized(lang map[string]string) string {
+	var b strings.Builder
+	c.writeLocalized(&b, lang)
+	return b.String()
+}
+
+func (c *Component) writeLocalized(b *strings.Builder, lang map[string]string) {
+	b.WriteString(c.Text)
+
+	if format, ok := lang[c.Translate]; ok {
+		writeFormat(b, format, c.With, lang)
+	} else {
+		if c.Translate != "" && len(c.With) == 0 {
+			b.WriteString(c.Translate)
+		}
+		for i, arg := range c.With {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			arg.writeLocalized(b, lang)
+		}
+	}
+
+	for _, child := range c.Extra {
+		child.writeLocalized(b, lang)
+	}
+}
+
+// writeFormat fills the %s and %1$s placeholders of a translation.
+func writeFormat(b *strings.Builder, format string, with []*Component, lang map[string]string) {
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		switch {
+		case format[i] == '%':
+			b.WriteByte('%')
+		case format[i] == 's':
+			if next < len(with) {
+				with[next].writeLocalized(b, lang)
+			}
+			next++
+		default:
+			// positional like %1$s
+			end := strings.Index(format[i:], "$s")
+			n, err := strconv.Atoi(format[i : i+max(end, 0)])
+			if end < 0 || err != nil {
+				b.WriteByte('%')
+				b.WriteByte(format[i])
+				continue
+			}
+			if n >= 1 && n <= len(with) {
+				with[n-1].writeLocalized(b, lang)
+			}
+			i += end + 1
+		}
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
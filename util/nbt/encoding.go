@@ -0,0 +1,232 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Encoding lays out the numbers of the binary format. Shorts, floats and
+// doubles are always fixed width; ints, longs and lengths may be encoded
+// differently, as in the network format of Bedrock Edition.
+type Encoding interface {
+	WriteInt16(w io.ByteWriter, v int16) error
+	WriteInt32(w io.ByteWriter, v int32) error
+	WriteInt64(w io.ByteWriter, v int64) error
+	WriteFloat32(w io.ByteWriter, v float32) error
+	WriteFloat64(w io.ByteWriter, v float64) error
+	// WriteLength writes the length of a list or an array.
+	WriteLength(w io.ByteWriter, n int) error
+	WriteStringLength(w io.ByteWriter, n int) error
+
+	ReadInt16(r io.ByteReader) (int16, error)
+	ReadInt32(r io.ByteReader) (int32, error)
+	ReadInt64(r io.ByteReader) (int64, error)
+	ReadFloat32(r io.ByteReader) (float32, error)
+	ReadFloat64(r io.ByteReader) (float64, error)
+	ReadLength(r io.ByteReader) (int, error)
+	ReadStringLength(r io.ByteReader) (int, error)
+}
+
+var (
+	// BigEndian is the format used by Java Edition on disk and on the wire.
+	BigEndian Encoding = fixedEncoding{binary.BigEndian}
+
+	// LittleEndian is the format of Bedrock Edition files such as level.dat
+	// and .mcstructure.
+	LittleEndian Encoding = fixedEncoding{binary.LittleEndian}
+
+	// NetworkLittleEndian is the format of Bedrock Edition packets. Ints,
+	// longs and list and array lengths are zigzag varints and string
+	// lengths are unsigned varints.
+	NetworkLittleEndian Encoding = varintEncoding{fixedEncoding{binary.LittleEndian}}
+)
+
+type fixedEncoding struct {
+	order binary.ByteOrder
+}
+
+func writeBytes(w io.ByteWriter, bs []byte) error {
+	for _, b := range bs {
+		if err := w.WriteByte(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readBytes(r io.ByteReader, bs []byte) error {
+	for i := range bs {
+		b, err := r.ReadByte()
+		if err == io.EOF && i > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		bs[i] = b
+	}
+
+	return nil
+}
+
+func (f fixedEncoding) WriteInt16(w io.ByteWriter, v int16) error {
+	var bs [2]byte
+	f.order.PutUint16(bs[:], uint16(v))
+	return writeBytes(w, bs[:])
+}
+
+func (f fixedEncoding) WriteInt32(w io.ByteWriter, v int32) error {
+	var bs [4]byte
+	f.order.PutUint32(bs[:], uint32(v))
+	return writeBytes(w, bs[:])
+}
+
+func (f fixedEncoding) WriteInt64(w io.ByteWriter, v int64) error {
+	var bs [8]byte
+	f.order.PutUint64(bs[:], uint64(v))
+	return writeBytes(w, bs[:])
+}
+
+func (f fixedEncoding) WriteFloat32(w io.ByteWriter, v float32) error {
+	return f.WriteInt32(w, int32(math.Float32bits(v)))
+}
+
+func (f fixedEncoding) WriteFloat64(w io.ByteWriter, v float64) error {
+	return f.WriteInt64(w, int64(math.Float64bits(v)))
+}
+
+func (f fixedEncoding) WriteLength(w io.ByteWriter, n int) error {
+	if n > math.MaxInt32 {
+		return errors.New("nbt: length too large")
+	}
+
+	return f.WriteInt32(w, int32(n))
+}
+
+func (f fixedEncoding) WriteStringLength(w io.ByteWriter, n int) error {
+	if n > 0xffff {
+		return errors.New("string too long")
+	}
+
+	return f.WriteInt16(w, int16(n))
+}
+
+func (f fixedEncoding) ReadInt16(r io.ByteReader) (int16, error) {
+	var bs [2]byte
+	err := readBytes(r, bs[:])
+	return int16(f.order.Uint16(bs[:])), err
+}
+
+func (f fixedEncoding) ReadInt32(r io.ByteReader) (int32, error) {
+	var bs [4]byte
+	err := readBytes(r, bs[:])
+	return int32(f.order.Uint32(bs[:])), err
+}
+
+func (f fixedEncoding) ReadInt64(r io.ByteReader) (int64, error) {
+	var bs [8]byte
+	err := readBytes(r, bs[:])
+	return int64(f.order.Uint64(bs[:])), err
+}
+
+func (f fixedEncoding) ReadFloat32(r io.ByteReader) (float32, error) {
+	v, err := f.ReadInt32(r)
+	return math.Float32frombits(uint32(v)), err
+}
+
+func (f fixedEncoding) ReadFloat64(r io.ByteReader) (float64, error) {
+	v, err := f.ReadInt64(r)
+	return math.Float64frombits(uint64(v)), err
+}
+
+func (f fixedEncoding) ReadLength(r io.ByteReader) (int, error) {
+	v, err := f.ReadInt32(r)
+	return int(v), err
+}
+
+func (f fixedEncoding) ReadStringLength(r io.ByteReader) (int, error) {
+	v, err := f.ReadInt16(r)
+	return int(uint16(v)), err
+}
+
+type varintEncoding struct {
+	fixedEncoding
+}
+
+func writeUvarint(w io.ByteWriter, v uint64) error {
+	var bs [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(bs[:], v)
+	return writeBytes(w, bs[:n])
+}
+
+func (varintEncoding) WriteInt32(w io.ByteWriter, v int32) error {
+	return writeUvarint(w, uint64(uint32(v<<1)^uint32(v>>31)))
+}
+
+func (varintEncoding) WriteInt64(w io.ByteWriter, v int64) error {
+	return writeUvarint(w, uint64(v<<1)^uint64(v>>63))
+}
+
+func (e varintEncoding) WriteLength(w io.ByteWriter, n int) error {
+	if n > math.MaxInt32 {
+		return errors.New("nbt: length too large")
+	}
+
+	return e.WriteInt32(w, int32(n))
+}
+
+func (varintEncoding) WriteStringLength(w io.ByteWriter, n int) error {
+	if n > math.MaxInt16 {
+		return errors.New("string too long")
+	}
+
+	return writeUvarint(w, uint64(n))
+}
+
+func readUvarint(r io.ByteReader, max int) (uint64, error) {
+	var v uint64
+	for shift := 0; shift < max*7; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && shift > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		v |= uint64(b&0x7f) << uint(shift)
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+
+	return 0, errors.New("nbt: varint too long")
+}
+
+func (varintEncoding) ReadInt32(r io.ByteReader) (int32, error) {
+	u, err := readUvarint(r, 5)
+	v := uint32(u)
+	return int32(v>>1) ^ -int32(v&1), err
+}
+
+func (varintEncoding) ReadInt64(r io.ByteReader) (int64, error) {
+	u, err := readUvarint(r, 10)
+	return int64(u>>1) ^ -int64(u&1), err
+}
+
+func (e varintEncoding) ReadLength(r io.ByteReader) (int, error) {
+	v, err := e.ReadInt32(r)
+	return int(v), err
+}
+
+func (varintEncoding) ReadStringLength(r io.ByteReader) (int, error) {
+	v, err := readUvarint(r, 5)
+	if err == nil && v > math.MaxInt16 {
+		return 0, errors.New("nbt: string too long")
+	}
+
+	return int(v), err
+}
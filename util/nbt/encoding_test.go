@@ -0,0 +1,102 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEncodingLayouts(t *testing.T) {
+	tests := []struct {
+		enc      Encoding
+		expected string
+	}{
+		{BigEndian, "030001610000012c"},
+		{LittleEndian, "030100612c010000"},
+		{NetworkLittleEndian, "030161d804"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetEncoding(test.enc)
+		if err := enc.Encode(int32(300), "a"); err != nil {
+			t.Fatalf("Encode failed: %s", err)
+		}
+
+		if hex.EncodeToString(buf.Bytes()) != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, hex.EncodeToString(buf.Bytes()))
+		}
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	type block struct {
+		Name    string `nbt:"name"`
+		Version int32  `nbt:"version"`
+		States  map[string]int8
+		Pos     []int32 `nbt:",intarray"`
+		Time    int64
+		Scale   float32
+		Height  float64
+		Data    []byte
+		Ticks   []int64 `nbt:",longarray"`
+		Nested  []struct{ Id int16 }
+	}
+
+	src := block{
+		Name:    "minecraft:stone",
+		Version: 17959425,
+		States:  map[string]int8{"stone_type": 1},
+		Pos:     []int32{-1, 64, 1 << 30},
+		Time:    -1 << 40,
+		Scale:   0.5,
+		Height:  -12.25,
+		Data:    []byte{0, 0xff},
+		Ticks:   []int64{-1, 1 << 62},
+		Nested:  []struct{ Id int16 }{{-2}, {300}},
+	}
+
+	for _, e := range []Encoding{BigEndian, LittleEndian, NetworkLittleEndian} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetEncoding(e)
+		if err := enc.Encode(&src, ""); err != nil {
+			t.Fatalf("Encode failed: %s", err)
+		}
+
+		dec := NewDecoder(bytes.NewReader(buf.Bytes()))
+		dec.SetEncoding(e)
+
+		var dst block
+		if err := dec.Decode(&dst); err != nil {
+			t.Fatalf("Decode failed: %s", err)
+		}
+
+		if dst.Name != src.Name || dst.Version != src.Version || dst.States["stone_type"] != 1 ||
+			dst.Pos[2] != src.Pos[2] || dst.Time != src.Time || dst.Scale != src.Scale ||
+			dst.Height != src.Height || dst.Data[1] != 0xff || dst.Ticks[1] != src.Ticks[1] ||
+			dst.Nested[1].Id != 300 {
+			t.Errorf("Round trip mismatch: %+v", dst)
+		}
+
+		dec = NewDecoder(bytes.NewReader(buf.Bytes()))
+		dec.SetEncoding(e)
+
+		var tree Tag
+		if err := dec.Decode(&tree); err != nil {
+			t.Fatalf("Decode of tree failed: %s", err)
+		}
+
+		var again bytes.Buffer
+		enc = NewEncoder(&again)
+		enc.SetEncoding(e)
+		if err := enc.Encode(tree, ""); err != nil {
+			t.Fatalf("Encode of tree failed: %s", err)
+		}
+
+		if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+			t.Errorf("Tree round trip mismatch")
+		}
+	}
+}
@@ -0,0 +1,480 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+type JSONMode int
+
+const (
+	// JSONPlain maps tags to the closest JSON values. It is meant for display
+	// and loses type information: reading it back picks Int, Long or Double
+	// for numbers and a Byte for booleans.
+	JSONPlain JSONMode = iota
+
+	// JSONTyped wraps every value as {"type":"int","value":1} so that it can
+	// be read back into exactly the same tags. Lists carry an elementType.
+	JSONTyped
+)
+
+var jsonTypeNames = map[byte]string{
+	TagByte:      "byte",
+	TagShort:     "short",
+	TagInt:       "int",
+	TagLong:      "long",
+	TagFloat:     "float",
+	TagDouble:    "double",
+	TagByteArray: "byteArray",
+	TagString:    "string",
+	TagList:      "list",
+	TagCompound:  "compound",
+	TagIntArray:  "intArray",
+	TagLongArray: "longArray",
+	TagEnd:       "end",
+}
+
+func jsonTypeOf(name string) (byte, bool) {
+	for t, n := range jsonTypeNames {
+		if n == name {
+			return t, true
+		}
+	}
+
+	return TagEnd, false
+}
+
+func ToJSON(t Tag, mode JSONMode) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, t, mode); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, t Tag, mode JSONMode) error {
+	if t == nil {
+		return errors.New("nbt: cannot convert nil tag to JSON")
+	}
+
+	if mode == JSONTyped {
+		buf.WriteString(`{"type":"` + jsonTypeNames[t.Type()] + `",`)
+		if l, ok := t.(*List); ok {
+			buf.WriteString(`"elementType":"` + jsonTypeNames[l.elemType] + `",`)
+		}
+		buf.WriteString(`"value":`)
+	}
+
+	switch v := t.(type) {
+	case Byte:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case Short:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case Int:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case Long:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case Float:
+		buf.WriteString(jsonFloat(float64(v), 32))
+	case Double:
+		buf.WriteString(jsonFloat(float64(v), 64))
+	case String:
+		s, err := json.Marshal(string(v))
+		if err != nil {
+			return err
+		}
+		buf.Write(s)
+	case ByteArray:
+		buf.WriteByte('[')
+		for i, n := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.FormatInt(int64(n), 10))
+		}
+		buf.WriteByte(']')
+	case IntArray:
+		buf.WriteByte('[')
+		for i, n := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.FormatInt(int64(n), 10))
+		}
+		buf.WriteByte(']')
+	case LongArray:
+		buf.WriteByte('[')
+		for i, n := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.FormatInt(n, 10))
+		}
+		buf.WriteByte(']')
+	case *List:
+		buf.WriteByte('[')
+		for i, e := range v.elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, e, mode); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *Compound:
+		buf.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(k)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, v.tags[k], mode); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return errors.New("nbt: cannot convert " + TagName(t.Type()) + " to JSON")
+	}
+
+	if mode == JSONTyped {
+		buf.WriteByte('}')
+	}
+
+	return nil
+}
+
+// jsonFloat formats f so that it parses back to the same value. JSON has
+// no literals for NaN and infinities, so those become strings.
+func jsonFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return `"NaN"`
+	case math.IsInf(f, 1):
+		return `"Infinity"`
+	case math.IsInf(f, -1):
+		return `"-Infinity"`
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func FromJSON(data []byte, mode JSONMode) (Tag, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := readJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("nbt: trailing data after JSON value")
+	}
+
+	if mode == JSONTyped {
+		return typedFromJSON(v)
+	}
+
+	return plainFromJSON(v)
+}
+
+// jsonObject keeps the keys of a JSON object in document order, which
+// encoding/json maps do not.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func readJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &jsonObject{values: make(map[string]interface{})}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+
+			v, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, dup := obj.values[key]; !dup {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = v
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+
+	return tok, nil
+}
+
+func plainFromJSON(v interface{}) (Tag, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, errors.New("nbt: cannot convert JSON null")
+	case bool:
+		if x {
+			return Byte(1), nil
+		}
+		return Byte(0), nil
+	case json.Number:
+		if n, err := strconv.ParseInt(string(x), 10, 64); err == nil {
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return Int(n), nil
+			}
+			return Long(n), nil
+		}
+		f, err := strconv.ParseFloat(string(x), 64)
+		if err != nil {
+			return nil, errors.New("nbt: invalid JSON number " + string(x))
+		}
+		return Double(f), nil
+	case string:
+		return String(x), nil
+	case []interface{}:
+		elems := make([]Tag, len(x))
+		widest := TagEnd
+		for i, e := range x {
+			t, err := plainFromJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = t
+			if isPlainNumber(t) && t.Type() > widest {
+				widest = t.Type()
+			}
+		}
+
+		// [1.5, 64] must not fail just because 64 looks like an integer
+		l := &List{}
+		for _, t := range elems {
+			if isPlainNumber(t) {
+				t = widenNumber(t, widest)
+			}
+			if err := l.Append(t); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case *jsonObject:
+		c := NewCompound()
+		for _, k := range x.keys {
+			t, err := plainFromJSON(x.values[k])
+			if err != nil {
+				return nil, err
+			}
+			c.Set(k, t)
+		}
+		return c, nil
+	}
+
+	return nil, errors.New("nbt: unexpected JSON value")
+}
+
+func isPlainNumber(t Tag) bool {
+	switch t.(type) {
+	case Int, Long, Double:
+		return true
+	}
+
+	return false
+}
+
+// widenNumber converts an Int, Long or Double to the wider type to,
+// relying on TagInt < TagLong < TagDouble.
+func widenNumber(t Tag, to byte) Tag {
+	switch v := t.(type) {
+	case Int:
+		if to == TagLong {
+			return Long(v)
+		} else if to == TagDouble {
+			return Double(v)
+		}
+	case Long:
+		if to == TagDouble {
+			return Double(v)
+		}
+	}
+
+	return t
+}
+
+func typedFromJSON(v interface{}) (Tag, error) {
+	obj, ok := v.(*jsonObject)
+	if !ok {
+		return nil, errors.New("nbt: typed JSON value must be an object")
+	}
+
+	typeName, _ := obj.values["type"].(string)
+	tagType, ok := jsonTypeOf(typeName)
+	if !ok || tagType == TagEnd {
+		return nil, errors.New("nbt: invalid type " + strconv.Quote(typeName) + " in typed JSON")
+	}
+
+	value, ok := obj.values["value"]
+	if !ok {
+		return nil, errors.New("nbt: missing value for " + typeName + " in typed JSON")
+	}
+
+	switch tagType {
+	case TagByte, TagShort, TagInt, TagLong:
+		bits := map[byte]int{TagByte: 8, TagShort: 16, TagInt: 32, TagLong: 64}[tagType]
+		n, err := jsonInt(value, bits)
+		if err != nil {
+			return nil, err
+		}
+		switch tagType {
+		case TagByte:
+			return Byte(n), nil
+		case TagShort:
+			return Short(n), nil
+		case TagInt:
+			return Int(n), nil
+		}
+		return Long(n), nil
+	case TagFloat:
+		f, err := jsonFloatValue(value, 32)
+		return Float(f), err
+	case TagDouble:
+		f, err := jsonFloatValue(value, 64)
+		return Double(f), err
+	case TagString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("nbt: string value must be a JSON string")
+		}
+		return String(s), nil
+	case TagByteArray, TagIntArray, TagLongArray:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("nbt: " + typeName + " value must be a JSON array")
+		}
+		bits := map[byte]int{TagByteArray: 8, TagIntArray: 32, TagLongArray: 64}[tagType]
+		ns := make([]int64, len(arr))
+		for i, e := range arr {
+			n, err := jsonInt(e, bits)
+			if err != nil {
+				return nil, err
+			}
+			ns[i] = n
+		}
+		switch tagType {
+		case TagByteArray:
+			a := make(ByteArray, len(ns))
+			for i, n := range ns {
+				a[i] = int8(n)
+			}
+			return a, nil
+		case TagIntArray:
+			a := make(IntArray, len(ns))
+			for i, n := range ns {
+				a[i] = int32(n)
+			}
+			return a, nil
+		}
+		return LongArray(ns), nil
+	case TagList:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("nbt: list value must be a JSON array")
+		}
+		elemName, _ := obj.values["elementType"].(string)
+		elemType, ok := jsonTypeOf(elemName)
+		if !ok {
+			return nil, errors.New("nbt: invalid elementType " + strconv.Quote(elemName) + " in typed JSON")
+		}
+		l := &List{elemType: elemType}
+		for _, e := range arr {
+			t, err := typedFromJSON(e)
+			if err != nil {
+				return nil, err
+			}
+			if t.Type() != elemType {
+				return nil, errors.New("nbt: " + TagName(t.Type()) + " in list of " + TagName(elemType))
+			}
+			l.elems = append(l.elems, t)
+		}
+		return l, nil
+	case TagCompound:
+		values, ok := value.(*jsonObject)
+		if !ok {
+			return nil, errors.New("nbt: compound value must be a JSON object")
+		}
+		c := NewCompound()
+		for _, k := range values.keys {
+			t, err := typedFromJSON(values.values[k])
+			if err != nil {
+				return nil, err
+			}
+			c.Set(k, t)
+		}
+		return c, nil
+	}
+
+	return nil, errors.New("nbt: unexpected type " + typeName)
+}
+
+func jsonInt(v interface{}, bits int) (int64, error) {
+	num, ok := v.(json.Number)
+	if !ok {
+		return 0, errors.New("nbt: integer value must be a JSON number")
+	}
+
+	n, err := strconv.ParseInt(string(num), 10, bits)
+	if err != nil {
+		return 0, errors.New("nbt: invalid " + strconv.Itoa(bits) + "-bit integer " + string(num))
+	}
+
+	return n, nil
+}
+
+func jsonFloatValue(v interface{}, bits int) (float64, error) {
+	switch x := v.(type) {
+	case json.Number:
+		return strconv.ParseFloat(string(x), bits)
+	case string:
+		switch x {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+	}
+
+	return 0, errors.New("nbt: invalid floating point value")
+}
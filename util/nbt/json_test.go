@@ -0,0 +1,86 @@
+package nbt
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+const testPlayerSNBT = `{Name:"Steve",Health:20.0f,XpTotal:1234,Seed:-8070450532247928832L,Flags:3b,Level:7s,` +
+	`Pos:[1.5d,64.0d,-3.25d],Inventory:[{Slot:0b,id:"minecraft:stone",Count:64b}],Empty:[],` +
+	`UUID:[I;1,2,3,4],Colors:[B;-1,2],Times:[L;1L,-2L],Nested:{a:{}}}`
+
+func TestToJSONPlain(t *testing.T) {
+	tag, err := ParseSNBT(`{Name:"Steve",Health:20.0f,Pos:[1.5d,64.0d],UUID:[I;1,2],Inventory:[{id:"minecraft:stone"}]}`)
+	if err != nil {
+		t.Fatalf("ParseSNBT failed: %s", err)
+	}
+
+	data, err := ToJSON(tag, JSONPlain)
+	if err != nil {
+		t.Fatalf("ToJSON failed: %s", err)
+	}
+
+	expected := `{"Name":"Steve","Health":20,"Pos":[1.5,64],"UUID":[1,2],"Inventory":[{"id":"minecraft:stone"}]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected JSON:\n%s\n%s", expected, data)
+	}
+
+	back, err := FromJSON(data, JSONPlain)
+	if err != nil {
+		t.Fatalf("FromJSON failed: %s", err)
+	}
+
+	if v, _ := back.(*Compound).Path("Pos[0]"); v != Double(1.5) {
+		t.Errorf("Unexpected value: %#v", v)
+	}
+}
+
+func TestJSONTypedRoundTrip(t *testing.T) {
+	tag, err := ParseSNBT(testPlayerSNBT)
+	if err != nil {
+		t.Fatalf("ParseSNBT failed: %s", err)
+	}
+	tag.(*Compound).Set("NaN", Float(float32(math.NaN())))
+
+	empty, _ := NewList(TagCompound)
+	tag.(*Compound).Set("EmptyCompounds", empty)
+
+	var original bytes.Buffer
+	if err := WriteTag(&original, "", tag); err != nil {
+		t.Fatalf("WriteTag failed: %s", err)
+	}
+
+	data, err := ToJSON(tag, JSONTyped)
+	if err != nil {
+		t.Fatalf("ToJSON failed: %s", err)
+	}
+
+	back, err := FromJSON(data, JSONTyped)
+	if err != nil {
+		t.Fatalf("FromJSON failed: %s\n%s", err, data)
+	}
+
+	var again bytes.Buffer
+	if err := WriteTag(&again, "", back); err != nil {
+		t.Fatalf("WriteTag failed: %s", err)
+	}
+
+	if !bytes.Equal(original.Bytes(), again.Bytes()) {
+		t.Errorf("Typed JSON round trip is not byte identical:\n%s", data)
+	}
+}
+
+func TestFromJSONTypedErrors(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"byte","value":300}`,
+		`{"type":"bogus","value":1}`,
+		`{"type":"list","elementType":"int","value":[{"type":"long","value":1}]}`,
+		`{"type":"string"}`,
+		`[1]`,
+	} {
+		if _, err := FromJSON([]byte(s), JSONTyped); err == nil {
+			t.Errorf("FromJSON(%s) should fail", s)
+		}
+	}
+}
@@ -3,15 +3,58 @@ package nbt
 import (
 	"bytes"
 	"errors"
-	"math"
+	"io"
 	"reflect"
+	"sort"
 )
 
+// Marshal encodes v as a tag with the given name. Struct fields are written
+// in declaration order and map entries in sorted key order, so equal values
+// always produce identical bytes.
 func Marshal(v interface{}, name string) ([]byte, error) {
 	e := &encoder{}
 	return e.marshal(v, name)
 }
 
+type Encoder struct {
+	w        io.Writer
+	enc      Encoding
+	sortKeys bool
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SortKeys makes the encoder write the entries of a Compound in sorted key
+// order rather than in insertion order, which gives a canonical encoding of
+// tag trees regardless of how they were built.
+func (enc *Encoder) SortKeys(sortKeys bool) {
+	enc.sortKeys = sortKeys
+}
+
+// SetEncoding selects the binary layout of numbers. The default is
+// BigEndian.
+func (enc *Encoder) SetEncoding(e Encoding) {
+	enc.enc = e
+}
+
+func (enc *Encoder) Encode(v interface{}, name string) error {
+	e := &encoder{enc: enc.enc, sortKeys: enc.sortKeys}
+	data, err := e.marshal(v, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = enc.w.Write(data)
+	return err
+}
+
+// Marshaler is implemented by types which encode themselves as a tag.
+type Marshaler interface {
+	MarshalNBT() (Tag, error)
+}
+
 type MarshalTypeError struct {
 	Kind reflect.Kind
 }
@@ -22,6 +65,8 @@ func (e *MarshalTypeError) Error() string {
 
 type encoder struct {
 	bytes.Buffer
+	enc      Encoding
+	sortKeys bool
 }
 
 func (e *encoder) marshal(v interface{}, name string) ([]byte, error) {
@@ -41,138 +86,187 @@ func (e *encoder) marshal(v interface{}, name string) ([]byte, error) {
 	return e.Bytes(), nil
 }
 
-func (e *encoder) writeValue(rv reflect.Value, name string) (err error) {
-	switch k := rv.Kind(); k {
-	case reflect.Int8:
-		e.WriteByte(TagByte)
-		e.writeString(name)
-		err = e.WriteByte(byte(rv.Int()))
-	case reflect.Int16:
-		e.WriteByte(TagShort)
-		e.writeString(name)
-		err = e.writeInt16(int16(rv.Int()))
-	case reflect.Int32, reflect.Int:
-		e.WriteByte(TagInt)
-		e.writeString(name)
-		err = e.writeInt32(int32(rv.Int()))
-	case reflect.Int64:
-		e.WriteByte(TagLong)
+func (e *encoder) writeValue(rv reflect.Value, name string) error {
+	rv, err := indirect(rv)
+	if err != nil {
+		return err
+	}
+
+	if t, ok, err := toTag(rv); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		e.WriteByte(t.Type())
 		e.writeString(name)
-		err = e.writeInt64(rv.Int())
+		return e.writeTag(t)
+	}
+
+	tag, err := typeTag(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	e.WriteByte(tag)
+	e.writeString(name)
+	return e.writePayload(rv, tag)
+}
+
+func (e *encoder) writePayload(rv reflect.Value, tag byte) error {
+	switch tag {
+	case TagByte:
+		return e.WriteByte(byte(intValue(rv)))
+	case TagShort:
+		return e.writeInt16(int16(intValue(rv)))
+	case TagInt:
+		return e.writeInt32(int32(intValue(rv)))
+	case TagLong:
+		return e.writeInt64(intValue(rv))
+	case TagFloat:
+		return e.writeFloat(float32(rv.Float()))
+	case TagDouble:
+		return e.writeDouble(rv.Float())
+	case TagString:
+		return e.writeString(rv.String())
+	case TagByteArray, TagIntArray, TagLongArray:
+		return e.writeArray(rv, tag)
+	case TagList:
+		return e.writeSlice(rv)
+	case TagCompound:
+		return e.writeCompound(rv)
+	}
+
+	return errors.New("nbt: cannot marshal " + rv.Type().String() + " as " + TagName(tag))
+}
+
+func indirect(rv reflect.Value) (reflect.Value, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, errors.New("nbt: cannot marshal nil")
+		}
+
+		if rv.Kind() == reflect.Ptr && isDynamic(rv.Type()) {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	return rv, nil
+}
+
+// typeTag returns the tag type a value of type t is encoded as.
+func typeTag(t reflect.Type) (byte, error) {
+	switch k := t.Kind(); k {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return TagByte, nil
+	case reflect.Int16, reflect.Uint16:
+		return TagShort, nil
+	case reflect.Int32, reflect.Int, reflect.Uint32, reflect.Uint:
+		return TagInt, nil
+	case reflect.Int64, reflect.Uint64:
+		return TagLong, nil
 	case reflect.Float32:
-		e.WriteByte(TagFloat)
-		e.writeString(name)
-		err = e.writeFloat(float32(rv.Float()))
+		return TagFloat, nil
 	case reflect.Float64:
-		e.WriteByte(TagDouble)
-		e.writeString(name)
-		err = e.writeDouble(rv.Float())
+		return TagDouble, nil
+	case reflect.String:
+		return TagString, nil
 	case reflect.Array:
-		switch ek := rv.Type().Elem().Kind(); ek {
-		case reflect.Int8:
-			e.WriteByte(TagByteArray)
-		case reflect.Int32, reflect.Int:
-			e.WriteByte(TagIntArray)
-		case reflect.Int64:
-			e.WriteByte(TagLongArray)
+		switch ek := t.Elem().Kind(); ek {
+		case reflect.Int8, reflect.Uint8:
+			return TagByteArray, nil
+		case reflect.Int32, reflect.Int, reflect.Uint32, reflect.Uint:
+			return TagIntArray, nil
+		case reflect.Int64, reflect.Uint64:
+			return TagLongArray, nil
 		default:
-			err = errors.New("nbt: cannot marshal array of " + ek.String())
+			return TagEnd, errors.New("nbt: cannot marshal array of " + ek.String())
 		}
-		e.writeString(name)
-		err = e.writeArray(rv)
-	case reflect.String:
-		e.WriteByte(TagString)
-		e.writeString(name)
-		err = e.writeString(rv.String())
 	case reflect.Slice:
-		e.WriteByte(TagList)
-		e.writeString(name)
-		err = e.writeSlice(rv)
-	case reflect.Struct:
-		e.WriteByte(TagCompound)
-		e.writeString(name)
-		err = e.writeCompound(rv)
+		if t.Elem().Kind() == reflect.Uint8 {
+			return TagByteArray, nil
+		}
+		return TagList, nil
+	case reflect.Struct, reflect.Map:
+		return TagCompound, nil
+	case reflect.Ptr:
+		return typeTag(t.Elem())
 	default:
-		err = &MarshalTypeError{k}
+		return TagEnd, &MarshalTypeError{k}
+	}
+}
+
+func intValue(rv reflect.Value) int64 {
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return int64(rv.Uint())
 	}
 
-	return
+	return rv.Int()
+}
+
+func (e *encoder) encoding() Encoding {
+	if e.enc == nil {
+		return BigEndian
+	}
+
+	return e.enc
 }
 
 func (e *encoder) writeInt16(n int16) error {
-	e.WriteByte(byte(n >> 8))
-	e.WriteByte(byte(n))
-	return nil
+	return e.encoding().WriteInt16(e, n)
 }
 
 func (e *encoder) writeInt32(n int32) error {
-	e.WriteByte(byte(n >> 24))
-	e.WriteByte(byte(n >> 16))
-	e.WriteByte(byte(n >> 8))
-	e.WriteByte(byte(n))
-	return nil
+	return e.encoding().WriteInt32(e, n)
 }
 
 func (e *encoder) writeInt64(n int64) error {
-	e.WriteByte(byte(n >> 56))
-	e.WriteByte(byte(n >> 48))
-	e.WriteByte(byte(n >> 40))
-	e.WriteByte(byte(n >> 32))
-	e.WriteByte(byte(n >> 24))
-	e.WriteByte(byte(n >> 16))
-	e.WriteByte(byte(n >> 8))
-	e.WriteByte(byte(n))
-	return nil
+	return e.encoding().WriteInt64(e, n)
 }
 
 func (e *encoder) writeFloat(n float32) error {
-	return e.writeInt32(int32(math.Float32bits(n)))
+	return e.encoding().WriteFloat32(e, n)
 }
 
 func (e *encoder) writeDouble(n float64) error {
-	return e.writeInt64(int64(math.Float64bits(n)))
+	return e.encoding().WriteFloat64(e, n)
+}
+
+func (e *encoder) writeLength(n int) error {
+	return e.encoding().WriteLength(e, n)
 }
 
 func (e *encoder) writeString(name string) error {
-	n := len(name)
-	if n > 0xffff {
-		return errors.New("string too long")
+	if err := e.encoding().WriteStringLength(e, len(name)); err != nil {
+		return err
 	}
 
-	e.writeInt16(int16(n))
 	_, err := e.WriteString(name)
-
 	return err
 }
 
-func (e *encoder) writeArray(rv reflect.Value) error {
-	e.writeInt32(int32(rv.Len()))
+func (e *encoder) writeArray(rv reflect.Value, tag byte) error {
+	e.writeLength(rv.Len())
 
-	var write func(v reflect.Value) error
-	switch ek := rv.Type().Elem().Kind(); ek {
-	case reflect.Int8:
-		write = func(v reflect.Value) error {
-			e.WriteByte(byte(v.Int()))
-			return nil
-		}
-	case reflect.Int32, reflect.Int:
-		write = func(v reflect.Value) error {
-			e.writeInt32(int32(v.Int()))
-			return nil
-		}
-	case reflect.Int64:
-		write = func(v reflect.Value) error {
-			e.writeInt64(v.Int())
-			return nil
-		}
-	default:
-		return errors.New("nbt: cannot marshal array of " + ek.String())
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		_, err := e.Write(rv.Bytes())
+		return err
 	}
 
 	for i := 0; i < rv.Len(); i++ {
-		err := write(rv.Index(i))
-		if err != nil {
-			return err
+		n := intValue(rv.Index(i))
+		switch tag {
+		case TagByteArray:
+			e.WriteByte(byte(n))
+		case TagIntArray:
+			e.writeInt32(int32(n))
+		default:
+			e.writeInt64(n)
 		}
 	}
 
@@ -180,77 +274,67 @@ func (e *encoder) writeArray(rv reflect.Value) error {
 }
 
 func (e *encoder) writeSlice(rv reflect.Value) error {
-	var write func(v reflect.Value) error
-	switch ek := rv.Type().Elem().Kind(); ek {
-	case reflect.Int8:
-		e.WriteByte(TagByte)
-		write = func(v reflect.Value) error {
-			return e.WriteByte(byte(v.Int()))
-		}
-	case reflect.Int16:
-		e.WriteByte(TagShort)
-		write = func(v reflect.Value) error {
-			return e.writeInt16(int16(v.Int()))
+	et := rv.Type().Elem()
+	if isDynamic(et) {
+		l := &List{}
+		for i := 0; i < rv.Len(); i++ {
+			t, ok, err := toTag(rv.Index(i))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("nbt: cannot marshal nil element in " + rv.Type().String())
+			}
+			if err := l.Append(t); err != nil {
+				return err
+			}
 		}
-	case reflect.Int32:
-		e.WriteByte(TagInt)
-		write = func(v reflect.Value) error {
-			return e.writeInt32(int32(v.Int()))
-		}
-	case reflect.Int64:
-		e.WriteByte(TagLong)
-		write = func(v reflect.Value) error {
-			return e.writeInt64(v.Int())
-		}
-	case reflect.Float32:
-		e.WriteByte(TagFloat)
-		write = func(v reflect.Value) error {
-			return e.writeFloat(float32(v.Float()))
-		}
-	case reflect.Float64:
-		e.WriteByte(TagDouble)
-		write = func(v reflect.Value) error {
-			return e.writeDouble(v.Float())
-		}
-	case reflect.String:
-		e.WriteByte(TagString)
-		write = func(v reflect.Value) error {
-			return e.writeString(v.String())
-		}
-	case reflect.Slice:
-		e.WriteByte(TagList)
-		write = func(v reflect.Value) error {
-			return e.writeSlice(v)
-		}
-	case reflect.Struct, reflect.Map:
-		e.WriteByte(TagCompound)
-		write = func(v reflect.Value) error {
-			return e.writeCompound(v)
-		}
-	default:
-		return errors.New("nbt: cannot marshal slice with " + ek.String())
+		return e.writeTag(l)
+	}
+
+	elemTag, err := typeTag(et)
+	if err != nil {
+		return err
 	}
 
-	e.writeInt32(int32(rv.Len()))
+	e.WriteByte(elemTag)
+	e.writeLength(rv.Len())
 
 	for i := 0; i < rv.Len(); i++ {
-		err := write(rv.Index(i))
+		v, err := indirect(rv.Index(i))
 		if err != nil {
 			return err
 		}
+
+		if err := e.writePayload(v, elemTag); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (e *encoder) writeCompound(rv reflect.Value) error {
-	nameIndexMap, err := getTargetFieldNames(rv.Type())
+	if rv.Kind() == reflect.Map {
+		return e.writeMap(rv)
+	}
+
+	fields, err := getTargetFields(rv.Type())
 	if err != nil {
 		return err
 	}
 
-	for k, v := range nameIndexMap {
-		err = e.writeValue(rv.Field(v), k)
+	for _, f := range fields.list {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		if f.tag == TagEnd || isDynamic(f.typ) {
+			err = e.writeValue(fv, f.name)
+		} else {
+			err = e.writeField(fv, f)
+		}
 		if err != nil {
 			return err
 		}
@@ -258,3 +342,161 @@ func (e *encoder) writeCompound(rv reflect.Value) error {
 
 	return e.WriteByte(TagEnd)
 }
+
+func (e *encoder) writeMap(rv reflect.Value) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return errors.New("nbt: cannot marshal map with " + rv.Type().Key().String() + " keys")
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, k := range keys {
+		if err := e.writeValue(rv.MapIndex(k), k.String()); err != nil {
+			return err
+		}
+	}
+
+	return e.WriteByte(TagEnd)
+}
+
+func (e *encoder) writeField(rv reflect.Value, f field) error {
+	rv, err := indirect(rv)
+	if err != nil {
+		return err
+	}
+
+	e.WriteByte(f.tag)
+	e.writeString(f.name)
+	return e.writePayload(rv, f.tag)
+}
+
+var (
+	tagType       = reflect.TypeOf((*Tag)(nil)).Elem()
+	marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+)
+
+// isDynamic reports whether the encoding of t can only be decided from a
+// value, either because it is a Tag or it implements Marshaler.
+func isDynamic(t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return true
+	}
+
+	pt := reflect.PtrTo(t)
+	return t.Implements(tagType) || pt.Implements(tagType) ||
+		t.Implements(marshalerType) || pt.Implements(marshalerType)
+}
+
+// toTag converts rv to a Tag if it implements Marshaler or is a Tag itself.
+func toTag(rv reflect.Value) (Tag, bool, error) {
+	if rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if !rv.CanInterface() {
+		return nil, false, nil
+	}
+
+	var m Marshaler
+	if v, ok := rv.Interface().(Marshaler); ok {
+		m = v
+	} else if rv.CanAddr() {
+		m, _ = rv.Addr().Interface().(Marshaler)
+	}
+
+	if m != nil {
+		t, err := m.MarshalNBT()
+		if err != nil {
+			return nil, false, err
+		}
+		if t == nil {
+			return nil, false, errors.New("nbt: MarshalNBT of " + rv.Type().String() + " returned nil")
+		}
+		return t, true, nil
+	}
+
+	if t, ok := rv.Interface().(Tag); ok {
+		return t, true, nil
+	}
+
+	// Compound and List only implement Tag through their pointers
+	if rv.Kind() == reflect.Struct && rv.CanAddr() {
+		if t, ok := rv.Addr().Interface().(Tag); ok {
+			return t, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (e *encoder) writeTag(t Tag) error {
+	switch v := t.(type) {
+	case Byte:
+		return e.WriteByte(byte(v))
+	case Short:
+		return e.writeInt16(int16(v))
+	case Int:
+		return e.writeInt32(int32(v))
+	case Long:
+		return e.writeInt64(int64(v))
+	case Float:
+		return e.writeFloat(float32(v))
+	case Double:
+		return e.writeDouble(float64(v))
+	case String:
+		return e.writeString(string(v))
+	case ByteArray:
+		e.writeLength(len(v))
+		for _, b := range v {
+			e.WriteByte(byte(b))
+		}
+	case IntArray:
+		e.writeLength(len(v))
+		for _, n := range v {
+			e.writeInt32(n)
+		}
+	case LongArray:
+		e.writeLength(len(v))
+		for _, n := range v {
+			e.writeInt64(n)
+		}
+	case *List:
+		// empty lists keep their element type so that files read and
+		// written back stay byte for byte identical
+		e.WriteByte(v.elemType)
+		e.writeLength(len(v.elems))
+		for _, elem := range v.elems {
+			if err := e.writeTag(elem); err != nil {
+				return err
+			}
+		}
+	case *Compound:
+		keys := v.keys
+		if e.sortKeys {
+			keys = v.Keys()
+			sort.Strings(keys)
+		}
+
+		for _, k := range keys {
+			elem := v.tags[k]
+			e.WriteByte(elem.Type())
+			if err := e.writeString(k); err != nil {
+				return err
+			}
+			if err := e.writeTag(elem); err != nil {
+				return err
+			}
+		}
+		return e.WriteByte(TagEnd)
+	default:
+		return errors.New("nbt: cannot marshal tag of type " + TagName(t.Type()))
+	}
+
+	return nil
+}
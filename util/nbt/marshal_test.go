@@ -1,6 +1,7 @@
 package nbt
 
 import (
+	"bytes"
 	"encoding/hex"
 	"testing"
 )
@@ -98,3 +99,73 @@ func TestMarshalLongArray(t *testing.T) {
 
 	t.Logf("Marshalled data: %s", hex.EncodeToString(raw))
 }
+
+func TestMarshalCompoundOrder(t *testing.T) {
+	type test_struct struct {
+		A string `nbt:"a"`
+		B int    `nbt:"b"`
+	}
+
+	expected := "0a00000800016100047465737403000162000004d200"
+	for i := 0; i < 10; i++ {
+		raw, err := Marshal(&test_struct{"test", 1234}, "")
+		if err != nil {
+			t.Fatalf("Marshal failed: %s", err)
+		}
+
+		if hex.EncodeToString(raw) != expected {
+			t.Fatalf("Unexpected data: %s", hex.EncodeToString(raw))
+		}
+	}
+}
+
+func TestMarshalMap(t *testing.T) {
+	data := map[string]int32{"c": 3, "a": 1, "b": 2}
+	expected := "0a000003000161000000010300016200000002030001630000000300"
+
+	for i := 0; i < 10; i++ {
+		raw, err := Marshal(data, "")
+		if err != nil {
+			t.Fatalf("Marshal failed: %s", err)
+		}
+
+		if hex.EncodeToString(raw) != expected {
+			t.Fatalf("Unexpected data: %s", hex.EncodeToString(raw))
+		}
+	}
+
+	raw, _ := Marshal(data, "")
+	var back map[string]int32
+	if err := Unmarshal(raw, &back); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	if len(back) != 3 || back["b"] != 2 {
+		t.Errorf("Round trip mismatch: %+v", back)
+	}
+}
+
+func TestEncoderSortKeys(t *testing.T) {
+	c := NewCompound()
+	c.Set("b", Byte(2))
+	c.Set("a", Byte(1))
+
+	var insertion, sorted bytes.Buffer
+	if err := NewEncoder(&insertion).Encode(c, ""); err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+
+	enc := NewEncoder(&sorted)
+	enc.SortKeys(true)
+	if err := enc.Encode(c, ""); err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+
+	if hex.EncodeToString(insertion.Bytes()) != "0a00000100016202010001610100" {
+		t.Errorf("Unexpected insertion order data: %s", hex.EncodeToString(insertion.Bytes()))
+	}
+
+	if hex.EncodeToString(sorted.Bytes()) != "0a00000100016101010001620200" {
+		t.Errorf("Unexpected sorted data: %s", hex.EncodeToString(sorted.Bytes()))
+	}
+}
@@ -0,0 +1,113 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+type testUUID [16]byte
+
+func (u testUUID) MarshalNBT() (Tag, error) {
+	a := make(IntArray, 4)
+	for i := range a {
+		a[i] = int32(u[i*4])<<24 | int32(u[i*4+1])<<16 | int32(u[i*4+2])<<8 | int32(u[i*4+3])
+	}
+	return a, nil
+}
+
+func (u *testUUID) UnmarshalNBT(t Tag) error {
+	a, ok := t.(IntArray)
+	if !ok || len(a) != 4 {
+		return errors.New("invalid uuid")
+	}
+	for i, n := range a {
+		u[i*4], u[i*4+1], u[i*4+2], u[i*4+3] = byte(n>>24), byte(n>>16), byte(n>>8), byte(n)
+	}
+	return nil
+}
+
+func TestMarshalerRoundTrip(t *testing.T) {
+	type entity struct {
+		UUID     testUUID
+		Owner    *testUUID
+		Friends  []testUUID
+		OnGround bool
+		Air      uint16
+		Flags    uint8
+		Seed     uint64
+		Data     []byte
+	}
+
+	id := testUUID{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 1, 2, 3, 4, 5, 6, 7, 8}
+	owner := testUUID{0xff}
+	src := &entity{
+		UUID:     id,
+		Owner:    &owner,
+		Friends:  []testUUID{owner, id},
+		OnGround: true,
+		Air:      0xfffe,
+		Flags:    0x80,
+		Seed:     1 << 63,
+		Data:     []byte{1, 2, 0xff},
+	}
+
+	raw, err := Marshal(src, "")
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	_, tree, err := ReadTag(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadTag failed: %s", err)
+	}
+
+	c := tree.(*Compound)
+	for name, expected := range map[string]byte{
+		"UUID":     TagIntArray,
+		"Owner":    TagIntArray,
+		"Friends":  TagList,
+		"OnGround": TagByte,
+		"Air":      TagShort,
+		"Flags":    TagByte,
+		"Seed":     TagLong,
+		"Data":     TagByteArray,
+	} {
+		if tag := c.Get(name); tag == nil || tag.Type() != expected {
+			t.Errorf("%s: expected %s, got %v", name, TagName(expected), tag)
+		}
+	}
+
+	var dst entity
+	if err := Unmarshal(raw, &dst); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	if dst.UUID != src.UUID || *dst.Owner != owner || len(dst.Friends) != 2 || dst.Friends[1] != id ||
+		!dst.OnGround || dst.Air != src.Air || dst.Flags != src.Flags || dst.Seed != src.Seed ||
+		hex.EncodeToString(dst.Data) != "0102ff" {
+		t.Errorf("Round trip mismatch: %+v", dst)
+	}
+}
+
+func TestMarshalerTopLevel(t *testing.T) {
+	id := testUUID{1}
+	raw, err := Marshal(id, "UUID")
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	if hex.EncodeToString(raw[:7]) != "0b000455554944" {
+		t.Errorf("Unexpected header: %s", hex.EncodeToString(raw))
+	}
+
+	var back testUUID
+	if err := Unmarshal(raw, &back); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	if back != id {
+		t.Errorf("Round trip mismatch: %x", back)
+	}
+}
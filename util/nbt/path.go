@@ -0,0 +1,302 @@
+package nbt
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// pathNode is one step of a path such as Data.Player.Inventory[0].id,
+// either a compound key or an index into a list or array.
+type pathNode struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func (n pathNode) String() string {
+	if n.isIndex {
+		return "[" + strconv.Itoa(n.index) + "]"
+	}
+
+	return QuotePathKey(n.key)
+}
+
+// QuotePathKey returns a compound key as it is written in a path, quoted
+// if it is empty or has characters that would end it.
+func QuotePathKey(key string) string {
+	if key != "" && !strings.ContainsAny(key, ".[]\"\\ ") {
+		return key
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(key); i++ {
+		if key[i] == '"' || key[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+func parsePath(path string) ([]pathNode, error) {
+	var nodes []pathNode
+	i := 0
+
+	for i < len(path) {
+		switch c := path[i]; {
+		case c == '.':
+			if i == 0 || i == len(path)-1 || path[i+1] == '.' || path[i+1] == '[' {
+				return nil, errors.New("nbt: invalid path " + strconv.Quote(path))
+			}
+			i++
+		case c == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, errors.New("nbt: unclosed index in path " + strconv.Quote(path))
+			}
+			n, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, errors.New("nbt: invalid index in path " + strconv.Quote(path))
+			}
+			nodes = append(nodes, pathNode{index: n, isIndex: true})
+			i += end + 1
+			if err := checkSeparator(path, i); err != nil {
+				return nil, err
+			}
+		case c == '"':
+			var key strings.Builder
+			j := i + 1
+			for ; j < len(path) && path[j] != '"'; j++ {
+				if path[j] == '\\' && j+1 < len(path) {
+					j++
+				}
+				key.WriteByte(path[j])
+			}
+			if j == len(path) {
+				return nil, errors.New("nbt: unclosed quote in path " + strconv.Quote(path))
+			}
+			nodes = append(nodes, pathNode{key: key.String()})
+			i = j + 1
+			if err := checkSeparator(path, i); err != nil {
+				return nil, err
+			}
+		default:
+			j := i
+			for j < len(path) && path[j] != '.' && path[j] != '[' {
+				j++
+			}
+			nodes = append(nodes, pathNode{key: path[i:j]})
+			i = j
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil, errors.New("nbt: empty path")
+	}
+
+	return nodes, nil
+}
+
+// checkSeparator makes sure a closing bracket or quote at i is followed
+// by another node or the end of the path.
+func checkSeparator(path string, i int) error {
+	if i < len(path) && path[i] != '.' && path[i] != '[' {
+		return errors.New("nbt: expected . or [ at " + strconv.Itoa(i) + " in path " + strconv.Quote(path))
+	}
+
+	return nil
+}
+
+func (c *Compound) Path(path string) (Tag, error) {
+	nodes, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Tag = c
+	for i, n := range nodes {
+		if t, err = child(t, n); err != nil {
+			return nil, &PathError{path, nodes[:i+1], err}
+		}
+	}
+
+	return t, nil
+}
+
+// SetPath stores t at path, creating missing intermediate compounds.
+func (c *Compound) SetPath(path string, t Tag) error {
+	if t == nil {
+		return errors.New("nbt: cannot set nil at path " + strconv.Quote(path))
+	}
+
+	nodes, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	var parent Tag = c
+	for i, n := range nodes[:len(nodes)-1] {
+		next, err := child(parent, n)
+		if err != nil && !n.isIndex && !nodes[i+1].isIndex {
+			if pc, ok := parent.(*Compound); ok && pc.Get(n.key) == nil {
+				next = NewCompound()
+				pc.Set(n.key, next)
+				err = nil
+			}
+		}
+		if err != nil {
+			return &PathError{path, nodes[:i+1], err}
+		}
+		parent = next
+	}
+
+	last := nodes[len(nodes)-1]
+	if err := setChild(parent, last, t); err != nil {
+		return &PathError{path, nodes, err}
+	}
+
+	return nil
+}
+
+func (c *Compound) DeletePath(path string) error {
+	nodes, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	var parent Tag = c
+	for i, n := range nodes[:len(nodes)-1] {
+		if parent, err = child(parent, n); err != nil {
+			return &PathError{path, nodes[:i+1], err}
+		}
+	}
+
+	last := nodes[len(nodes)-1]
+	switch p := parent.(type) {
+	case *Compound:
+		if last.isIndex || !p.Delete(last.key) {
+			return &PathError{path, nodes, errors.New("no such tag")}
+		}
+	case *List:
+		if !last.isIndex {
+			return &PathError{path, nodes, errors.New("list has no key " + strconv.Quote(last.key))}
+		}
+		if err := p.Remove(last.index); err != nil {
+			return &PathError{path, nodes, err}
+		}
+	default:
+		return &PathError{path, nodes, errors.New("cannot delete from " + TagName(parent.Type()))}
+	}
+
+	return nil
+}
+
+type PathError struct {
+	Path  string
+	nodes []pathNode
+	Err   error
+}
+
+func (e *PathError) Error() string {
+	var at strings.Builder
+	for i, n := range e.nodes {
+		if i > 0 && !n.isIndex {
+			at.WriteByte('.')
+		}
+		at.WriteString(n.String())
+	}
+
+	return "nbt: path " + strconv.Quote(e.Path) + " at " + at.String() + ": " + e.Err.Error()
+}
+
+func child(t Tag, n pathNode) (Tag, error) {
+	if !n.isIndex {
+		c, ok := t.(*Compound)
+		if !ok {
+			return nil, errors.New(TagName(t.Type()) + " has no key " + strconv.Quote(n.key))
+		}
+		v := c.Get(n.key)
+		if v == nil {
+			return nil, errors.New("no such tag")
+		}
+		return v, nil
+	}
+
+	switch v := t.(type) {
+	case *List:
+		if e := v.Get(n.index); e != nil {
+			return e, nil
+		}
+	case ByteArray:
+		if n.index < len(v) {
+			return Byte(v[n.index]), nil
+		}
+	case IntArray:
+		if n.index < len(v) {
+			return Int(v[n.index]), nil
+		}
+	case LongArray:
+		if n.index < len(v) {
+			return Long(v[n.index]), nil
+		}
+	default:
+		return nil, errors.New(TagName(t.Type()) + " cannot be indexed")
+	}
+
+	return nil, errors.New("index out of range")
+}
+
+func setChild(parent Tag, n pathNode, t Tag) error {
+	if !n.isIndex {
+		c, ok := parent.(*Compound)
+		if !ok {
+			return errors.New(TagName(parent.Type()) + " has no key " + strconv.Quote(n.key))
+		}
+		c.Set(n.key, t)
+		return nil
+	}
+
+	mismatch := errors.New("cannot store " + TagName(t.Type()) + " in " + TagName(parent.Type()))
+	switch p := parent.(type) {
+	case *List:
+		if n.index == p.Len() {
+			return p.Append(t)
+		}
+		return p.Set(n.index, t)
+	case ByteArray:
+		v, ok := t.(Byte)
+		if !ok {
+			return mismatch
+		}
+		if n.index >= len(p) {
+			return errors.New("index out of range")
+		}
+		p[n.index] = int8(v)
+	case IntArray:
+		v, ok := t.(Int)
+		if !ok {
+			return mismatch
+		}
+		if n.index >= len(p) {
+			return errors.New("index out of range")
+		}
+		p[n.index] = int32(v)
+	case LongArray:
+		v, ok := t.(Long)
+		if !ok {
+			return mismatch
+		}
+		if n.index >= len(p) {
+			return errors.New("index out of range")
+		}
+		p[n.index] = int64(v)
+	default:
+		return errors.New(TagName(parent.Type()) + " cannot be indexed")
+	}
+
+	return nil
+}
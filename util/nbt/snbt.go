@@ -0,0 +1,594 @@
+package nbt
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	snbtFloat      = regexp.MustCompile(`^[-+]?(?:[0-9]+\.?|[0-9]*\.[0-9]+)(?:[eE][-+]?[0-9]+)?[fFdD]$`)
+	snbtDouble     = regexp.MustCompile(`^[-+]?(?:[0-9]+\.|[0-9]*\.[0-9]+)(?:[eE][-+]?[0-9]+)?$`)
+	snbtInteger    = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)[bBsSlL]?$`)
+	snbtSimpleWord = regexp.MustCompile(`^[0-9A-Za-z_\-.+]+$`)
+)
+
+type SNBTSyntaxError struct {
+	Msg    string
+	Offset int
+}
+
+func (e *SNBTSyntaxError) Error() string {
+	return "nbt: " + e.Msg + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// ParseSNBT parses a stringified NBT value such as
+// {Enchantments:[{id:"sharpness",lvl:5s}]}.
+func ParseSNBT(s string) (Tag, error) {
+	p := &snbtParser{s: s}
+	t, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("trailing data")
+	}
+
+	return t, nil
+}
+
+// UnmarshalSNBT parses s and stores the result in the value pointed to by v
+// the same way Unmarshal does for binary data.
+func UnmarshalSNBT(s string, v interface{}) error {
+	t, err := ParseSNBT(s)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTag(&buf, "", t); err != nil {
+		return err
+	}
+
+	return Unmarshal(buf.Bytes(), v)
+}
+
+// deepest nesting of compounds and lists, as in vanilla
+const maxSNBTDepth = 512
+
+type snbtParser struct {
+	s     string
+	pos   int
+	depth int
+}
+
+func (p *snbtParser) errorf(msg string) error {
+	return &SNBTSyntaxError{msg, p.pos}
+}
+
+func (p *snbtParser) skipSpace() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *snbtParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+
+	return p.s[p.pos]
+}
+
+func (p *snbtParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected '" + string(c) + "'")
+	}
+
+	p.pos++
+	return nil
+}
+
+func (p *snbtParser) parseValue() (Tag, error) {
+	switch p.peek() {
+	case '{', '[':
+		return p.parseNested()
+	case '"', '\'':
+		s, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return String(s), nil
+	case 0:
+		return nil, p.errorf("expected value")
+	}
+
+	start := p.pos
+	word := p.parseWord()
+	if word == "" {
+		return nil, p.errorf("expected value")
+	}
+
+	t := literal(word)
+	if t == nil {
+		p.pos = start
+		return nil, p.errorf("invalid literal " + strconv.Quote(word))
+	}
+
+	return t, nil
+}
+
+func (p *snbtParser) parseNested() (Tag, error) {
+	if p.depth >= maxSNBTDepth {
+		return nil, p.errorf("too deeply nested")
+	}
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.s[p.pos] == '{' {
+		return p.parseCompound()
+	}
+	return p.parseList()
+}
+
+func (p *snbtParser) parseWord() string {
+	start := p.pos
+	for p.pos < len(p.s) && isWordChar(p.s[p.pos]) {
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
+
+func isWordChar(c byte) bool {
+	return c >= '0' && c <= '9' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= 'a' && c <= 'z' ||
+		c == '_' || c == '-' || c == '.' || c == '+'
+}
+
+func (p *snbtParser) parseQuoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.s) {
+				break
+			}
+			esc := p.s[p.pos]
+			if esc != '\\' && esc != '"' && esc != '\'' {
+				p.pos--
+				return "", p.errorf("invalid escape sequence")
+			}
+			sb.WriteByte(esc)
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func literal(word string) Tag {
+	switch {
+	case snbtFloat.MatchString(word):
+		num := word[:len(word)-1]
+		switch word[len(word)-1] {
+		case 'f', 'F':
+			if f, err := strconv.ParseFloat(num, 32); err == nil {
+				return Float(f)
+			}
+		default:
+			if f, err := strconv.ParseFloat(num, 64); err == nil {
+				return Double(f)
+			}
+		}
+	case snbtInteger.MatchString(word):
+		num, bits, suffix := word, 32, byte(0)
+		if last := word[len(word)-1]; last > '9' {
+			num, suffix = word[:len(word)-1], last|0x20
+		}
+		switch suffix {
+		case 'b':
+			bits = 8
+		case 's':
+			bits = 16
+		case 'l':
+			bits = 64
+		}
+		n, err := strconv.ParseInt(num, 10, bits)
+		if err != nil {
+			break
+		}
+		switch bits {
+		case 8:
+			return Byte(n)
+		case 16:
+			return Short(n)
+		case 64:
+			return Long(n)
+		}
+		return Int(n)
+	case snbtDouble.MatchString(word):
+		if f, err := strconv.ParseFloat(word, 64); err == nil {
+			return Double(f)
+		}
+	case word == "true":
+		return Byte(1)
+	case word == "false":
+		return Byte(0)
+	}
+
+	return String(word)
+}
+
+func (p *snbtParser) parseKey() (string, error) {
+	switch p.peek() {
+	case '"', '\'':
+		return p.parseQuoted()
+	}
+
+	key := p.parseWord()
+	if key == "" {
+		return "", p.errorf("expected key")
+	}
+
+	return key, nil
+}
+
+func (p *snbtParser) parseCompound() (Tag, error) {
+	p.pos++
+
+	c := NewCompound()
+	if p.peek() == '}' {
+		p.pos++
+		return c, nil
+	}
+
+	for {
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		c.Set(key, v)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return c, nil
+		default:
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *snbtParser) parseList() (Tag, error) {
+	p.pos++
+
+	p.skipSpace()
+	if p.pos+1 < len(p.s) && p.s[p.pos+1] == ';' {
+		return p.parseArray()
+	}
+
+	l := &List{}
+	if p.peek() == ']' {
+		p.pos++
+		return l, nil
+	}
+
+	for {
+		start := p.pos
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := l.Append(v); err != nil {
+			p.pos = start
+			return nil, p.errorf(strings.TrimPrefix(err.Error(), "nbt: "))
+		}
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return l, nil
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *snbtParser) parseArray() (Tag, error) {
+	kind := p.s[p.pos]
+	var min, max int64
+	switch kind {
+	case 'B':
+		min, max = math.MinInt8, math.MaxInt8
+	case 'I':
+		min, max = math.MinInt32, math.MaxInt32
+	case 'L':
+		min, max = math.MinInt64, math.MaxInt64
+	default:
+		return nil, p.errorf("invalid array type '" + string(kind) + "'")
+	}
+	p.pos += 2
+
+	var values []int64
+	if p.peek() == ']' {
+		p.pos++
+	} else {
+		for {
+			start := p.pos
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+
+			var n int64
+			switch x := v.(type) {
+			case Byte:
+				n = int64(x)
+			case Short:
+				n = int64(x)
+			case Int:
+				n = int64(x)
+			case Long:
+				n = int64(x)
+			default:
+				p.pos = start
+				return nil, p.errorf("invalid element in " + string(kind) + " array")
+			}
+			if n < min || n > max {
+				p.pos = start
+				return nil, p.errorf("value out of range in " + string(kind) + " array")
+			}
+			values = append(values, n)
+
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if err := p.expect(']'); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	switch kind {
+	case 'B':
+		a := make(ByteArray, len(values))
+		for i, n := range values {
+			a[i] = int8(n)
+		}
+		return a, nil
+	case 'I':
+		a := make(IntArray, len(values))
+		for i, n := range values {
+			a[i] = int32(n)
+		}
+		return a, nil
+	}
+
+	return LongArray(values), nil
+}
+
+// FormatSNBT returns the compact stringified form of t.
+func FormatSNBT(t Tag) string {
+	f := &snbtFormatter{}
+	f.format(t, 0)
+	return f.String()
+}
+
+// FormatSNBTIndent is like FormatSNBT but places each compound entry and
+// each element of a list of containers on its own line.
+func FormatSNBTIndent(t Tag, indent string) string {
+	f := &snbtFormatter{indent: indent}
+	f.format(t, 0)
+	return f.String()
+}
+
+// MarshalSNBT returns the compact stringified form of v, which is encoded
+// the same way Marshal does.
+func MarshalSNBT(v interface{}) (string, error) {
+	data, err := Marshal(v, "")
+	if err != nil {
+		return "", err
+	}
+
+	_, t, err := ReadTag(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return FormatSNBT(t), nil
+}
+
+type snbtFormatter struct {
+	strings.Builder
+	indent string
+}
+
+func (f *snbtFormatter) newline(depth int) {
+	if f.indent == "" {
+		return
+	}
+
+	f.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		f.WriteString(f.indent)
+	}
+}
+
+func (f *snbtFormatter) separator() {
+	f.WriteByte(',')
+}
+
+func (f *snbtFormatter) format(t Tag, depth int) {
+	switch v := t.(type) {
+	case Byte:
+		f.WriteString(strconv.FormatInt(int64(v), 10) + "b")
+	case Short:
+		f.WriteString(strconv.FormatInt(int64(v), 10) + "s")
+	case Int:
+		f.WriteString(strconv.FormatInt(int64(v), 10))
+	case Long:
+		f.WriteString(strconv.FormatInt(int64(v), 10) + "L")
+	case Float:
+		f.WriteString(formatFloat(float64(v), 32) + "f")
+	case Double:
+		f.WriteString(formatFloat(float64(v), 64) + "d")
+	case String:
+		f.WriteString(quoteSNBT(string(v)))
+	case ByteArray:
+		f.WriteString("[B;")
+		for i, n := range v {
+			if i > 0 {
+				f.separator()
+			}
+			f.WriteString(strconv.FormatInt(int64(n), 10) + "b")
+		}
+		f.WriteByte(']')
+	case IntArray:
+		f.WriteString("[I;")
+		for i, n := range v {
+			if i > 0 {
+				f.separator()
+			}
+			f.WriteString(strconv.FormatInt(int64(n), 10))
+		}
+		f.WriteByte(']')
+	case LongArray:
+		f.WriteString("[L;")
+		for i, n := range v {
+			if i > 0 {
+				f.separator()
+			}
+			f.WriteString(strconv.FormatInt(n, 10) + "L")
+		}
+		f.WriteByte(']')
+	case *List:
+		f.WriteByte('[')
+		nested := v.elemType == TagList || v.elemType == TagCompound
+		for i, e := range v.elems {
+			if i > 0 {
+				f.separator()
+				if !nested && f.indent != "" {
+					f.WriteByte(' ')
+				}
+			}
+			if nested {
+				f.newline(depth + 1)
+			}
+			f.format(e, depth+1)
+		}
+		if nested && len(v.elems) > 0 {
+			f.newline(depth)
+		}
+		f.WriteByte(']')
+	case *Compound:
+		f.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				f.separator()
+			}
+			f.newline(depth + 1)
+			f.WriteString(formatKey(k))
+			f.WriteByte(':')
+			if f.indent != "" {
+				f.WriteByte(' ')
+			}
+			f.format(v.tags[k], depth+1)
+		}
+		if len(v.keys) > 0 {
+			f.newline(depth)
+		}
+		f.WriteByte('}')
+	}
+}
+
+// formatFloat writes a number the parser reads back. SNBT has no literals
+// for NaN and infinities, so those are clamped to 0 and the largest finite
+// value.
+func formatFloat(v float64, bits int) string {
+	max := math.MaxFloat64
+	if bits == 32 {
+		max = math.MaxFloat32
+	}
+
+	switch {
+	case math.IsNaN(v):
+		v = 0
+	case math.IsInf(v, 1):
+		v = max
+	case math.IsInf(v, -1):
+		v = -max
+	}
+
+	s := strconv.FormatFloat(v, 'g', -1, bits)
+	if strings.ContainsAny(s, ".eE") {
+		return s
+	}
+
+	return s + ".0"
+}
+
+func formatKey(k string) string {
+	if snbtSimpleWord.MatchString(k) {
+		return k
+	}
+
+	return quoteSNBT(k)
+}
+
+func quoteSNBT(s string) string {
+	quote := byte('"')
+	if strings.IndexByte(s, '"') >= 0 && strings.IndexByte(s, '\'') < 0 {
+		quote = '\''
+	}
+
+	var sb strings.Builder
+	sb.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		if s[i] == quote || s[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte(quote)
+
+	return sb.String()
+}
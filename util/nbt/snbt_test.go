@@ -0,0 +1,141 @@
+package nbt
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseSNBT(t *testing.T) {
+	s := `{Enchantments:[{id:"sharpness",lvl:5s}], "display name": 'say "hi"', Pos:[1.5d, 64.0, -3d],
+		Flags: [B; 1b, 2b], Ids: [I; 1, -2], Times: [L; 3L], Rotation: [90f, 1e1f], Count: 64b, Enabled: true, Seed: 98895L, raw: stone_bricks}`
+
+	root, err := ParseSNBT(s)
+	if err != nil {
+		t.Fatalf("ParseSNBT failed: %s", err)
+	}
+
+	c := root.(*Compound)
+	checks := map[string]Tag{
+		"Enchantments[0].id":  String("sharpness"),
+		"Enchantments[0].lvl": Short(5),
+		`"display name"`:      String(`say "hi"`),
+		"Pos[1]":              Double(64),
+		"Flags[1]":            Byte(2),
+		"Ids[1]":              Int(-2),
+		"Times[0]":            Long(3),
+		"Rotation[1]":         Float(10),
+		"Count":               Byte(64),
+		"Enabled":             Byte(1),
+		"Seed":                Long(98895),
+		"raw":                 String("stone_bricks"),
+	}
+
+	for path, expected := range checks {
+		v, err := c.Path(path)
+		if err != nil {
+			t.Errorf("Path %s failed: %s", path, err)
+			continue
+		}
+		if !Equal(v, expected) {
+			t.Errorf("Path %s: expected %#v, got %#v", path, expected, v)
+		}
+	}
+}
+
+func TestParseSNBTErrors(t *testing.T) {
+	for _, s := range []string{
+		`{a:1`,
+		`[1, 2b]`,
+		`[B; 1, 300]`,
+		`{a:"unterminated}`,
+		`{a:1} trailing`,
+		`[X; 1]`,
+		strings.Repeat("[", 513) + strings.Repeat("]", 513),
+		strings.Repeat("{a:", 513) + "1" + strings.Repeat("}", 513),
+	} {
+		if _, err := ParseSNBT(s); err == nil {
+			t.Errorf("ParseSNBT(%q) should fail", s)
+		}
+	}
+}
+
+func TestParseSNBTDepth(t *testing.T) {
+	if _, err := ParseSNBT(strings.Repeat("[", 512) + strings.Repeat("]", 512)); err != nil {
+		t.Fatalf("ParseSNBT failed: %s", err)
+	}
+
+	if _, err := ParseSNBT(strings.Repeat("[", 1<<20)); err == nil {
+		t.Errorf("ParseSNBT should fail for deep nesting")
+	}
+}
+
+func TestFormatSNBTRoundTrip(t *testing.T) {
+	s := `{Enchantments:[{id:"sharpness",lvl:5s}],"display name":'say "hi"',Pos:[1.5d,64.0d],Flags:[B;1b,2b],Ids:[I;1,-2],Times:[L;3L],Rotation:[90.0f],Empty:[],Nested:{}}`
+
+	root, err := ParseSNBT(s)
+	if err != nil {
+		t.Fatalf("ParseSNBT failed: %s", err)
+	}
+
+	if out := FormatSNBT(root); out != s {
+		t.Errorf("FormatSNBT mismatch:\n%s\n%s", s, out)
+	}
+
+	indented := FormatSNBTIndent(root, "    ")
+	reparsed, err := ParseSNBT(indented)
+	if err != nil {
+		t.Fatalf("ParseSNBT of indented output failed: %s\n%s", err, indented)
+	}
+
+	if !Equal(root, reparsed) {
+		t.Errorf("Indented round trip mismatch:\n%s", indented)
+	}
+
+	t.Logf("Indented:\n%s", indented)
+}
+
+func TestSNBTReflection(t *testing.T) {
+	type item struct {
+		Id    string `nbt:"id"`
+		Count int8
+	}
+
+	var it item
+	if err := UnmarshalSNBT(`{id:"minecraft:diamond_sword",Count:1b}`, &it); err != nil {
+		t.Fatalf("UnmarshalSNBT failed: %s", err)
+	}
+
+	if it.Id != "minecraft:diamond_sword" || it.Count != 1 {
+		t.Errorf("Unexpected item: %+v", it)
+	}
+
+	s, err := MarshalSNBT(&it)
+	if err != nil {
+		t.Fatalf("MarshalSNBT failed: %s", err)
+	}
+
+	t.Logf("Marshalled SNBT: %s", s)
+}
+
+func TestFormatSNBTNonFinite(t *testing.T) {
+	cases := []struct {
+		tag  Tag
+		want Tag
+	}{
+		{Float(float32(math.NaN())), Float(0)},
+		{Float(float32(math.Inf(1))), Float(math.MaxFloat32)},
+		{Double(math.Inf(-1)), Double(-math.MaxFloat64)},
+	}
+
+	for _, c := range cases {
+		s := FormatSNBT(c.tag)
+		parsed, err := ParseSNBT(s)
+		if err != nil {
+			t.Fatalf("ParseSNBT failed on %s: %s", s, err)
+		}
+		if !Equal(parsed, c.want) {
+			t.Errorf("Unexpected value for %s: %v", s, parsed)
+		}
+	}
+}
@@ -3,25 +3,266 @@ package nbt
 import (
 	"errors"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 )
 
-func getTargetFieldNames(t reflect.Type) (map[string]int, error) {
+// field describes how one struct field is encoded, as parsed from its
+// `nbt:"name,options"` tag.
+type field struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	tag       byte // TagEnd if the tag type is inferred from typ
+}
+
+type structFields struct {
+	list   []field
+	byName map[string]int
+	err    error
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+var tagOptions = map[string]byte{
+	"byte":      TagByte,
+	"short":     TagShort,
+	"int":       TagInt,
+	"long":      TagLong,
+	"float":     TagFloat,
+	"double":    TagDouble,
+	"string":    TagString,
+	"list":      TagList,
+	"compound":  TagCompound,
+	"bytearray": TagByteArray,
+	"intarray":  TagIntArray,
+	"longarray": TagLongArray,
+}
+
+func cachedFields(t reflect.Type) *structFields {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(*structFields)
+	}
+
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.(*structFields)
+}
+
+func getTargetFields(t reflect.Type) (*structFields, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, errors.New("nbt: struct must be given")
 	}
 
-	n := t.NumField()
-	names := make(map[string]int)
+	fields := cachedFields(t)
+	return fields, fields.err
+}
+
+// typeFields collects the encoded fields of t in declaration order.
+// Fields of embedded structs are promoted unless the embedded struct is
+// given a name. As in encoding/json, a shallower field hides deeper ones
+// with the same name, and equally deep duplicates hide each other.
+func typeFields(t reflect.Type) *structFields {
+	type candidate struct {
+		field
+		depth  int
+		tagged bool
+	}
+
+	var candidates []candidate
+	var walkErr error
+
+	var walk func(t reflect.Type, index []int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("nbt")
+			if tag == "-" {
+				continue
+			}
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			name, opts := tag, ""
+			if comma := strings.IndexByte(tag, ','); comma >= 0 {
+				name, opts = tag[:comma], tag[comma+1:]
+			}
+
+			if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isDynamic(sf.Type) {
+				walk(ft, append(append([]int(nil), index...), i), visited)
+				continue
+			}
+
+			if sf.PkgPath != "" {
+				// unexported
+				continue
+			}
+
+			c := candidate{
+				field: field{
+					name:  name,
+					index: append(append([]int(nil), index...), i),
+					typ:   sf.Type,
+				},
+				depth:  len(index),
+				tagged: name != "",
+			}
+			if name == "" {
+				c.name = sf.Name
+			}
+
+			for _, opt := range strings.Split(opts, ",") {
+				switch opt {
+				case "":
+				case "omitempty":
+					c.omitEmpty = true
+				default:
+					tagType, ok := tagOptions[opt]
+					if !ok {
+						walkErr = errors.New("nbt: unknown option " + opt + " on field " + t.String() + "." + sf.Name)
+						continue
+					}
+					if !canEncodeAs(sf.Type, tagType) {
+						walkErr = errors.New("nbt: cannot encode field " + t.String() + "." + sf.Name + " as " + TagName(tagType))
+						continue
+					}
+					c.tag = tagType
+				}
+			}
+
+			candidates = append(candidates, c)
+		}
+
+		visited[t] = false
+	}
+	walk(t, nil, make(map[reflect.Type]bool))
+
+	// pick the dominant field for each name
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].name != candidates[j].name {
+			return candidates[i].name < candidates[j].name
+		}
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+		return candidates[i].tagged && !candidates[j].tagged
+	})
+
+	var list []field
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+
+		first := candidates[i]
+		if j-i == 1 || candidates[i+1].depth > first.depth || (first.tagged && !candidates[i+1].tagged) {
+			list = append(list, first.field)
+		}
+		i = j
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].index, list[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	fields := &structFields{list: list, byName: make(map[string]int), err: walkErr}
+	for i, f := range list {
+		fields.byName[f.name] = i
+	}
+
+	return fields
+}
+
+// canEncodeAs reports whether a value of type t may be forced to tagType
+// with a struct tag option.
+func canEncodeAs(t reflect.Type, tagType byte) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch tagType {
+	case TagByte, TagShort, TagInt, TagLong:
+		switch t.Kind() {
+		case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+			return true
+		}
+	case TagFloat, TagDouble:
+		return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+	case TagString:
+		return t.Kind() == reflect.String
+	case TagList:
+		return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+	case TagCompound:
+		return t.Kind() == reflect.Struct || t.Kind() == reflect.Map
+	case TagByteArray, TagIntArray, TagLongArray:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return false
+		}
+		switch t.Elem().Kind() {
+		case reflect.Bool, reflect.Int8, reflect.Uint8:
+			return tagType == TagByteArray
+		case reflect.Int32, reflect.Int, reflect.Uint32, reflect.Uint:
+			return tagType == TagIntArray
+		case reflect.Int64, reflect.Uint64:
+			return tagType == TagLongArray
+		}
+	}
+
+	return false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
 
-	for i := 0; i < n; i++ {
-		f := t.Field(i)
-		tag := f.Tag.Get("nbt")
-		if len(tag) == 0 {
-			names[f.Name] = i
-		} else {
-			names[tag] = i
+// fieldByIndex is like reflect.Value.FieldByIndex but reports whether an
+// embedded pointer on the way was nil. If alloc is set, nil embedded
+// pointers are allocated instead, unless they are unexported.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
 		}
+		v = v.Field(x)
 	}
 
-	return names, nil
+	return v, true
 }
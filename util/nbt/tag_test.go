@@ -0,0 +1,196 @@
+package nbt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type TestPosition struct {
+	Pos      [3]float64 `nbt:",list"`
+	Rotation []float32
+}
+
+type testEntity struct {
+	*TestPosition
+	Id        string `nbt:"id"`
+	CustomTag string `nbt:"CustomName,omitempty"`
+	Health    int    `nbt:",short"`
+	Colors    []int8 `nbt:",bytearray"`
+	Scratch   int    `nbt:"-"`
+	internal  int
+}
+
+func TestMarshalFieldOptions(t *testing.T) {
+	src := &testEntity{
+		TestPosition: &TestPosition{Pos: [3]float64{1, 64, -3}, Rotation: []float32{90, 0}},
+		Id:           "minecraft:zombie",
+		Health:       20,
+		Colors:       []int8{1, -1},
+		Scratch:      7,
+		internal:     8,
+	}
+
+	raw, err := Marshal(src, "")
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	_, tree, err := ReadTag(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadTag failed: %s", err)
+	}
+
+	c := tree.(*Compound)
+	expected := []string{"Pos", "Rotation", "id", "Health", "Colors"}
+	keys := c.Keys()
+	if len(keys) != len(expected) {
+		t.Fatalf("Unexpected keys: %v", keys)
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Fatalf("Unexpected keys: %v", keys)
+		}
+	}
+
+	if c.Get("Pos").Type() != TagList || c.Get("Health").Type() != TagShort || c.Get("Colors").Type() != TagByteArray {
+		t.Errorf("Type overrides not applied: %s", FormatSNBT(c))
+	}
+
+	var dst testEntity
+	if err := Unmarshal(raw, &dst); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	if dst.TestPosition == nil || dst.Pos != src.Pos || dst.Id != src.Id || dst.Health != 20 ||
+		len(dst.Colors) != 2 || dst.Colors[1] != -1 || dst.Scratch != 0 {
+		t.Errorf("Round trip mismatch: %+v", dst)
+	}
+}
+
+// every value a field option accepts must unmarshal back
+func TestFieldOptionRoundTrip(t *testing.T) {
+	cases := []interface{}{
+		&struct {
+			V bool `nbt:",byte"`
+		}{true},
+		&struct {
+			V uint16 `nbt:",short"`
+		}{65535},
+		&struct {
+			V int8 `nbt:",int"`
+		}{-5},
+		&struct {
+			V uint32 `nbt:",long"`
+		}{4000000000},
+		&struct {
+			V float64 `nbt:",float"`
+		}{1.5},
+		&struct {
+			V float32 `nbt:",double"`
+		}{0.1},
+		&struct {
+			V string `nbt:",string"`
+		}{"x"},
+		&struct {
+			V [2]int32 `nbt:",list"`
+		}{[2]int32{1, 2}},
+		&struct {
+			V map[string]int32 `nbt:",compound"`
+		}{map[string]int32{"a": 1}},
+		&struct {
+			V []bool `nbt:",bytearray"`
+		}{[]bool{true, false}},
+		&struct {
+			V []uint `nbt:",intarray"`
+		}{[]uint{1, 4000000000}},
+		&struct {
+			V []uint64 `nbt:",longarray"`
+		}{[]uint64{1, 1 << 63}},
+	}
+
+	for _, src := range cases {
+		raw, err := Marshal(src, "")
+		if err != nil {
+			t.Fatalf("Marshal failed: %s", err)
+		}
+
+		dst := reflect.New(reflect.TypeOf(src).Elem())
+		if err := Unmarshal(raw, dst.Interface()); err != nil {
+			t.Fatalf("Unmarshal of %T failed: %s", src, err)
+		}
+		if !reflect.DeepEqual(dst.Interface(), src) {
+			t.Errorf("Round trip mismatch: %+v", dst.Elem())
+		}
+	}
+}
+
+func TestMarshalFieldOptionErrors(t *testing.T) {
+	type badOption struct {
+		Name string `nbt:",intarray"`
+	}
+
+	if _, err := Marshal(&badOption{}, ""); err == nil {
+		t.Errorf("Marshal should reject incompatible type override")
+	}
+
+	type unknownOption struct {
+		Name string `nbt:",bogus"`
+	}
+
+	if _, err := Marshal(&unknownOption{}, ""); err == nil {
+		t.Errorf("Marshal should reject unknown option")
+	}
+}
+
+func TestFieldShadowing(t *testing.T) {
+	type inner struct {
+		A int32
+		B int32
+	}
+	type outer struct {
+		inner
+		B string
+	}
+
+	fields, err := getTargetFields(reflect.TypeOf(outer{}))
+	if err != nil {
+		t.Fatalf("getTargetFields failed: %s", err)
+	}
+
+	if len(fields.list) != 2 || fields.list[1].name != "B" || fields.list[1].typ.Kind().String() != "string" {
+		t.Errorf("Unexpected fields: %+v", fields.list)
+	}
+}
+
+func BenchmarkMarshalStruct(b *testing.B) {
+	src := &testEntity{
+		TestPosition: &TestPosition{Rotation: []float32{90, 0}},
+		Id:           "minecraft:zombie",
+	}
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(src, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnmarshalUnexportedEmbeddedPointer(t *testing.T) {
+	type position struct {
+		X int32
+	}
+	type entity struct {
+		*position
+	}
+
+	raw, err := Marshal(&entity{&position{1}}, "")
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	var dst entity
+	if err := Unmarshal(raw, &dst); err == nil {
+		t.Errorf("Unmarshal should fail for nil unexported embedded pointer")
+	}
+}
@@ -0,0 +1,358 @@
+package nbt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// Tag is a node of a dynamic NBT tree. Leaf tags are plain Go values
+// (Int, String, ...), containers are *List and *Compound.
+type Tag interface {
+	Type() byte
+}
+
+type Byte int8
+type Short int16
+type Int int32
+type Long int64
+type Float float32
+type Double float64
+type ByteArray []int8
+type String string
+type IntArray []int32
+type LongArray []int64
+
+func (Byte) Type() byte      { return TagByte }
+func (Short) Type() byte     { return TagShort }
+func (Int) Type() byte       { return TagInt }
+func (Long) Type() byte      { return TagLong }
+func (Float) Type() byte     { return TagFloat }
+func (Double) Type() byte    { return TagDouble }
+func (ByteArray) Type() byte { return TagByteArray }
+func (String) Type() byte    { return TagString }
+func (IntArray) Type() byte  { return TagIntArray }
+func (LongArray) Type() byte { return TagLongArray }
+
+func TagName(t byte) string {
+	switch t {
+	case TagEnd:
+		return "End"
+	case TagByte:
+		return "Byte"
+	case TagShort:
+		return "Short"
+	case TagInt:
+		return "Int"
+	case TagLong:
+		return "Long"
+	case TagFloat:
+		return "Float"
+	case TagDouble:
+		return "Double"
+	case TagByteArray:
+		return "ByteArray"
+	case TagString:
+		return "String"
+	case TagList:
+		return "List"
+	case TagCompound:
+		return "Compound"
+	case TagIntArray:
+		return "IntArray"
+	case TagLongArray:
+		return "LongArray"
+	}
+
+	return "Unknown(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ReadTag reads a single named tag in binary form from r.
+func ReadTag(r io.Reader) (name string, t Tag, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+
+	d := &decoder{r: bytes.NewReader(data)}
+	tag, name, err := d.readTag()
+	if err != nil {
+		return
+	}
+
+	if tag == TagEnd {
+		return "", nil, errors.New("nbt: unexpected end tag")
+	}
+
+	t, err = d.readPayload(tag)
+	return
+}
+
+// WriteTag writes t in binary form as a named tag to w.
+func WriteTag(w io.Writer, name string, t Tag) error {
+	e := &encoder{}
+	e.WriteByte(t.Type())
+	if err := e.writeString(name); err != nil {
+		return err
+	}
+
+	if err := e.writeTag(t); err != nil {
+		return err
+	}
+
+	_, err := w.Write(e.Bytes())
+	return err
+}
+
+// List holds tags of a single type. An empty list may have TagEnd as its
+// element type; it adopts the type of the first appended element.
+type List struct {
+	elemType byte
+	elems    []Tag
+}
+
+func NewList(elemType byte, elems ...Tag) (*List, error) {
+	l := &List{elemType: elemType}
+	for _, e := range elems {
+		if err := l.Append(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func (l *List) Type() byte {
+	return TagList
+}
+
+func (l *List) ElemType() byte {
+	return l.elemType
+}
+
+func (l *List) Len() int {
+	return len(l.elems)
+}
+
+func (l *List) Get(i int) Tag {
+	if i < 0 || i >= len(l.elems) {
+		return nil
+	}
+
+	return l.elems[i]
+}
+
+func (l *List) Set(i int, t Tag) error {
+	if i < 0 || i >= len(l.elems) {
+		return errors.New("nbt: list index out of range (" + strconv.Itoa(i) + ")")
+	}
+
+	if err := l.checkType(t); err != nil {
+		return err
+	}
+
+	l.elems[i] = t
+	return nil
+}
+
+func (l *List) Append(t Tag) error {
+	if err := l.checkType(t); err != nil {
+		return err
+	}
+
+	if len(l.elems) == 0 {
+		l.elemType = t.Type()
+	}
+
+	l.elems = append(l.elems, t)
+	return nil
+}
+
+func (l *List) Remove(i int) error {
+	if i < 0 || i >= len(l.elems) {
+		return errors.New("nbt: list index out of range (" + strconv.Itoa(i) + ")")
+	}
+
+	l.elems = append(l.elems[:i], l.elems[i+1:]...)
+	return nil
+}
+
+func (l *List) checkType(t Tag) error {
+	if t == nil {
+		return errors.New("nbt: cannot add nil to list")
+	}
+
+	if len(l.elems) == 0 && (l.elemType == TagEnd || l.elemType == t.Type()) {
+		return nil
+	}
+
+	if t.Type() != l.elemType {
+		return errors.New("nbt: cannot add " + TagName(t.Type()) + " to list of " + TagName(l.elemType))
+	}
+
+	return nil
+}
+
+// Compound is a named set of tags which remembers insertion order.
+type Compound struct {
+	keys []string
+	tags map[string]Tag
+}
+
+func NewCompound() *Compound {
+	return &Compound{tags: make(map[string]Tag)}
+}
+
+func (c *Compound) Type() byte {
+	return TagCompound
+}
+
+func (c *Compound) Len() int {
+	return len(c.keys)
+}
+
+func (c *Compound) Keys() []string {
+	keys := make([]string, len(c.keys))
+	copy(keys, c.keys)
+	return keys
+}
+
+func (c *Compound) Get(name string) Tag {
+	return c.tags[name]
+}
+
+// Set stores t under name. A nil tag cannot be written, so it is ignored.
+func (c *Compound) Set(name string, t Tag) {
+	if t == nil {
+		return
+	}
+
+	if c.tags == nil {
+		c.tags = make(map[string]Tag)
+	}
+
+	if _, ok := c.tags[name]; !ok {
+		c.keys = append(c.keys, name)
+	}
+	c.tags[name] = t
+}
+
+func (c *Compound) Delete(name string) bool {
+	if _, ok := c.tags[name]; !ok {
+		return false
+	}
+
+	delete(c.tags, name)
+	for i, k := range c.keys {
+		if k == name {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// Equal reports whether two tags have the same type and contents.
+// Compounds compare equal regardless of key order.
+func Equal(a, b Tag) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch av := a.(type) {
+	case ByteArray:
+		bv := b.(ByteArray)
+		if len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if av[i] != bv[i] {
+				return false
+			}
+		}
+		return true
+	case IntArray:
+		bv := b.(IntArray)
+		if len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if av[i] != bv[i] {
+				return false
+			}
+		}
+		return true
+	case LongArray:
+		bv := b.(LongArray)
+		if len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if av[i] != bv[i] {
+				return false
+			}
+		}
+		return true
+	case *List:
+		bv := b.(*List)
+		if av.Len() != bv.Len() {
+			return false
+		}
+		if av.Len() > 0 && av.elemType != bv.elemType {
+			return false
+		}
+		for i := range av.elems {
+			if !Equal(av.elems[i], bv.elems[i]) {
+				return false
+			}
+		}
+		return true
+	case *Compound:
+		bv := b.(*Compound)
+		if av.Len() != bv.Len() {
+			return false
+		}
+		for _, k := range av.keys {
+			other, ok := bv.tags[k]
+			if !ok || !Equal(av.tags[k], other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+// Clone returns a deep copy of t.
+func Clone(t Tag) Tag {
+	switch v := t.(type) {
+	case ByteArray:
+		return append(ByteArray(nil), v...)
+	case IntArray:
+		return append(IntArray(nil), v...)
+	case LongArray:
+		return append(LongArray(nil), v...)
+	case *List:
+		l := &List{elemType: v.elemType, elems: make([]Tag, len(v.elems))}
+		for i, e := range v.elems {
+			l.elems[i] = Clone(e)
+		}
+		return l
+	case *Compound:
+		c := NewCompound()
+		for _, k := range v.keys {
+			c.Set(k, Clone(v.tags[k]))
+		}
+		return c
+	}
+
+	return t
+}
@@ -0,0 +1,158 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func newTestTree(t *testing.T) *Compound {
+	inventory, err := NewList(TagCompound)
+	if err != nil {
+		t.Fatalf("NewList failed: %s", err)
+	}
+
+	for i, id := range []string{"minecraft:gold_ore", "minecraft:stone"} {
+		item := NewCompound()
+		item.Set("Slot", Byte(i))
+		item.Set("id", String(id))
+		item.Set("Count", Byte(1))
+		inventory.Append(item)
+	}
+
+	player := NewCompound()
+	player.Set("Inventory", inventory)
+	player.Set("Pos", IntArray{10, 64, -3})
+
+	data := NewCompound()
+	data.Set("Player", player)
+	data.Set("RandomSeed", Long(98895))
+
+	root := NewCompound()
+	root.Set("Data", data)
+
+	return root
+}
+
+func TestTreePath(t *testing.T) {
+	root := newTestTree(t)
+
+	id, err := root.Path("Data.Player.Inventory[1].id")
+	if err != nil {
+		t.Fatalf("Path failed: %s", err)
+	}
+	if id != String("minecraft:stone") {
+		t.Errorf("Unexpected id: %v", id)
+	}
+
+	y, err := root.Path("Data.Player.Pos[1]")
+	if err != nil {
+		t.Fatalf("Path failed: %s", err)
+	}
+	if y != Int(64) {
+		t.Errorf("Unexpected y: %v", y)
+	}
+
+	if _, err := root.Path("Data.Player.Inventory[2]"); err == nil {
+		t.Errorf("Path should fail for out of range index")
+	}
+
+	if err := root.SetPath("Data.Player.Abilities.flying", Byte(1)); err != nil {
+		t.Fatalf("SetPath failed: %s", err)
+	}
+	if v, _ := root.Path("Data.Player.Abilities.flying"); v != Byte(1) {
+		t.Errorf("Unexpected value after SetPath: %v", v)
+	}
+
+	if err := root.SetPath("Data.Player.Pos[0]", nil); err == nil {
+		t.Errorf("SetPath should fail for nil")
+	}
+	for _, path := range []string{"Data.Player.Pos[0]x", `Data."Player"Pos`} {
+		if _, err := root.Path(path); err == nil {
+			t.Errorf("Path should fail without a separator: %s", path)
+		}
+	}
+
+	if err := root.DeletePath("Data.Player.Inventory[0]"); err != nil {
+		t.Fatalf("DeletePath failed: %s", err)
+	}
+	if v, _ := root.Path("Data.Player.Inventory[0].id"); v != String("minecraft:stone") {
+		t.Errorf("Unexpected id after DeletePath: %v", v)
+	}
+}
+
+func TestTreeEqualAndClone(t *testing.T) {
+	root := newTestTree(t)
+	clone := Clone(root).(*Compound)
+
+	if !Equal(root, clone) {
+		t.Fatalf("Clone is not equal to original")
+	}
+
+	clone.SetPath("Data.Player.Pos[0]", Int(11))
+	if Equal(root, clone) {
+		t.Errorf("Modifying clone changed original")
+	}
+}
+
+func TestTreeRoundTrip(t *testing.T) {
+	root := newTestTree(t)
+
+	var buf bytes.Buffer
+	if err := WriteTag(&buf, "", root); err != nil {
+		t.Fatalf("WriteTag failed: %s", err)
+	}
+
+	name, read, err := ReadTag(&buf)
+	if err != nil {
+		t.Fatalf("ReadTag failed: %s", err)
+	}
+
+	if name != "" || !Equal(root, read) {
+		t.Errorf("Round trip mismatch")
+	}
+}
+
+func TestTreeUnmarshal(t *testing.T) {
+	data, _ := hex.DecodeString("0A000568656C6C6F08000161000474657374030001620000000106000163BFB41205C28F5C2904000164000000000001234500")
+
+	var root Compound
+	if err := Unmarshal(data, &root); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	if root.Get("b") != Int(1) || root.Get("d") != Long(0x12345) {
+		t.Errorf("Unexpected compound: %+v", root)
+	}
+
+	type partial struct {
+		A    string   `nbt:"a"`
+		Rest Compound `nbt:"b"`
+		Any  Tag      `nbt:"c"`
+	}
+
+	var p partial
+	if err := Unmarshal(data, &p); err == nil {
+		t.Errorf("Unmarshal of Int into Compound should fail")
+	}
+
+	type loose struct {
+		A   string `nbt:"a"`
+		Any Tag    `nbt:"c"`
+	}
+
+	var l loose
+	if err := Unmarshal(data, &l); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+	if _, ok := l.Any.(Double); !ok {
+		t.Errorf("Unexpected value: %v", l.Any)
+	}
+
+	raw, err := Marshal(&l, "hello")
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	t.Logf("Marshalled data: %s", hex.EncodeToString(raw))
+}
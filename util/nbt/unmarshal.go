@@ -1,20 +1,56 @@
 package nbt
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"log"
-	"math"
 	"reflect"
 	"strconv"
 )
 
 func Unmarshal(data []byte, v interface{}) error {
-	d := &decoder{bytes.NewReader(data)}
+	d := &decoder{r: bytes.NewReader(data)}
 	return d.unmarshal(v)
 }
 
+type Decoder struct {
+	d decoder
+}
+
+// NewDecoder returns a decoder which reads tags from r. Reads are buffered
+// unless r already implements io.ByteReader, so the decoder may consume
+// more data than the tags it returns.
+func NewDecoder(r io.Reader) *Decoder {
+	br, ok := r.(reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &Decoder{decoder{r: br}}
+}
+
+// SetEncoding selects the binary layout of numbers. The default is
+// BigEndian.
+func (dec *Decoder) SetEncoding(e Encoding) {
+	dec.d.enc = e
+}
+
+// Decode reads the next tag and stores it in the value pointed to by v,
+// which may also be a *Tag or *Compound to read a tag tree.
+func (dec *Decoder) Decode(v interface{}) error {
+	return dec.d.unmarshal(v)
+}
+
+// Unmarshaler is implemented by types which decode themselves from a tag.
+type Unmarshaler interface {
+	UnmarshalNBT(Tag) error
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
 type UnmarshalError struct {
 	Type reflect.Type
 }
@@ -40,8 +76,14 @@ func (e *UnmarshalTypeError) Error() string {
 	return "nbt: cannot unmarshal from " + e.Src + " to " + e.Dst.String()
 }
 
+type reader interface {
+	io.Reader
+	io.ByteReader
+}
+
 type decoder struct {
-	r *bytes.Reader
+	r   reader
+	enc Encoding
 }
 
 func (d *decoder) unmarshal(v interface{}) error {
@@ -77,96 +119,130 @@ func (d *decoder) readTag() (tag byte, name string, err error) {
 }
 
 func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
-	switch tag {
-	case TagByte:
-		switch k := v.Kind(); k {
-		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-			value, err := d.r.ReadByte()
-			if err != nil {
-				return err
-			}
-
-			v.SetInt(int64(value))
-		default:
-			return &UnmarshalTypeError{"Byte", k}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
 		}
-	case TagShort:
-		switch k := v.Kind(); k {
-		case reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-			value, err := d.readInt16()
-			if err != nil {
-				return err
-			}
+		return d.readValue(tag, name, v.Elem())
+	}
 
-			v.SetInt(int64(value))
-		default:
-			return &UnmarshalTypeError{"Short", k}
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		t, err := d.readPayload(tag)
+		if err != nil {
+			return err
 		}
-	case TagInt:
-		switch k := v.Kind(); k {
-		case reflect.Int, reflect.Int32, reflect.Int64:
-			value, err := d.readInt32()
-			if err != nil {
-				return err
-			}
 
-			v.SetInt(int64(value))
-		default:
-			return &UnmarshalTypeError{"Int", k}
+		return v.Addr().Interface().(Unmarshaler).UnmarshalNBT(t)
+	}
+
+	if v.Type() == tagType || (v.CanAddr() && v.Kind() == reflect.Struct && v.Addr().Type().Implements(tagType)) {
+		t, err := d.readPayload(tag)
+		if err != nil {
+			return err
 		}
-	case TagLong:
-		switch k := v.Kind(); k {
-		case reflect.Int64:
-			value, err := d.readInt64()
-			if err != nil {
-				return err
+
+		tv := reflect.ValueOf(t)
+		if v.Type() != tagType {
+			if tv.Kind() == reflect.Ptr {
+				tv = tv.Elem()
 			}
+			if tv.Type() != v.Type() {
+				return &UnmarshalTypeError{TagName(tag), v.Kind()}
+			}
+		}
+
+		v.Set(tv)
+		return nil
+	}
 
-			v.SetInt(int64(value))
+	switch tag {
+	case TagByte, TagShort, TagInt, TagLong:
+		var n int64
+		var err error
+		switch tag {
+		case TagByte:
+			var b byte
+			b, err = d.r.ReadByte()
+			n = int64(int8(b))
+		case TagShort:
+			var m int16
+			m, err = d.readInt16()
+			n = int64(m)
+		case TagInt:
+			var m int32
+			m, err = d.readInt32()
+			n = int64(m)
 		default:
-			return &UnmarshalTypeError{"Long", k}
+			n, err = d.readInt64()
 		}
+		if err != nil {
+			return err
+		}
+
+		return setInteger(v, n, tag)
 	case TagFloat:
 		switch k := v.Kind(); k {
 		case reflect.Float32, reflect.Float64:
-			value, err := d.readInt32()
+			f, err := d.encoding().ReadFloat32(d.r)
 			if err != nil {
 				return err
 			}
 
-			f := math.Float32frombits(uint32(value))
 			v.SetFloat(float64(f))
 		default:
 			return &UnmarshalTypeError{"Float", k}
 		}
 	case TagDouble:
 		switch k := v.Kind(); k {
-		case reflect.Float64:
-			value, err := d.readInt64()
+		case reflect.Float32, reflect.Float64:
+			f, err := d.encoding().ReadFloat64(d.r)
 			if err != nil {
 				return err
 			}
 
-			f := math.Float64frombits(uint64(value))
 			v.SetFloat(f)
 		default:
 			return &UnmarshalTypeError{"Double", k}
 		}
 	case TagByteArray:
+		l, err := d.readLength()
+		if err != nil {
+			return err
+		}
+
+		bs, err := d.readBytes(l)
+		if err != nil {
+			return err
+		}
+
 		switch k := v.Kind(); k {
-		case reflect.Slice:
-			length, err := d.readInt32()
-			if err != nil {
-				return err
+		case reflect.Slice, reflect.Array:
+			switch elemKind := v.Type().Elem().Kind(); elemKind {
+			case reflect.Uint8, reflect.Int8, reflect.Bool:
+			default:
+				return &UnmarshalTypeError{"ByteArray", elemKind}
 			}
 
-			bs := make([]byte, length)
-			_, err = d.r.Read(bs)
-			if err != nil {
-				return err
+			value := v
+			if k == reflect.Slice {
+				value = reflect.MakeSlice(v.Type(), 0, initialCap(l))
+			} else if v.Len() < l {
+				return errors.New("nbt: given array size is smaller than payload (" + strconv.Itoa(v.Len()) + " < " + strconv.Itoa(l) + ")")
+			}
+
+			for i, b := range bs {
+				value = grow(value, i)
+				switch elem := value.Index(i); elem.Kind() {
+				case reflect.Uint8:
+					elem.SetUint(uint64(b))
+				case reflect.Bool:
+					elem.SetBool(b != 0)
+				default:
+					elem.SetInt(int64(int8(b)))
+				}
 			}
 
-			v.SetBytes(bs)
+			v.Set(value)
 		default:
 			return &UnmarshalTypeError{"ByteArray", k}
 		}
@@ -188,7 +264,7 @@ func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
 			return err
 		}
 
-		l, err := d.readInt32()
+		l, err := d.readLength()
 		if err != nil {
 			return err
 		}
@@ -196,10 +272,10 @@ func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
 		var value reflect.Value
 		switch k := v.Kind(); k {
 		case reflect.Slice:
-			value = reflect.MakeSlice(v.Type(), int(l), int(l))
+			value = reflect.MakeSlice(v.Type(), 0, initialCap(l))
 		case reflect.Array:
-			if v.Len() < int(l) {
-				return errors.New("nbt: given array size is smaller than payload (" + strconv.Itoa(v.Len()) + " < " + strconv.Itoa(int(l)) + ")")
+			if v.Len() < l {
+				return errors.New("nbt: given array size is smaller than payload (" + strconv.Itoa(v.Len()) + " < " + strconv.Itoa(l) + ")")
 			}
 			value = v
 
@@ -207,7 +283,8 @@ func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
 			return &UnmarshalTypeError{"List", k}
 		}
 
-		for i := 0; i < int(l); i++ {
+		for i := 0; i < l; i++ {
+			value = grow(value, i)
 			if err := d.readValue(t, "", value.Index(i)); err != nil {
 				return err
 			}
@@ -217,7 +294,7 @@ func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
 	case TagCompound:
 		switch k := v.Kind(); k {
 		case reflect.Struct:
-			nameIndexMap, err := getTargetFieldNames(v.Type())
+			fields, err := getTargetFields(v.Type())
 			if err != nil {
 				return err
 			}
@@ -232,8 +309,12 @@ func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
 					break
 				}
 
-				if index, ok := nameIndexMap[name]; ok {
-					if err = d.readValue(t, name, v.Field(index)); err != nil {
+				if i, ok := fields.byName[name]; ok {
+					fv, ok := fieldByIndex(v, fields.list[i].index, true)
+					if !ok {
+						return errors.New("nbt: cannot set embedded pointer to unexported struct for field " + name)
+					}
+					if err = d.readValue(t, name, fv); err != nil {
 						return err
 					}
 				} else {
@@ -242,182 +323,172 @@ func (d *decoder) readValue(tag byte, name string, v reflect.Value) error {
 					}
 				}
 			}
-		default:
-			return &UnmarshalTypeError{"Compound", k}
-		}
-	case TagIntArray:
-		l, err := d.readInt32()
-		if err != nil {
-			return err
-		}
-
-		switch k := v.Kind(); k {
-		case reflect.Slice:
-			if elemKind := v.Type().Elem().Kind(); elemKind != reflect.Int && elemKind != reflect.Int32 {
-				return &UnmarshalTypeError{"IntArray", elemKind}
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return &UnmarshalTypeError{"Compound", k}
 			}
 
-			value := reflect.MakeSlice(v.Type(), int(l), int(l))
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
 
-			for i := 0; i < int(l); i++ {
-				n, err := d.readInt32()
+			for {
+				t, name, err := d.readTag()
 				if err != nil {
 					return err
 				}
-				value.Index(i).SetInt(int64(n))
-			}
 
-			v.Set(value)
+				if t == TagEnd {
+					break
+				}
+
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err = d.readValue(t, name, elem); err != nil {
+					return err
+				}
+				v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+			}
 		default:
-			return &UnmarshalTypeError{"IntArray", k}
+			return &UnmarshalTypeError{"Compound", k}
 		}
-	case TagLongArray:
-		l, err := d.readInt32()
+	case TagIntArray, TagLongArray:
+		l, err := d.readLength()
 		if err != nil {
 			return err
 		}
 
+		name := TagName(tag)
 		switch k := v.Kind(); k {
-		case reflect.Slice:
-			if elemKind := v.Type().Elem().Kind(); elemKind != reflect.Int64 {
-				return &UnmarshalTypeError{"LongArray", elemKind}
+		case reflect.Slice, reflect.Array:
+			switch elemKind := v.Type().Elem().Kind(); elemKind {
+			case reflect.Int, reflect.Int32, reflect.Uint, reflect.Uint32:
+				if tag != TagIntArray {
+					return &UnmarshalTypeError{name, elemKind}
+				}
+			case reflect.Int64, reflect.Uint64:
+				if tag != TagLongArray {
+					return &UnmarshalTypeError{name, elemKind}
+				}
+			default:
+				return &UnmarshalTypeError{name, elemKind}
 			}
 
-			value := reflect.MakeSlice(v.Type(), int(l), int(l))
+			value := v
+			if k == reflect.Slice {
+				value = reflect.MakeSlice(v.Type(), 0, initialCap(l))
+			} else if v.Len() < l {
+				return errors.New("nbt: given array size is smaller than payload (" + strconv.Itoa(v.Len()) + " < " + strconv.Itoa(l) + ")")
+			}
 
-			for i := 0; i < int(l); i++ {
-				n, err := d.readInt64()
-				if err != nil {
+			for i := 0; i < l; i++ {
+				value = grow(value, i)
+
+				var n int64
+				if tag == TagIntArray {
+					m, err := d.readInt32()
+					if err != nil {
+						return err
+					}
+					n = int64(m)
+				} else if n, err = d.readInt64(); err != nil {
 					return err
 				}
-				value.Index(i).SetInt(int64(n))
+
+				switch elem := value.Index(i); elem.Kind() {
+				case reflect.Uint, reflect.Uint32:
+					elem.SetUint(uint64(uint32(n)))
+				case reflect.Uint64:
+					elem.SetUint(uint64(n))
+				default:
+					elem.SetInt(n)
+				}
 			}
 
 			v.Set(value)
 		default:
-			return &UnmarshalTypeError{"LongArray", k}
+			return &UnmarshalTypeError{name, k}
 		}
 	}
 
 	return nil
 }
 
-func (d *decoder) skip(t byte) (err error) {
-	switch t {
-	case TagByte:
-		_, err = d.r.ReadByte()
-	case TagShort:
-		_, err = d.readInt16()
-	case TagInt, TagFloat:
-		_, err = d.readInt32()
-	case TagLong, TagDouble:
-		_, err = d.readInt64()
-	case TagByteArray:
-		var l int32
-		l, err = d.readInt32()
-		if err != nil {
-			return
-		}
-		_, err = d.r.Seek(int64(l), io.SeekCurrent)
-		if err != nil {
-			return
-		}
-	case TagString:
-		_, err = d.readString()
-	case TagList:
-		var listType byte
-		listType, err = d.r.ReadByte()
-		if err != nil {
-			return
-		}
-		var l int32
-		l, err = d.readInt32()
-		if err != nil {
-			return
-		}
-		for i := 0; i < int(l); i++ {
-			d.skip(listType)
-		}
-	case TagCompound:
-		var tag byte
-		for {
-			tag, _, err = d.readTag()
-			if tag == TagEnd {
-				break
-			}
-			d.skip(tag)
+// setInteger stores n, read from an integer tag, in v. Signed targets
+// must be able to hold n; unsigned targets take the tag's bit pattern so
+// that values encoded from unsigned fields come back unchanged.
+func setInteger(v reflect.Value, n int64, tag byte) error {
+	switch k := v.Kind(); k {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		if v.OverflowInt(n) {
+			return &UnmarshalTypeError{TagName(tag), k}
 		}
-	case TagIntArray, TagLongArray:
-		var delta int64 = 4
-		if t == TagLongArray {
-			delta = 8
-		}
-		var l int32
-		l, err = d.readInt32()
-		if err != nil {
-			return
+		v.SetInt(n)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		u := uint64(n)
+		switch tag {
+		case TagByte:
+			u = uint64(uint8(n))
+		case TagShort:
+			u = uint64(uint16(n))
+		case TagInt:
+			u = uint64(uint32(n))
 		}
-		_, err = d.r.Seek(int64(l)*delta, io.SeekCurrent)
-		if err != nil {
-			return
+		if v.OverflowUint(u) {
+			return &UnmarshalTypeError{TagName(tag), k}
 		}
+		v.SetUint(u)
+	case reflect.Bool:
+		v.SetBool(n != 0)
+	default:
+		return &UnmarshalTypeError{TagName(tag), k}
 	}
 
-	return
+	return nil
 }
 
-func (d *decoder) readInt16() (v int16, err error) {
-	bs := make([]byte, 2)
-	_, err = d.r.Read(bs)
-	if err != nil {
-		return
+func (d *decoder) skip(t byte) error {
+	if t == TagByteArray {
+		l, err := d.readLength()
+		if err != nil {
+			return err
+		}
+
+		_, err = io.CopyN(ioutil.Discard, d.r, int64(l))
+		return err
 	}
-	v = int16(bs[0])<<8 | int16(bs[1])
 
-	return
+	_, err := d.readPayload(t)
+	return err
 }
 
-func (d *decoder) readInt32() (v int32, err error) {
-	bs := make([]byte, 4)
-	_, err = d.r.Read(bs)
-	if err != nil {
-		return
+func (d *decoder) encoding() Encoding {
+	if d.enc == nil {
+		return BigEndian
 	}
-	v = int32(bs[0])<<24 |
-		int32(bs[1])<<16 |
-		int32(bs[2])<<8 |
-		int32(bs[3])
 
-	return
+	return d.enc
 }
 
-func (d *decoder) readInt64() (v int64, err error) {
-	bs := make([]byte, 8)
-	_, err = d.r.Read(bs)
-	if err != nil {
-		return
-	}
+func (d *decoder) readInt16() (int16, error) {
+	return d.encoding().ReadInt16(d.r)
+}
 
-	v = int64(bs[0])<<56 |
-		int64(bs[1])<<48 |
-		int64(bs[2])<<40 |
-		int64(bs[3])<<32 |
-		int64(bs[4])<<24 |
-		int64(bs[5])<<16 |
-		int64(bs[6])<<8 |
-		int64(bs[7])
+func (d *decoder) readInt32() (int32, error) {
+	return d.encoding().ReadInt32(d.r)
+}
 
-	return
+func (d *decoder) readInt64() (int64, error) {
+	return d.encoding().ReadInt64(d.r)
 }
 
 func (d *decoder) readString() (v string, err error) {
-	l, err := d.readInt16()
+	l, err := d.encoding().ReadStringLength(d.r)
 	if err != nil {
 		return
 	}
 
 	s := make([]byte, l)
-	_, err = d.r.Read(s)
+	_, err = io.ReadFull(d.r, s)
 	if err != nil {
 		return
 	}
@@ -425,3 +496,177 @@ func (d *decoder) readString() (v string, err error) {
 	v = string(s)
 	return
 }
+
+const (
+	// longest list or array read from input of unknown size, far more
+	// than any Minecraft file holds
+	maxLength = 1 << 24
+
+	// lists and arrays grow by this many elements as they are read, so a
+	// length alone does not allocate much
+	allocStep = 1 << 12
+)
+
+func initialCap(l int) int {
+	if l > allocStep {
+		return allocStep
+	}
+	return l
+}
+
+// grow makes element i of a slice being filled as it is read, so only
+// elements that arrive are allocated. Arrays are returned as they are.
+func grow(v reflect.Value, i int) reflect.Value {
+	if v.Kind() != reflect.Slice || i < v.Len() {
+		return v
+	}
+
+	return reflect.Append(v, reflect.Zero(v.Type().Elem()))
+}
+
+// readBytes reads l bytes, allocating as they arrive.
+func (d *decoder) readBytes(l int) ([]byte, error) {
+	bs := make([]byte, 0, initialCap(l))
+	for len(bs) < l {
+		n := l - len(bs)
+		if n > allocStep {
+			n = allocStep
+		}
+
+		start := len(bs)
+		bs = append(bs, make([]byte, n)...)
+		if _, err := io.ReadFull(d.r, bs[start:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return bs, nil
+}
+
+// readLength reads the length of a list or an array. When the size of the
+// input is known, lengths that could not possibly fit are rejected early
+// rather than allocated; otherwise lengths over maxLength are.
+func (d *decoder) readLength() (int, error) {
+	l, err := d.encoding().ReadLength(d.r)
+	if err != nil {
+		return 0, err
+	}
+
+	if l < 0 {
+		return 0, errors.New("nbt: invalid length " + strconv.Itoa(l))
+	}
+
+	if r, ok := d.r.(interface{ Len() int }); ok && l > r.Len() {
+		return 0, errors.New("nbt: invalid length " + strconv.Itoa(l))
+	} else if !ok && l > maxLength {
+		return 0, errors.New("nbt: length " + strconv.Itoa(l) + " is too long")
+	}
+
+	return l, nil
+}
+
+func (d *decoder) readPayload(tag byte) (Tag, error) {
+	switch tag {
+	case TagByte:
+		v, err := d.r.ReadByte()
+		return Byte(v), err
+	case TagShort:
+		v, err := d.readInt16()
+		return Short(v), err
+	case TagInt:
+		v, err := d.readInt32()
+		return Int(v), err
+	case TagLong:
+		v, err := d.readInt64()
+		return Long(v), err
+	case TagFloat:
+		v, err := d.encoding().ReadFloat32(d.r)
+		return Float(v), err
+	case TagDouble:
+		v, err := d.encoding().ReadFloat64(d.r)
+		return Double(v), err
+	case TagString:
+		v, err := d.readString()
+		return String(v), err
+	case TagByteArray:
+		l, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		bs, err := d.readBytes(l)
+		if err != nil {
+			return nil, err
+		}
+		v := make(ByteArray, l)
+		for i, b := range bs {
+			v[i] = int8(b)
+		}
+		return v, nil
+	case TagIntArray:
+		l, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		v := make(IntArray, 0, initialCap(l))
+		for i := 0; i < l; i++ {
+			n, err := d.readInt32()
+			if err != nil {
+				return nil, err
+			}
+			v = append(v, n)
+		}
+		return v, nil
+	case TagLongArray:
+		l, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		v := make(LongArray, 0, initialCap(l))
+		for i := 0; i < l; i++ {
+			n, err := d.readInt64()
+			if err != nil {
+				return nil, err
+			}
+			v = append(v, n)
+		}
+		return v, nil
+	case TagList:
+		t, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		l, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		list := &List{elemType: t, elems: make([]Tag, 0, initialCap(l))}
+		for i := 0; i < l; i++ {
+			e, err := d.readPayload(t)
+			if err != nil {
+				return nil, err
+			}
+			list.elems = append(list.elems, e)
+		}
+		return list, nil
+	case TagCompound:
+		c := NewCompound()
+		for {
+			t, name, err := d.readTag()
+			if err != nil {
+				return nil, err
+			}
+
+			if t == TagEnd {
+				return c, nil
+			}
+
+			v, err := d.readPayload(t)
+			if err != nil {
+				return nil, err
+			}
+			c.Set(name, v)
+		}
+	}
+
+	return nil, errors.New("nbt: unknown tag type " + strconv.Itoa(int(tag)))
+}
@@ -1,7 +1,10 @@
 package nbt
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/hex"
+	"io"
 	"testing"
 )
 
@@ -153,3 +156,26 @@ func TestNbtUnmarshalByte(t *testing.T) {
 
 	t.Logf("Unmarshalled data: %d", val)
 }
+
+func TestNbtUnmarshalLongLength(t *testing.T) {
+	// a long array claiming 2^31-1 elements, read from a stream
+	data, _ := hex.DecodeString("0C00007FFFFFFF0000000000000001")
+	var val []int64
+	if err := NewDecoder(io.MultiReader(bytes.NewReader(data))).Decode(&val); err == nil {
+		t.Errorf("Decode should fail for a length over the limit")
+	}
+
+	// within the limit but cut short
+	data, _ = hex.DecodeString("00FFFFFF0000000000000001")
+	d := &decoder{r: bufio.NewReader(io.MultiReader(bytes.NewReader(data)))}
+	if _, err := d.readPayload(TagLongArray); err == nil {
+		t.Errorf("readPayload should fail for a truncated array")
+	}
+
+	// a list of 2^24-1 large structs must not be allocated before it is read
+	data, _ = hex.DecodeString("0900000A00FFFFFF")
+	var structs []struct{ A [256]int64 }
+	if err := NewDecoder(io.MultiReader(bytes.NewReader(data))).Decode(&structs); err == nil {
+		t.Errorf("Decode should fail for a truncated list")
+	}
+}
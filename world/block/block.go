@@ -0,0 +1,241 @@
+//go:generate go run generate.go
+
+// Package block maps block states, items, entities, biomes and sounds to
+// the numeric IDs of the 1.15.2 protocol.
+package block
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Property struct {
+	Name   string
+	Values []string
+}
+
+// Block is a block type. Its states take consecutive IDs from MinState,
+// enumerating property values with the last property changing fastest.
+type Block struct {
+	Name         string
+	Properties   []Property
+	MinState     int32
+	DefaultState int32
+}
+
+var (
+	byName  = make(map[string]*Block)
+	byState []*Block
+)
+
+func init() {
+	for i := range blocks {
+		b := &blocks[i]
+		byName[b.Name] = b
+
+		for id := b.MinState; id <= b.MaxState(); id++ {
+			for int(id) >= len(byState) {
+				byState = append(byState, nil)
+			}
+			byState[id] = b
+		}
+	}
+}
+
+func (b *Block) StateCount() int32 {
+	n := int32(1)
+	for _, p := range b.Properties {
+		n *= int32(len(p.Values))
+	}
+
+	return n
+}
+
+func (b *Block) MaxState() int32 {
+	return b.MinState + b.StateCount() - 1
+}
+
+// State returns the state with the given properties. Properties that are
+// not given keep their default value.
+func (b *Block) State(props map[string]string) (int32, bool) {
+	known := 0
+	offset := int32(0)
+	defaultOffset := b.DefaultState - b.MinState
+
+	for i := len(b.Properties) - 1; i >= 0; i-- {
+		p := b.Properties[i]
+		n := int32(len(p.Values))
+		index := defaultOffset % n
+		defaultOffset /= n
+
+		if v, ok := props[p.Name]; ok {
+			known++
+			index = -1
+			for j, value := range p.Values {
+				if value == v {
+					index = int32(j)
+				}
+			}
+			if index < 0 {
+				return 0, false
+			}
+		}
+
+		offset += index * stride(b.Properties[i+1:])
+	}
+
+	if known != len(props) {
+		return 0, false
+	}
+
+	return b.MinState + offset, true
+}
+
+func stride(props []Property) int32 {
+	n := int32(1)
+	for _, p := range props {
+		n *= int32(len(p.Values))
+	}
+
+	return n
+}
+
+// StateProperties returns the property values of a state of b.
+func (b *Block) StateProperties(id int32) map[string]string {
+	if id < b.MinState || id > b.MaxState() {
+		return nil
+	}
+
+	props := make(map[string]string, len(b.Properties))
+	offset := id - b.MinState
+	for i := len(b.Properties) - 1; i >= 0; i-- {
+		p := b.Properties[i]
+		n := int32(len(p.Values))
+		props[p.Name] = p.Values[offset%n]
+		offset /= n
+	}
+
+	return props
+}
+
+func namespaced(name string) string {
+	if strings.IndexByte(name, ':') < 0 {
+		return "minecraft:" + name
+	}
+
+	return name
+}
+
+// ByName returns the block with the given name. The minecraft namespace
+// may be left out.
+func ByName(name string) *Block {
+	return byName[namespaced(name)]
+}
+
+// ByState returns the block a state belongs to.
+func ByState(id int32) *Block {
+	if id < 0 || int(id) >= len(byState) {
+		return nil
+	}
+
+	return byState[id]
+}
+
+// All returns every known block ordered by state ID.
+func All() []*Block {
+	all := make([]*Block, len(blocks))
+	for i := range blocks {
+		all[i] = &blocks[i]
+	}
+
+	return all
+}
+
+func StateID(name string, props map[string]string) (int32, bool) {
+	b := ByName(name)
+	if b == nil {
+		return 0, false
+	}
+
+	return b.State(props)
+}
+
+func State(id int32) (name string, props map[string]string, ok bool) {
+	b := ByState(id)
+	if b == nil {
+		return "", nil, false
+	}
+
+	return b.Name, b.StateProperties(id), true
+}
+
+// Parse parses a block state in the command syntax, such as
+// minecraft:oak_stairs[facing=north,half=bottom].
+func Parse(s string) (int32, error) {
+	name, props := s, make(map[string]string)
+
+	if open := strings.IndexByte(s, '['); open >= 0 {
+		if !strings.HasSuffix(s, "]") {
+			return 0, errors.New("block: unclosed properties in " + strconv.Quote(s))
+		}
+
+		name = s[:open]
+		for _, kv := range strings.Split(s[open+1:len(s)-1], ",") {
+			if kv = strings.TrimSpace(kv); kv == "" {
+				continue
+			}
+			eq := strings.IndexByte(kv, '=')
+			if eq < 0 {
+				return 0, errors.New("block: invalid property " + strconv.Quote(kv))
+			}
+			props[strings.TrimSpace(kv[:eq])] = strings.TrimSpace(kv[eq+1:])
+		}
+	}
+
+	b := ByName(name)
+	if b == nil {
+		return 0, errors.New("block: unknown block " + strconv.Quote(name))
+	}
+
+	id, ok := b.State(props)
+	if !ok {
+		return 0, errors.New("block: invalid properties for " + b.Name)
+	}
+
+	return id, nil
+}
+
+// Format returns a state in the syntax accepted by Parse.
+func Format(id int32) string {
+	b := ByState(id)
+	if b == nil {
+		return "unknown:" + strconv.Itoa(int(id))
+	}
+	if len(b.Properties) == 0 {
+		return b.Name
+	}
+
+	props := b.StateProperties(id)
+	pairs := make([]string, 0, len(props))
+	for _, p := range b.Properties {
+		pairs = append(pairs, p.Name+"="+props[p.Name])
+	}
+	sort.Strings(pairs)
+
+	return b.Name + "[" + strings.Join(pairs, ",") + "]"
+}
+
+// Registry implements chunk.Registry.
+var Registry registry
+
+type registry struct{}
+
+func (registry) StateID(name string, props map[string]string) (int32, bool) {
+	return StateID(name, props)
+}
+
+func (registry) State(id int32) (string, map[string]string, bool) {
+	return State(id)
+}
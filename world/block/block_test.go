@@ -0,0 +1,82 @@
+package block
+
+import "testing"
+
+func TestBlockStates(t *testing.T) {
+	tests := []struct {
+		state string
+		id    int32
+	}{
+		{"minecraft:air", 0},
+		{"grass_block", 9},
+		{"minecraft:grass_block[snowy=true]", 8},
+		{"minecraft:oak_log[axis=x]", 72},
+		{"minecraft:oak_leaves[distance=1,persistent=true]", 144},
+		{"minecraft:oak_stairs[facing=north,half=bottom]", 1963},
+		{"minecraft:cave_air", 9670},
+	}
+
+	for _, test := range tests {
+		id, err := Parse(test.state)
+		if err != nil {
+			t.Fatalf("Parse failed: %s", err)
+		}
+		if id != test.id {
+			t.Fatalf("%s: expected %d but got %d", test.state, test.id, id)
+		}
+
+		name, props, ok := State(id)
+		if !ok {
+			t.Fatalf("State(%d) failed", id)
+		}
+		if back, ok := StateID(name, props); !ok || back != id {
+			t.Fatalf("%s did not round trip: %d", Format(id), back)
+		}
+	}
+
+	if s := Format(1963); s != "minecraft:oak_stairs[facing=north,half=bottom,shape=straight,waterlogged=false]" {
+		t.Fatalf("unexpected format: %s", s)
+	}
+
+	for _, state := range []string{"minecraft:nothing", "minecraft:stone[foo=bar]", "minecraft:oak_log[axis=w]", "minecraft:oak_log[axis=x"} {
+		if _, err := Parse(state); err == nil {
+			t.Fatalf("Parse accepted %s", state)
+		}
+	}
+}
+
+func TestRegistries(t *testing.T) {
+	if id, ok := Biomes.ID("plains"); !ok || id != 1 {
+		t.Fatalf("unexpected plains id: %d", id)
+	}
+	if name, ok := Items.Name(1); !ok || name != "minecraft:stone" {
+		t.Fatalf("unexpected item 1: %s", name)
+	}
+}
+
+func TestCollision(t *testing.T) {
+	tests := []struct {
+		state                   string
+		solid, collides, isTall bool
+	}{
+		{"air", false, false, false},
+		{"stone", true, true, false},
+		{"poppy", false, false, false},
+		{"oak_stairs", false, true, false},
+		{"oak_door", false, true, false},
+		{"ladder", false, true, false},
+		{"white_bed", false, true, false},
+		{"chest", false, true, false},
+		{"oak_fence", false, true, true},
+	}
+
+	for _, test := range tests {
+		id, err := Parse(test.state)
+		if err != nil {
+			t.Fatalf("Parse failed: %s", err)
+		}
+		if Solid(id) != test.solid || Collides(id) != test.collides || Tall(id) != test.isTall {
+			t.Fatalf("%s: unexpected shape", test.state)
+		}
+	}
+}
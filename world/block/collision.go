@@ -0,0 +1,196 @@
+package block
+
+import "strings"
+
+// The data reports have no collision shapes either. Every block is
+// treated as a full cube except the ones below, which can be walked
+// through or only fill part of their space.
+
+var passable = map[string]bool{
+	"minecraft:air":                   true,
+	"minecraft:void_air":              true,
+	"minecraft:cave_air":              true,
+	"minecraft:water":                 true,
+	"minecraft:lava":                  true,
+	"minecraft:grass":                 true,
+	"minecraft:tall_grass":            true,
+	"minecraft:fern":                  true,
+	"minecraft:large_fern":            true,
+	"minecraft:dead_bush":             true,
+	"minecraft:seagrass":              true,
+	"minecraft:tall_seagrass":         true,
+	"minecraft:kelp":                  true,
+	"minecraft:kelp_plant":            true,
+	"minecraft:dandelion":             true,
+	"minecraft:poppy":                 true,
+	"minecraft:blue_orchid":           true,
+	"minecraft:allium":                true,
+	"minecraft:azure_bluet":           true,
+	"minecraft:oxeye_daisy":           true,
+	"minecraft:cornflower":            true,
+	"minecraft:wither_rose":           true,
+	"minecraft:lily_of_the_valley":    true,
+	"minecraft:sunflower":             true,
+	"minecraft:lilac":                 true,
+	"minecraft:rose_bush":             true,
+	"minecraft:peony":                 true,
+	"minecraft:sugar_cane":            true,
+	"minecraft:wheat":                 true,
+	"minecraft:carrots":               true,
+	"minecraft:potatoes":              true,
+	"minecraft:beetroots":             true,
+	"minecraft:vine":                  true,
+	"minecraft:cobweb":                true,
+	"minecraft:fire":                  true,
+	"minecraft:nether_portal":         true,
+	"minecraft:end_portal":            true,
+	"minecraft:redstone_wire":         true,
+	"minecraft:lever":                 true,
+	"minecraft:tripwire":              true,
+	"minecraft:tripwire_hook":         true,
+	"minecraft:rail":                  true,
+	"minecraft:structure_void":        true,
+	"minecraft:torch":                 true,
+	"minecraft:sweet_berry_bush":      true,
+	"minecraft:nether_wart":           true,
+	"minecraft:melon_stem":            true,
+	"minecraft:pumpkin_stem":          true,
+	"minecraft:attached_melon_stem":   true,
+	"minecraft:attached_pumpkin_stem": true,
+}
+
+var passableSuffixes = []string{
+	"_sapling", "_tulip", "_mushroom", "_torch", "_rail", "_sign",
+	"_pressure_plate", "_button", "_banner", "_coral", "_coral_fan",
+}
+
+// blocks smaller than their space, or that open, like doors
+var partial = map[string]bool{
+	"minecraft:ladder":            true,
+	"minecraft:scaffolding":       true,
+	"minecraft:snow":              true,
+	"minecraft:farmland":          true,
+	"minecraft:grass_path":        true,
+	"minecraft:soul_sand":         true,
+	"minecraft:honey_block":       true,
+	"minecraft:chest":             true,
+	"minecraft:trapped_chest":     true,
+	"minecraft:ender_chest":       true,
+	"minecraft:shulker_box":       true,
+	"minecraft:cactus":            true,
+	"minecraft:cake":              true,
+	"minecraft:lily_pad":          true,
+	"minecraft:sea_pickle":        true,
+	"minecraft:turtle_egg":        true,
+	"minecraft:cocoa":             true,
+	"minecraft:bamboo":            true,
+	"minecraft:chorus_plant":      true,
+	"minecraft:chorus_flower":     true,
+	"minecraft:end_rod":           true,
+	"minecraft:dragon_egg":        true,
+	"minecraft:conduit":           true,
+	"minecraft:flower_pot":        true,
+	"minecraft:iron_bars":         true,
+	"minecraft:glass_pane":        true,
+	"minecraft:enchanting_table":  true,
+	"minecraft:end_portal_frame":  true,
+	"minecraft:brewing_stand":     true,
+	"minecraft:cauldron":          true,
+	"minecraft:hopper":            true,
+	"minecraft:lectern":           true,
+	"minecraft:grindstone":        true,
+	"minecraft:stonecutter":       true,
+	"minecraft:bell":              true,
+	"minecraft:lantern":           true,
+	"minecraft:campfire":          true,
+	"minecraft:composter":         true,
+	"minecraft:daylight_detector": true,
+	"minecraft:repeater":          true,
+	"minecraft:comparator":        true,
+	"minecraft:piston":            true,
+	"minecraft:sticky_piston":     true,
+	"minecraft:piston_head":       true,
+	"minecraft:moving_piston":     true,
+}
+
+var partialSuffixes = []string{
+	"_slab", "_stairs", "_door", "_trapdoor", "_carpet", "_bed", "_pane",
+	"_shulker_box", "_head", "_skull", "anvil", "_fence", "_fence_gate",
+	"_wall",
+}
+
+// blocks reaching half a block above their space
+var tallSuffixes = []string{"_fence", "_fence_gate", "_wall"}
+
+// shapes of blocks
+const (
+	shapeNone = iota
+	shapePartial
+	shapeFull
+)
+
+var (
+	shapes []uint8
+	tall   []bool
+)
+
+func init() {
+	n := blocks[len(blocks)-1].MaxState() + 1
+	shapes = make([]uint8, n)
+	tall = make([]bool, n)
+	for _, b := range blocks {
+		shape := shapeOf(b.Name)
+		isTall := hasSuffix(b.Name, tallSuffixes)
+		for id := b.MinState; id <= b.MaxState(); id++ {
+			shapes[id] = shape
+			tall[id] = isTall
+		}
+	}
+}
+
+func shapeOf(name string) uint8 {
+	switch {
+	case passable[name] || hasSuffix(name, passableSuffixes):
+		return shapeNone
+	case partial[name] || strings.HasPrefix(name, "minecraft:potted_") || hasSuffix(name, partialSuffixes):
+		return shapePartial
+	}
+
+	return shapeFull
+}
+
+func hasSuffix(name string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func shape(state int32) uint8 {
+	if state < 0 || int(state) >= len(shapes) {
+		return shapeNone
+	}
+
+	return shapes[state]
+}
+
+// Solid reports whether a block state fills its whole space, so that
+// entities cannot be anywhere inside it.
+func Solid(state int32) bool {
+	return shape(state) == shapeFull
+}
+
+// Collides reports whether entities may collide with some part of a
+// block state, which can be stood on.
+func Collides(state int32) bool {
+	return shape(state) != shapeNone
+}
+
+// Tall reports whether a block state reaches above its space, like
+// fences and walls.
+func Tall(state int32) bool {
+	return state >= 0 && int(state) < len(tall) && tall[state]
+}
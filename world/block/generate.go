@@ -0,0 +1,199 @@
+//go:build ignore
+// +build ignore
+
+// This program generates blocks_gen.go and registries_gen.go from the
+// data reports of the vanilla server, which are produced by
+//
+//	java -cp server.jar net.minecraft.data.Main --reports
+//
+// and copied into data/.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+)
+
+type blockReport struct {
+	Properties map[string][]string `json:"properties"`
+	States     []struct {
+		Properties map[string]string `json:"properties"`
+		Id         int32             `json:"id"`
+		Default    bool              `json:"default"`
+	} `json:"states"`
+}
+
+type registryReport struct {
+	Entries map[string]struct {
+		ProtocolId int32 `json:"protocol_id"`
+	} `json:"entries"`
+}
+
+func main() {
+	generateBlocks()
+	generateRegistries()
+}
+
+func readJSON(path string, v interface{}) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Fatalf("%s: %s", path, err)
+	}
+}
+
+func writeSource(path string, buf *bytes.Buffer) {
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("%s: %s", path, err)
+	}
+
+	if err := ioutil.WriteFile(path, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generateBlocks() {
+	var report map[string]blockReport
+	readJSON("data/blocks.json", &report)
+
+	type block struct {
+		name       string
+		props      []string
+		values     [][]string
+		minState   int32
+		defaultId  int32
+		stateCount int
+	}
+
+	var blocks []block
+	for name, r := range report {
+		b := block{name: name, minState: -1, defaultId: -1, stateCount: 1}
+
+		// properties are sorted by name and the last one changes fastest
+		for prop := range r.Properties {
+			b.props = append(b.props, prop)
+		}
+		sort.Strings(b.props)
+		for _, prop := range b.props {
+			b.values = append(b.values, r.Properties[prop])
+			b.stateCount *= len(r.Properties[prop])
+		}
+
+		if len(r.States) != b.stateCount {
+			log.Fatalf("%s: %d states, expected %d", name, len(r.States), b.stateCount)
+		}
+
+		for _, s := range r.States {
+			if b.minState < 0 || s.Id < b.minState {
+				b.minState = s.Id
+			}
+		}
+
+		for _, s := range r.States {
+			offset := 0
+			for i, prop := range b.props {
+				index := -1
+				for j, v := range b.values[i] {
+					if v == s.Properties[prop] {
+						index = j
+					}
+				}
+				if index < 0 {
+					log.Fatalf("%s: state %d has invalid %s", name, s.Id, prop)
+				}
+				offset = offset*len(b.values[i]) + index
+			}
+
+			if s.Id != b.minState+int32(offset) {
+				log.Fatalf("%s: state %d is out of order", name, s.Id)
+			}
+			if s.Default {
+				b.defaultId = s.Id
+			}
+		}
+
+		if b.defaultId < 0 {
+			log.Fatalf("%s: no default state", name)
+		}
+		blocks = append(blocks, b)
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].minState < blocks[j].minState })
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by generate.go from data/blocks.json; DO NOT EDIT.\n\n")
+	buf.WriteString("package block\n\n")
+	buf.WriteString("var blocks = []Block{\n")
+	for _, b := range blocks {
+		fmt.Fprintf(&buf, "\t{Name: %q", b.name)
+		if len(b.props) > 0 {
+			buf.WriteString(", Properties: []Property{")
+			for i, prop := range b.props {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				fmt.Fprintf(&buf, "{%q, []string{%s}}", prop, quoteAll(b.values[i]))
+			}
+			buf.WriteString("}")
+		}
+		fmt.Fprintf(&buf, ", MinState: %d, DefaultState: %d},\n", b.minState, b.defaultId)
+	}
+	buf.WriteString("}\n")
+
+	writeSource("blocks_gen.go", &buf)
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func generateRegistries() {
+	var report map[string]registryReport
+	readJSON("data/registries.json", &report)
+
+	var names []string
+	for name := range report {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by generate.go from data/registries.json; DO NOT EDIT.\n\n")
+	buf.WriteString("package block\n\n")
+	buf.WriteString("var registryEntries = map[string][]entry{\n")
+	for _, name := range names {
+		type e struct {
+			name string
+			id   int32
+		}
+		var entries []e
+		for entry, v := range report[name].Entries {
+			entries = append(entries, e{entry, v.ProtocolId})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+
+		fmt.Fprintf(&buf, "\t%q: {\n", name)
+		for _, entry := range entries {
+			fmt.Fprintf(&buf, "\t\t{%q, %d},\n", entry.name, entry.id)
+		}
+		buf.WriteString("\t},\n")
+	}
+	buf.WriteString("}\n")
+
+	writeSource("registries_gen.go", &buf)
+}
@@ -0,0 +1,62 @@
+package block
+
+import "strings"
+
+type entry struct {
+	name string
+	id   int32
+}
+
+// IDRegistry maps the names of one vanilla registry to protocol IDs.
+type IDRegistry struct {
+	ids   map[string]int32
+	names map[int32]string
+	order []string
+}
+
+var (
+	Items    = newIDRegistry(registryEntries["minecraft:item"])
+	Entities = newIDRegistry(registryEntries["minecraft:entity_type"])
+	Biomes   = newIDRegistry(registryEntries["minecraft:biome"])
+	Sounds   = newIDRegistry(registryEntries["minecraft:sound_event"])
+)
+
+func newIDRegistry(entries []entry) *IDRegistry {
+	r := &IDRegistry{
+		ids:   make(map[string]int32, len(entries)),
+		names: make(map[int32]string, len(entries)),
+	}
+
+	for _, e := range entries {
+		r.ids[e.name] = e.id
+		r.names[e.id] = e.name
+		r.order = append(r.order, e.name)
+	}
+
+	return r
+}
+
+// ID returns the protocol ID of name. The minecraft namespace may be left
+// out.
+func (r *IDRegistry) ID(name string) (int32, bool) {
+	if strings.IndexByte(name, ':') < 0 {
+		name = "minecraft:" + name
+	}
+
+	id, ok := r.ids[name]
+	return id, ok
+}
+
+func (r *IDRegistry) Name(id int32) (string, bool) {
+	name, ok := r.names[id]
+	return name, ok
+}
+
+// Names returns all names ordered by ID.
+func (r *IDRegistry) Names() []string {
+	return append([]string(nil), r.order...)
+}
+
+func (r *IDRegistry) Len() int {
+	return len(r.order)
+}
@@ -0,0 +1,253 @@
+package chunk
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+// DataVersion is the data version of 1.15.2 written into saved chunks.
+const DataVersion = 2230
+
+// Registry maps global block state IDs to the block name and properties
+// stored in Anvil palettes.
+type Registry interface {
+	StateID(name string, props map[string]string) (int32, bool)
+	State(id int32) (name string, props map[string]string, ok bool)
+}
+
+// Level tags handled by the model; everything else goes to Chunk.Extra.
+var knownLevelTags = map[string]bool{
+	"xPos":          true,
+	"zPos":          true,
+	"LastUpdate":    true,
+	"InhabitedTime": true,
+	"Biomes":        true,
+	"Heightmaps":    true,
+	"Sections":      true,
+}
+
+// FromNBT builds a chunk from the root compound of a chunk in a region
+// file. Block states unknown to reg are replaced with air.
+func FromNBT(root *nbt.Compound, reg Registry) (*Chunk, error) {
+	level, ok := root.Get("Level").(*nbt.Compound)
+	if !ok {
+		return nil, errors.New("chunk: missing Level compound")
+	}
+
+	x, okX := level.Get("xPos").(nbt.Int)
+	z, okZ := level.Get("zPos").(nbt.Int)
+	if !okX || !okZ {
+		return nil, errors.New("chunk: missing chunk position")
+	}
+
+	c := New(int32(x), int32(z))
+	if v, ok := level.Get("LastUpdate").(nbt.Long); ok {
+		c.LastUpdate = int64(v)
+	}
+	if v, ok := level.Get("InhabitedTime").(nbt.Long); ok {
+		c.InhabitedTime = int64(v)
+	}
+	if v, ok := level.Get("Biomes").(nbt.IntArray); ok && len(v) == BiomeCount {
+		copy(c.Biomes[:], v)
+	}
+
+	if sections, ok := level.Get("Sections").(*nbt.List); ok {
+		for i := 0; i < sections.Len(); i++ {
+			section, ok := sections.Get(i).(*nbt.Compound)
+			if !ok {
+				return nil, errors.New("chunk: section is not a compound")
+			}
+			if err := c.readSection(section, reg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	heightmaps, _ := level.Get("Heightmaps").(*nbt.Compound)
+	if heightmaps == nil || !readHeightmap(&c.MotionBlocking, heightmaps.Get("MOTION_BLOCKING")) ||
+		!readHeightmap(&c.WorldSurface, heightmaps.Get("WORLD_SURFACE")) {
+		c.UpdateHeightmaps()
+	}
+
+	for _, k := range level.Keys() {
+		if !knownLevelTags[k] {
+			c.Extra.Set(k, level.Get(k))
+		}
+	}
+
+	return c, nil
+}
+
+func readHeightmap(h *Heightmap, t nbt.Tag) bool {
+	data, ok := t.(nbt.LongArray)
+	return ok && h.unpack(data)
+}
+
+func (c *Chunk) readSection(section *nbt.Compound, reg Registry) error {
+	y, ok := section.Get("Y").(nbt.Byte)
+	if !ok || y < -1 || y > SectionCount {
+		return errors.New("chunk: invalid section y")
+	}
+
+	if light, ok := section.Get("BlockLight").(nbt.ByteArray); ok && len(light) == SectionSize/2 {
+		c.BlockLight[y+1] = fromByteArray(light)
+	}
+	if light, ok := section.Get("SkyLight").(nbt.ByteArray); ok && len(light) == SectionSize/2 {
+		c.SkyLight[y+1] = fromByteArray(light)
+	}
+
+	palette, ok := section.Get("Palette").(*nbt.List)
+	if !ok || y < 0 || y >= SectionCount {
+		return nil
+	}
+
+	states := make([]int32, palette.Len())
+	for i := range states {
+		entry, ok := palette.Get(i).(*nbt.Compound)
+		if !ok {
+			return errors.New("chunk: palette entry is not a compound")
+		}
+
+		name, _ := entry.Get("Name").(nbt.String)
+		props := make(map[string]string)
+		if p, ok := entry.Get("Properties").(*nbt.Compound); ok {
+			for _, k := range p.Keys() {
+				if v, ok := p.Get(k).(nbt.String); ok {
+					props[k] = string(v)
+				}
+			}
+		}
+
+		if id, ok := reg.StateID(string(name), props); ok {
+			states[i] = id
+		}
+	}
+
+	data, _ := section.Get("BlockStates").(nbt.LongArray)
+	if len(data) == 0 || len(data)%64 != 0 || len(data)/64 > 32 {
+		return errors.New("chunk: invalid block states length")
+	}
+
+	s := newSectionFrom(states, toUint64s(data))
+	if !s.IsEmpty() {
+		c.Sections[y] = s
+	}
+
+	return nil
+}
+
+// ToNBT returns the chunk in the layout of a region file chunk.
+func (c *Chunk) ToNBT(reg Registry) (*nbt.Compound, error) {
+	level := nbt.NewCompound()
+	level.Set("xPos", nbt.Int(c.X))
+	level.Set("zPos", nbt.Int(c.Z))
+	level.Set("LastUpdate", nbt.Long(c.LastUpdate))
+	level.Set("InhabitedTime", nbt.Long(c.InhabitedTime))
+	level.Set("Biomes", nbt.IntArray(append([]int32(nil), c.Biomes[:]...)))
+
+	heightmaps := nbt.NewCompound()
+	heightmaps.Set("MOTION_BLOCKING", nbt.LongArray(c.MotionBlocking.pack()))
+	heightmaps.Set("WORLD_SURFACE", nbt.LongArray(c.WorldSurface.pack()))
+	level.Set("Heightmaps", heightmaps)
+
+	sections, _ := nbt.NewList(nbt.TagCompound)
+	for y := -1; y <= SectionCount; y++ {
+		section, err := c.writeSection(y, reg)
+		if err != nil {
+			return nil, err
+		}
+		if section != nil {
+			sections.Append(section)
+		}
+	}
+	level.Set("Sections", sections)
+
+	if c.Extra != nil {
+		for _, k := range c.Extra.Keys() {
+			level.Set(k, c.Extra.Get(k))
+		}
+	}
+	if level.Get("Status") == nil {
+		level.Set("Status", nbt.String("full"))
+	}
+
+	root := nbt.NewCompound()
+	root.Set("DataVersion", nbt.Int(DataVersion))
+	root.Set("Level", level)
+
+	return root, nil
+}
+
+func (c *Chunk) writeSection(y int, reg Registry) (*nbt.Compound, error) {
+	var blocks *Section
+	if y >= 0 && y < SectionCount {
+		blocks = c.Sections[y]
+	}
+
+	blockLight, skyLight := c.BlockLight[y+1], c.SkyLight[y+1]
+	if blocks == nil && blockLight == nil && skyLight == nil {
+		return nil, nil
+	}
+
+	section := nbt.NewCompound()
+	section.Set("Y", nbt.Byte(y))
+
+	if blocks != nil {
+		states, data := blocks.compact(minIndirectBits)
+		palette, _ := nbt.NewList(nbt.TagCompound)
+		for _, state := range states {
+			name, props, ok := reg.State(state)
+			if !ok {
+				return nil, errors.New("chunk: unknown block state")
+			}
+
+			entry := nbt.NewCompound()
+			entry.Set("Name", nbt.String(name))
+			if len(props) > 0 {
+				keys := make([]string, 0, len(props))
+				for k := range props {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+
+				p := nbt.NewCompound()
+				for _, k := range keys {
+					p.Set(k, nbt.String(props[k]))
+				}
+				entry.Set("Properties", p)
+			}
+			palette.Append(entry)
+		}
+		section.Set("Palette", palette)
+		section.Set("BlockStates", nbt.LongArray(toInt64s(data.data)))
+	}
+
+	if blockLight != nil {
+		section.Set("BlockLight", toByteArray(blockLight))
+	}
+	if skyLight != nil {
+		section.Set("SkyLight", toByteArray(skyLight))
+	}
+
+	return section, nil
+}
+
+func fromByteArray(a nbt.ByteArray) NibbleArray {
+	out := make(NibbleArray, len(a))
+	for i, v := range a {
+		out[i] = byte(v)
+	}
+
+	return out
+}
+
+func toByteArray(a NibbleArray) nbt.ByteArray {
+	out := make(nbt.ByteArray, len(a))
+	for i, v := range a {
+		out[i] = int8(v)
+	}
+
+	return out
+}
@@ -0,0 +1,52 @@
+package chunk
+
+// bitArray packs fixed width entries into longs the way 1.15 does, with
+// an entry continuing into the next long when it does not fit.
+type bitArray struct {
+	bits uint
+	mask uint64
+	data []uint64
+}
+
+func newBitArray(bits uint, size int) bitArray {
+	return bitArray{
+		bits: bits,
+		mask: 1<<bits - 1,
+		data: make([]uint64, (size*int(bits)+63)/64),
+	}
+}
+
+func (a bitArray) get(i int) uint64 {
+	bit := uint(i) * a.bits
+	start, offset := bit/64, bit%64
+
+	v := a.data[start] >> offset
+	if offset+a.bits > 64 {
+		v |= a.data[start+1] << (64 - offset)
+	}
+
+	return v & a.mask
+}
+
+func (a bitArray) set(i int, v uint64) {
+	bit := uint(i) * a.bits
+	start, offset := bit/64, bit%64
+	v &= a.mask
+
+	a.data[start] = a.data[start]&^(a.mask<<offset) | v<<offset
+	if offset+a.bits > 64 {
+		rest := offset + a.bits - 64
+		a.data[start+1] = a.data[start+1]&^(1<<rest-1) | v>>(a.bits-rest)
+	}
+}
+
+// bitsFor returns the smallest width, at least min, that can index n
+// entries.
+func bitsFor(n int, min uint) uint {
+	bits := min
+	for 1<<bits < n {
+		bits++
+	}
+
+	return bits
+}
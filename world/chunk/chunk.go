@@ -0,0 +1,205 @@
+// Package chunk is the in-memory model of a 16x256x16 chunk column.
+package chunk
+
+import "github.com/skdltmxn/go-mine/util/nbt"
+
+const (
+	SectionCount = 16
+	Height       = SectionCount * 16
+
+	// LightSectionCount covers one section below and above the column,
+	// which the client needs to light the bottom and top faces.
+	LightSectionCount = SectionCount + 2
+
+	// biomes are stored per 4x4x4 cell since 1.15
+	BiomeCount = 4 * 4 * 64
+)
+
+// Heightmap holds, for each column, one more than the y of the highest
+// matching block, or 0 if there is none.
+type Heightmap [16 * 16]int16
+
+func (h *Heightmap) Get(x, z int) int {
+	return int(h[z<<4|x])
+}
+
+// pack encodes the heightmap as 9-bit entries.
+func (h *Heightmap) pack() []int64 {
+	a := newBitArray(9, len(h))
+	for i, v := range h {
+		a.set(i, uint64(v))
+	}
+
+	return toInt64s(a.data)
+}
+
+func (h *Heightmap) unpack(data []int64) bool {
+	if len(data) != (len(h)*9+63)/64 {
+		return false
+	}
+
+	a := bitArray{bits: 9, mask: 1<<9 - 1, data: toUint64s(data)}
+	for i := range h {
+		h[i] = int16(a.get(i))
+	}
+
+	return true
+}
+
+type Chunk struct {
+	X, Z int32
+
+	// nil sections are all air
+	Sections [SectionCount]*Section
+	Biomes   [BiomeCount]int32
+
+	// Both heightmaps track the highest non-air block. The vanilla
+	// MOTION_BLOCKING map also skips blocks without collision, which
+	// needs block properties the chunk does not know about.
+	MotionBlocking Heightmap
+	WorldSurface   Heightmap
+
+	// indexed by section y + 1; nil if not lit
+	BlockLight [LightSectionCount]NibbleArray
+	SkyLight   [LightSectionCount]NibbleArray
+
+	LastUpdate    int64
+	InhabitedTime int64
+
+	// Extra keeps the Level tags the model does not handle, such as
+	// Entities and TileEntities, so they survive a load and save.
+	Extra *nbt.Compound
+}
+
+func New(x, z int32) *Chunk {
+	return &Chunk{X: x, Z: z, Extra: nbt.NewCompound()}
+}
+
+// GetBlock returns the state at chunk relative x and z and absolute y.
+func (c *Chunk) GetBlock(x, y, z int) int32 {
+	if y < 0 || y >= Height {
+		return AirState
+	}
+
+	s := c.Sections[y>>4]
+	if s == nil {
+		return AirState
+	}
+
+	return s.Get(x, y&15, z)
+}
+
+func (c *Chunk) SetBlock(x, y, z int, state int32) {
+	if y < 0 || y >= Height {
+		return
+	}
+
+	s := c.Sections[y>>4]
+	if s == nil {
+		if isAir(state) {
+			return
+		}
+		s = NewSection()
+		c.Sections[y>>4] = s
+	}
+
+	s.Set(x, y&15, z, state)
+	if s.IsEmpty() {
+		c.Sections[y>>4] = nil
+	}
+
+	c.updateHeight(x, y, z, state)
+}
+
+func (c *Chunk) updateHeight(x, y, z int, state int32) {
+	i := z<<4 | x
+	height := int(c.MotionBlocking[i])
+
+	switch {
+	case !isAir(state) && y >= height:
+		height = y + 1
+	case isAir(state) && y == height-1:
+		for height > 0 && isAir(c.GetBlock(x, height-1, z)) {
+			height--
+		}
+	default:
+		return
+	}
+
+	c.MotionBlocking[i] = int16(height)
+	c.WorldSurface[i] = int16(height)
+}
+
+// UpdateHeightmaps recomputes both heightmaps from the blocks.
+func (c *Chunk) UpdateHeightmaps() {
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			height := Height
+			for height > 0 && isAir(c.GetBlock(x, height-1, z)) {
+				height--
+			}
+			c.MotionBlocking[z<<4|x] = int16(height)
+			c.WorldSurface[z<<4|x] = int16(height)
+		}
+	}
+}
+
+func biomeIndex(x, y, z int) int {
+	return (y>>2)<<4 | (z>>2)<<2 | x>>2
+}
+
+func (c *Chunk) Biome(x, y, z int) int32 {
+	if y < 0 {
+		y = 0
+	} else if y >= Height {
+		y = Height - 1
+	}
+
+	return c.Biomes[biomeIndex(x, y, z)]
+}
+
+// SetBiome sets the biome of the 4x4x4 cell containing the block.
+func (c *Chunk) SetBiome(x, y, z int, biome int32) {
+	if y < 0 || y >= Height {
+		return
+	}
+
+	c.Biomes[biomeIndex(x, y, z)] = biome
+}
+
+// FillBiome sets the biome of the whole column.
+func (c *Chunk) FillBiome(biome int32) {
+	for i := range c.Biomes {
+		c.Biomes[i] = biome
+	}
+}
+
+// SectionMask returns a bit mask of the non-empty sections.
+func (c *Chunk) SectionMask() int {
+	mask := 0
+	for y, s := range c.Sections {
+		if s != nil && !s.IsEmpty() {
+			mask |= 1 << uint(y)
+		}
+	}
+
+	return mask
+}
+
+func toInt64s(data []uint64) []int64 {
+	out := make([]int64, len(data))
+	for i, v := range data {
+		out[i] = int64(v)
+	}
+
+	return out
+}
+
+func toUint64s(data []int64) []uint64 {
+	out := make([]uint64, len(data))
+	for i, v := range data {
+		out[i] = uint64(v)
+	}
+
+	return out
+}
@@ -0,0 +1,126 @@
+package chunk
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testRegistry names every state "test:block" with its ID as a property.
+type testRegistry struct{}
+
+func (testRegistry) StateID(name string, props map[string]string) (int32, bool) {
+	if name == "minecraft:air" {
+		return AirState, true
+	}
+	if !strings.HasPrefix(name, "test:") {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(props["id"])
+	return int32(id), err == nil
+}
+
+func (testRegistry) State(id int32) (string, map[string]string, bool) {
+	if id == AirState {
+		return "minecraft:air", nil, true
+	}
+
+	return "test:block", map[string]string{"id": strconv.Itoa(int(id))}, true
+}
+
+func TestBitArray(t *testing.T) {
+	a := newBitArray(DirectBits, SectionSize)
+	for i := 0; i < SectionSize; i++ {
+		a.set(i, uint64(i*7))
+	}
+
+	for i := 0; i < SectionSize; i++ {
+		if v := a.get(i); v != uint64(i*7)&a.mask {
+			t.Fatalf("entry %d mismatch: %d", i, v)
+		}
+	}
+}
+
+func TestSectionPalette(t *testing.T) {
+	s := NewSection()
+
+	for i := 0; i < 300; i++ {
+		s.Set(i&15, i>>8, i>>4&15, int32(i+1))
+	}
+	if s.palette != nil || s.bits != DirectBits {
+		t.Fatalf("section did not switch to direct mode: %d bits", s.bits)
+	}
+
+	for i := 0; i < 300; i++ {
+		if v := s.Get(i&15, i>>8, i>>4&15); v != int32(i+1) {
+			t.Fatalf("block %d mismatch: %d", i, v)
+		}
+	}
+
+	for i := 0; i < 300; i++ {
+		s.Set(i&15, i>>8, i>>4&15, AirState)
+	}
+	if s.bits != minIndirectBits || !s.IsEmpty() {
+		t.Fatalf("section did not shrink: %d bits", s.bits)
+	}
+}
+
+func TestChunkHeightmap(t *testing.T) {
+	c := New(0, 0)
+	c.SetBlock(3, 70, 4, 1)
+	c.SetBlock(3, 10, 4, 1)
+
+	if h := c.MotionBlocking.Get(3, 4); h != 71 {
+		t.Fatalf("unexpected height: %d", h)
+	}
+
+	c.SetBlock(3, 70, 4, AirState)
+	if h := c.MotionBlocking.Get(3, 4); h != 11 {
+		t.Fatalf("unexpected height: %d", h)
+	}
+	if c.Sections[4] != nil {
+		t.Fatalf("empty section not removed")
+	}
+}
+
+func TestChunkNBT(t *testing.T) {
+	c := New(-3, 7)
+	for i := 0; i < 1000; i++ {
+		c.SetBlock(i&15, (i>>8)*60, i>>4&15, int32(i%400+1))
+	}
+	c.FillBiome(4)
+	c.SkyLight[0] = NewNibbleArray()
+	c.SkyLight[0].Set(1, 2, 3, 15)
+
+	root, err := c.ToNBT(testRegistry{})
+	if err != nil {
+		t.Fatalf("ToNBT failed: %s", err)
+	}
+
+	loaded, err := FromNBT(root, testRegistry{})
+	if err != nil {
+		t.Fatalf("FromNBT failed: %s", err)
+	}
+
+	if loaded.X != -3 || loaded.Z != 7 || loaded.Biome(0, 0, 0) != 4 {
+		t.Fatalf("chunk header mismatch")
+	}
+	for i := 0; i < 1000; i++ {
+		if v := loaded.GetBlock(i&15, (i>>8)*60, i>>4&15); v != int32(i%400+1) {
+			t.Fatalf("block %d mismatch: %d", i, v)
+		}
+	}
+	if loaded.MotionBlocking != c.MotionBlocking {
+		t.Fatalf("heightmap mismatch")
+	}
+	if loaded.SkyLight[0] == nil || loaded.SkyLight[0].Get(1, 2, 3) != 15 {
+		t.Fatalf("sky light mismatch")
+	}
+
+	p, err := loaded.DataPacket()
+	if err != nil {
+		t.Fatalf("DataPacket failed: %s", err)
+	}
+	t.Logf("chunk data packet: %d bytes", len(p.Data()))
+}
@@ -0,0 +1,20 @@
+package chunk
+
+// NibbleArray holds 4-bit light levels for a section, two per byte with
+// the even index in the low half.
+type NibbleArray []byte
+
+func NewNibbleArray() NibbleArray {
+	return make(NibbleArray, SectionSize/2)
+}
+
+func (a NibbleArray) Get(x, y, z int) byte {
+	i := blockIndex(x, y, z)
+	return a[i>>1] >> (uint(i&1) * 4) & 0x0f
+}
+
+func (a NibbleArray) Set(x, y, z int, v byte) {
+	i := blockIndex(x, y, z)
+	shift := uint(i&1) * 4
+	a[i>>1] = a[i>>1]&^(0x0f<<shift) | (v&0x0f)<<shift
+}
@@ -0,0 +1,109 @@
+package chunk
+
+import (
+	"github.com/skdltmxn/go-mine/net/packet"
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+// DataPacket encodes the chunk as a full Chunk Data packet.
+func (c *Chunk) DataPacket() (*packet.Packet, error) {
+	p := packet.NewPacket(0x22)
+	w := packet.NewWriter(p)
+
+	mask := c.SectionMask()
+
+	w.WriteInt(c.X)
+	w.WriteInt(c.Z)
+	w.WriteBool(true) // full chunk
+	w.WriteVarint(mask)
+
+	heightmaps := nbt.NewCompound()
+	heightmaps.Set("MOTION_BLOCKING", nbt.LongArray(c.MotionBlocking.pack()))
+	heightmaps.Set("WORLD_SURFACE", nbt.LongArray(c.WorldSurface.pack()))
+	if err := nbt.WriteTag(w, "", heightmaps); err != nil {
+		return nil, err
+	}
+
+	for _, biome := range c.Biomes {
+		w.WriteInt(biome)
+	}
+
+	data := packet.NewWriter(packet.NewPacket(0))
+	for y, s := range c.Sections {
+		if mask&(1<<uint(y)) != 0 {
+			s.writeTo(data)
+		}
+	}
+	w.WriteVarint(data.Len())
+	w.Write(data.Bytes())
+
+	// block entities
+	w.WriteVarint(0)
+
+	return p, nil
+}
+
+func (s *Section) writeTo(w *packet.Writer) {
+	w.WriteShort(int16(s.BlockCount()))
+
+	palette, data := s.compact(minIndirectBits)
+	if data.bits > maxIndirectBits {
+		palette = nil
+		data = newBitArray(DirectBits, SectionSize)
+		for i := 0; i < SectionSize; i++ {
+			data.set(i, uint64(s.get(i)))
+		}
+	}
+
+	w.WriteUbyte(uint8(data.bits))
+	if palette != nil {
+		w.WriteVarint(len(palette))
+		for _, state := range palette {
+			w.WriteVarint(int(state))
+		}
+	}
+
+	w.WriteVarint(len(data.data))
+	for _, v := range data.data {
+		w.WriteLong(int64(v))
+	}
+}
+
+// LightPacket encodes the light of the chunk as an Update Light packet.
+// Sections without light data are left to the client.
+func (c *Chunk) LightPacket() *packet.Packet {
+	p := packet.NewPacket(0x25)
+	w := packet.NewWriter(p)
+
+	skyMask, blockMask := 0, 0
+	for i := 0; i < LightSectionCount; i++ {
+		if c.SkyLight[i] != nil {
+			skyMask |= 1 << uint(i)
+		}
+		if c.BlockLight[i] != nil {
+			blockMask |= 1 << uint(i)
+		}
+	}
+
+	w.WriteVarint(int(c.X))
+	w.WriteVarint(int(c.Z))
+	w.WriteVarint(skyMask)
+	w.WriteVarint(blockMask)
+	w.WriteVarint(0) // empty sky light mask
+	w.WriteVarint(0) // empty block light mask
+
+	for _, light := range c.SkyLight {
+		if light != nil {
+			w.WriteVarint(len(light))
+			w.Write(light)
+		}
+	}
+	for _, light := range c.BlockLight {
+		if light != nil {
+			w.WriteVarint(len(light))
+			w.Write(light)
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,226 @@
+package chunk
+
+import "sort"
+
+const (
+	SectionSize = 16 * 16 * 16
+
+	minIndirectBits = 4
+	maxIndirectBits = 8
+
+	// DirectBits is the width of a global block state ID in 1.15.2.
+	DirectBits = 14
+)
+
+// Block states of the air blocks in 1.15.2. They do not count towards the
+// number of blocks in a section.
+const (
+	AirState     int32 = 0
+	VoidAirState int32 = 9669
+	CaveAirState int32 = 9670
+)
+
+func isAir(state int32) bool {
+	return state == AirState || state == VoidAirState || state == CaveAirState
+}
+
+// Section is a 16x16x16 cube of block states. Up to 256 distinct states
+// are stored as indices into a palette, more than that as global IDs.
+type Section struct {
+	bits    uint
+	palette []int32 // nil in direct mode
+	index   map[int32]int
+	free    []int // unused palette slots
+	counts  map[int32]int
+	data    bitArray
+}
+
+// NewSection returns a section filled with air.
+func NewSection() *Section {
+	return &Section{
+		bits:    minIndirectBits,
+		palette: []int32{AirState},
+		index:   map[int32]int{AirState: 0},
+		counts:  map[int32]int{AirState: SectionSize},
+		data:    newBitArray(minIndirectBits, SectionSize),
+	}
+}
+
+func blockIndex(x, y, z int) int {
+	return y<<8 | z<<4 | x
+}
+
+func (s *Section) get(i int) int32 {
+	v := s.data.get(i)
+	if s.palette == nil {
+		return int32(v)
+	}
+
+	return s.palette[v]
+}
+
+// Get returns the state at section relative coordinates.
+func (s *Section) Get(x, y, z int) int32 {
+	return s.get(blockIndex(x, y, z))
+}
+
+func (s *Section) Set(x, y, z int, state int32) {
+	i := blockIndex(x, y, z)
+	old := s.get(i)
+	if old == state {
+		return
+	}
+
+	s.counts[state]++
+	if s.palette != nil {
+		if _, ok := s.index[state]; !ok && !s.addToPalette(state) {
+			s.repack(bitsFor(len(s.counts), minIndirectBits))
+		}
+	}
+
+	if s.palette == nil {
+		s.data.set(i, uint64(state))
+	} else {
+		s.data.set(i, uint64(s.index[state]))
+	}
+
+	if s.counts[old]--; s.counts[old] == 0 {
+		delete(s.counts, old)
+		if s.palette != nil {
+			s.free = append(s.free, s.index[old])
+			delete(s.index, old)
+		}
+		s.shrink()
+	}
+}
+
+func (s *Section) addToPalette(state int32) bool {
+	switch {
+	case len(s.free) > 0:
+		slot := s.free[len(s.free)-1]
+		s.free = s.free[:len(s.free)-1]
+		s.palette[slot] = state
+		s.index[state] = slot
+	case len(s.palette) < 1<<s.bits:
+		s.index[state] = len(s.palette)
+		s.palette = append(s.palette, state)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// shrink repacks the section with fewer bits once the states in use fit
+// in a quarter of the palette, so that a block toggled back and forth
+// does not repack every time.
+func (s *Section) shrink() {
+	n := len(s.counts)
+	switch {
+	case s.palette == nil && n <= 1<<(maxIndirectBits-1):
+		s.repack(bitsFor(n, minIndirectBits))
+	case s.palette != nil && s.bits > minIndirectBits && n <= 1<<(s.bits-2):
+		s.repack(bitsFor(n, minIndirectBits))
+	}
+}
+
+// repack rebuilds the palette from the states in use with the given
+// width, switching to direct mode if it is too wide.
+func (s *Section) repack(bits uint) {
+	var palette []int32
+	var index map[int32]int
+
+	if bits > maxIndirectBits {
+		bits = DirectBits
+	} else {
+		palette = s.states()
+		index = make(map[int32]int, len(palette))
+		for i, state := range palette {
+			index[state] = i
+		}
+	}
+
+	data := newBitArray(bits, SectionSize)
+	for i := 0; i < SectionSize; i++ {
+		state := s.get(i)
+		if palette == nil {
+			data.set(i, uint64(state))
+		} else {
+			data.set(i, uint64(index[state]))
+		}
+	}
+
+	s.bits = bits
+	s.palette = palette
+	s.index = index
+	s.free = nil
+	s.data = data
+}
+
+// states returns the states in use in ascending order.
+func (s *Section) states() []int32 {
+	states := make([]int32, 0, len(s.counts))
+	for state := range s.counts {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+
+	return states
+}
+
+// BlockCount returns the number of non-air blocks.
+func (s *Section) BlockCount() int {
+	n := SectionSize
+	for state, count := range s.counts {
+		if isAir(state) {
+			n -= count
+		}
+	}
+
+	return n
+}
+
+func (s *Section) IsEmpty() bool {
+	return s.BlockCount() == 0
+}
+
+// compact returns the section packed with a palette holding only the
+// states in use, at least minBits wide. Unlike the in-memory layout the
+// width is not capped, as Anvil files never use global IDs.
+func (s *Section) compact(minBits uint) ([]int32, bitArray) {
+	palette := s.states()
+	index := make(map[int32]int, len(palette))
+	for i, state := range palette {
+		index[state] = i
+	}
+
+	data := newBitArray(bitsFor(len(palette), minBits), SectionSize)
+	for i := 0; i < SectionSize; i++ {
+		data.set(i, uint64(index[s.get(i)]))
+	}
+
+	return palette, data
+}
+
+// newSectionFrom builds a section from a palette and its packed indices.
+func newSectionFrom(palette []int32, data []uint64) *Section {
+	bits := uint(len(data) * 64 / SectionSize)
+	src := bitArray{bits: bits, mask: 1<<bits - 1, data: data}
+
+	s := &Section{
+		bits:   DirectBits,
+		counts: make(map[int32]int),
+		data:   newBitArray(DirectBits, SectionSize),
+	}
+	for i := 0; i < SectionSize; i++ {
+		state := AirState
+		if v := src.get(i); v < uint64(len(palette)) {
+			state = palette[v]
+		}
+		s.data.set(i, uint64(state))
+		s.counts[state]++
+	}
+	s.repack(bitsFor(len(s.counts), minIndirectBits))
+
+	return s
+}
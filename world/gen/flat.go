@@ -0,0 +1,93 @@
+package gen
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/go-mine/world/block"
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+// DefaultFlatLayers is the layer string of the vanilla Classic Flat preset.
+const DefaultFlatLayers = "minecraft:bedrock,2*minecraft:dirt,minecraft:grass_block;minecraft:plains"
+
+type layer struct {
+	state int32
+	count int
+}
+
+// Flat generates a superflat world made of the same layers everywhere.
+type Flat struct {
+	layers []layer
+	biome  int32
+}
+
+// NewFlat parses a layer string as used by the vanilla superflat presets,
+// such as "minecraft:bedrock,2*minecraft:dirt,minecraft:grass_block;minecraft:plains".
+// Layers are listed bottom up and may give block properties, as in
+// "minecraft:grass_block[snowy=true]". Anything after the biome, such as
+// structures, is ignored.
+func NewFlat(layers string) (*Flat, error) {
+	parts := strings.Split(layers, ";")
+
+	f := &Flat{biome: biomeId("minecraft:plains")}
+	height := 0
+	for _, l := range strings.Split(parts[0], ",") {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+
+		count := 1
+		if star := strings.IndexByte(l, '*'); star >= 0 {
+			n, err := strconv.Atoi(l[:star])
+			if err != nil || n < 1 {
+				return nil, errors.New("gen: invalid layer count in " + strconv.Quote(l))
+			}
+			count, l = n, l[star+1:]
+		}
+
+		state, err := block.Parse(l)
+		if err != nil {
+			return nil, err
+		}
+
+		height += count
+		if height > chunk.Height {
+			return nil, errors.New("gen: layers are higher than the world")
+		}
+		f.layers = append(f.layers, layer{state, count})
+	}
+
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		biome, ok := block.Biomes.ID(strings.TrimSpace(parts[1]))
+		if !ok {
+			return nil, errors.New("gen: unknown biome " + strconv.Quote(parts[1]))
+		}
+		f.biome = biome
+	}
+
+	return f, nil
+}
+
+func (f *Flat) Generate(chunkX, chunkZ int32) *chunk.Chunk {
+	c := chunk.New(chunkX, chunkZ)
+	c.FillBiome(f.biome)
+
+	y := 0
+	for _, l := range f.layers {
+		for i := 0; i < l.count; i, y = i+1, y+1 {
+			if l.state == chunk.AirState {
+				continue
+			}
+			for z := 0; z < 16; z++ {
+				for x := 0; x < 16; x++ {
+					c.SetBlock(x, y, z, l.state)
+				}
+			}
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,31 @@
+package gen
+
+import "testing"
+
+func TestFlatLayers(t *testing.T) {
+	f, err := NewFlat(DefaultFlatLayers)
+	if err != nil {
+		t.Fatalf("NewFlat failed: %s", err)
+	}
+
+	c := f.Generate(-2, 5)
+	expected := []int32{33, 10, 10, 9, 0}
+	for y, state := range expected {
+		if v := c.GetBlock(7, y, 7); v != state {
+			t.Fatalf("unexpected block at y=%d: %d", y, v)
+		}
+	}
+
+	if h := c.MotionBlocking.Get(0, 0); h != 4 {
+		t.Fatalf("unexpected height: %d", h)
+	}
+	if b := c.Biome(0, 0, 0); b != 1 {
+		t.Fatalf("unexpected biome: %d", b)
+	}
+
+	for _, layers := range []string{"minecraft:unknown", "0*stone", "300*stone", "stone;nowhere", "grass_block[snowy=maybe]"} {
+		if _, err := NewFlat(layers); err == nil {
+			t.Fatalf("NewFlat accepted %q", layers)
+		}
+	}
+}
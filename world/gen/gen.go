@@ -0,0 +1,31 @@
+// Package gen generates the chunks of new worlds.
+package gen
+
+import (
+	"github.com/skdltmxn/go-mine/world/block"
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+type Generator interface {
+	Generate(chunkX, chunkZ int32) *chunk.Chunk
+}
+
+// defaultState returns the default state of a block the generators rely
+// on, so a missing one is a bug.
+func defaultState(name string) int32 {
+	b := block.ByName(name)
+	if b == nil {
+		panic("gen: unknown block " + name)
+	}
+
+	return b.DefaultState
+}
+
+func biomeId(name string) int32 {
+	id, ok := block.Biomes.ID(name)
+	if !ok {
+		panic("gen: unknown biome " + name)
+	}
+
+	return id
+}
@@ -0,0 +1,318 @@
+package gen
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/skdltmxn/go-mine/world/block"
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+const (
+	SeaLevel = 63
+
+	baseHeight = 66
+)
+
+// biome describes how a biome shapes and decorates the terrain.
+type biome struct {
+	id         int32
+	surface    int32
+	subsurface int32
+	amplitude  float64 // of the height noise, in blocks
+	trees      int     // attempts per chunk
+	log        int32
+	leaves     int32
+}
+
+var (
+	biomeOcean = &biome{id: biomeId("minecraft:ocean"), amplitude: 8}
+	biomeBeach = &biome{id: biomeId("minecraft:beach"), amplitude: 4}
+
+	biomePlains = &biome{id: biomeId("minecraft:plains"), amplitude: 6, trees: 1}
+	biomeForest = &biome{id: biomeId("minecraft:forest"), amplitude: 10, trees: 8}
+	biomeBirch  = &biome{id: biomeId("minecraft:birch_forest"), amplitude: 10, trees: 8}
+	biomeDesert = &biome{id: biomeId("minecraft:desert"), amplitude: 5}
+	biomeTaiga  = &biome{id: biomeId("minecraft:taiga"), amplitude: 12, trees: 6}
+	biomeTundra = &biome{id: biomeId("minecraft:snowy_tundra"), amplitude: 5, trees: 1}
+	biomeMounts = &biome{id: biomeId("minecraft:mountains"), amplitude: 40, trees: 1}
+)
+
+func init() {
+	grass, dirt, sand := defaultState("minecraft:grass_block"), defaultState("minecraft:dirt"), defaultState("minecraft:sand")
+
+	for _, b := range []*biome{biomePlains, biomeForest, biomeBirch, biomeMounts} {
+		b.surface, b.subsurface = grass, dirt
+	}
+	biomeTaiga.surface, biomeTaiga.subsurface = defaultState("minecraft:podzol"), dirt
+	snowyGrass, _ := block.StateID("minecraft:grass_block", map[string]string{"snowy": "true"})
+	biomeTundra.surface, biomeTundra.subsurface = snowyGrass, dirt
+	biomeDesert.surface, biomeDesert.subsurface = sand, defaultState("minecraft:sandstone")
+	biomeBeach.surface, biomeBeach.subsurface = sand, sand
+	biomeOcean.surface, biomeOcean.subsurface = defaultState("minecraft:gravel"), dirt
+
+	for _, b := range []*biome{biomePlains, biomeForest, biomeMounts} {
+		b.log, b.leaves = defaultState("minecraft:oak_log"), defaultState("minecraft:oak_leaves")
+	}
+	biomeBirch.log, biomeBirch.leaves = defaultState("minecraft:birch_log"), defaultState("minecraft:birch_leaves")
+	for _, b := range []*biome{biomeTaiga, biomeTundra} {
+		b.log, b.leaves = defaultState("minecraft:spruce_log"), defaultState("minecraft:spruce_leaves")
+	}
+}
+
+type ore struct {
+	state    int32
+	attempts int
+	size     int
+	maxY     int
+}
+
+var ores []ore
+
+func init() {
+	ores = []ore{
+		{defaultState("minecraft:coal_ore"), 20, 12, 128},
+		{defaultState("minecraft:iron_ore"), 20, 8, 64},
+		{defaultState("minecraft:gold_ore"), 2, 8, 32},
+		{defaultState("minecraft:gravel"), 8, 20, 128},
+		{defaultState("minecraft:granite"), 10, 24, 80},
+		{defaultState("minecraft:diorite"), 10, 24, 80},
+		{defaultState("minecraft:andesite"), 10, 24, 80},
+	}
+}
+
+// Noise generates hilly terrain from octave Perlin noise. It is not the
+// vanilla algorithm, but the same seed always gives the same chunks no
+// matter in which order they are generated.
+type Noise struct {
+	seed        int64
+	height      octaves
+	roughness   octaves
+	temperature octaves
+	humidity    octaves
+	caves       octaves
+}
+
+func NewNoise(seed int64) *Noise {
+	r := rand.New(rand.NewSource(seed))
+
+	return &Noise{
+		seed:        seed,
+		height:      newOctaves(r, 6),
+		roughness:   newOctaves(r, 2),
+		temperature: newOctaves(r, 2),
+		humidity:    newOctaves(r, 2),
+		caves:       newOctaves(r, 3),
+	}
+}
+
+// chunkRand returns a random source that only depends on the seed and the
+// chunk position.
+func (n *Noise) chunkRand(chunkX, chunkZ int32, salt int64) *rand.Rand {
+	return rand.New(rand.NewSource(n.seed ^ int64(chunkX)*341873128712 ^ int64(chunkZ)*132897987541 ^ salt))
+}
+
+// column returns the biome and the surface height at a block column.
+func (n *Noise) column(x, z int) (*biome, int) {
+	fx, fz := float64(x), float64(z)
+
+	rough := (n.roughness.noise2(fx/400, fz/400) + 1) / 2
+	base := n.height.noise2(fx/256, fz/256)
+
+	// continents: low noise sinks below sea level
+	height := baseHeight + int(base*24+base*rough*rough*48)
+
+	temp := n.temperature.noise2(fx/600, fz/600)
+	humid := n.humidity.noise2(fx/500, fz/500)
+
+	var b *biome
+	switch {
+	case height < SeaLevel-1:
+		b = biomeOcean
+	case height <= SeaLevel+1:
+		b = biomeBeach
+	case rough > 0.7:
+		b = biomeMounts
+	case temp < -0.3:
+		b = biomeTundra
+	case temp < -0.1:
+		b = biomeTaiga
+	case temp > 0.3 && humid < 0:
+		b = biomeDesert
+	case humid > 0.25:
+		b = biomeBirch
+	case humid > 0:
+		b = biomeForest
+	default:
+		b = biomePlains
+	}
+
+	// smaller bumps on top scaled by the biome
+	detail := n.height[len(n.height)-1].noise2(fx/24, fz/24)
+	height += int(detail * b.amplitude / 4)
+
+	if height < 1 {
+		height = 1
+	} else if height > chunk.Height-16 {
+		height = chunk.Height - 16
+	}
+
+	return b, height
+}
+
+func (n *Noise) Generate(chunkX, chunkZ int32) *chunk.Chunk {
+	c := chunk.New(chunkX, chunkZ)
+	r := n.chunkRand(chunkX, chunkZ, 0)
+
+	var biomes [16][16]*biome
+	var heights [16][16]int
+
+	stone := defaultState("minecraft:stone")
+	water := defaultState("minecraft:water")
+	bedrock := defaultState("minecraft:bedrock")
+
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			wx, wz := int(chunkX)*16+x, int(chunkZ)*16+z
+			b, height := n.column(wx, wz)
+			biomes[x][z], heights[x][z] = b, height
+
+			floor := r.Intn(4)
+			for y := 0; y < height; y++ {
+				state := stone
+				switch {
+				case y <= floor:
+					state = bedrock
+				case y == height-1:
+					state = b.surface
+					if height < SeaLevel && b != biomeBeach {
+						state = b.subsurface
+					}
+				case y >= height-4:
+					state = b.subsurface
+				}
+				c.SetBlock(x, y, z, state)
+			}
+			for y := height; y < SeaLevel; y++ {
+				c.SetBlock(x, y, z, water)
+			}
+		}
+	}
+
+	n.carveCaves(c, &heights)
+	n.placeOres(c)
+	n.placeTrees(c, &biomes, &heights)
+
+	for z := 0; z < 16; z += 4 {
+		for x := 0; x < 16; x += 4 {
+			id := biomes[x+2][z+2].id
+			for y := 0; y < chunk.Height; y += 4 {
+				c.SetBiome(x, y, z, id)
+			}
+		}
+	}
+
+	return c
+}
+
+// carveCaves hollows out the stone where 3D noise is close to zero, which
+// gives long winding tunnels. Caves stay clear of the surface so they do
+// not open into the ocean.
+func (n *Noise) carveCaves(c *chunk.Chunk, heights *[16][16]int) {
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			wx, wz := float64(int(c.X)*16+x), float64(int(c.Z)*16+z)
+			top := heights[x][z] - 6
+			for y := 6; y < top; y++ {
+				v := n.caves.noise3(wx/48, float64(y)/24, wz/48)
+				if math.Abs(v) < 0.04 {
+					c.SetBlock(x, y, z, chunk.CaveAirState)
+				}
+			}
+		}
+	}
+}
+
+// placeOres scatters small blobs of ore into the stone of the chunk.
+func (n *Noise) placeOres(c *chunk.Chunk) {
+	r := n.chunkRand(c.X, c.Z, 1)
+	stone := defaultState("minecraft:stone")
+
+	for _, o := range ores {
+		for i := 0; i < o.attempts; i++ {
+			x, y, z := r.Intn(16), r.Intn(o.maxY), r.Intn(16)
+			for j := 0; j < o.size; j++ {
+				if x >= 0 && x < 16 && z >= 0 && z < 16 && c.GetBlock(x, y, z) == stone {
+					c.SetBlock(x, y, z, o.state)
+				}
+				switch r.Intn(3) {
+				case 0:
+					x += r.Intn(3) - 1
+				case 1:
+					y += r.Intn(3) - 1
+				default:
+					z += r.Intn(3) - 1
+				}
+			}
+		}
+	}
+}
+
+// placeTrees grows trees on grass away from the chunk edges, so that a
+// tree never needs to write into a neighbor chunk.
+func (n *Noise) placeTrees(c *chunk.Chunk, biomes *[16][16]*biome, heights *[16][16]int) {
+	r := n.chunkRand(c.X, c.Z, 2)
+
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			b := biomes[x][z]
+			if b.trees == 0 {
+				continue
+			}
+			// on average b.trees attempts over the 144 interior columns
+			if r.Intn(144) >= b.trees || x < 2 || x > 13 || z < 2 || z > 13 {
+				continue
+			}
+
+			y := heights[x][z]
+			ground := c.GetBlock(x, y-1, z)
+			if y <= SeaLevel || (ground != b.surface && ground != b.subsurface) {
+				continue
+			}
+
+			n.growTree(c, r, x, y, z, b)
+		}
+	}
+}
+
+func (n *Noise) growTree(c *chunk.Chunk, r *rand.Rand, x, y, z int, b *biome) {
+	trunk := 4 + r.Intn(3)
+	if y+trunk+2 >= chunk.Height {
+		return
+	}
+
+	c.SetBlock(x, y-1, z, defaultState("minecraft:dirt"))
+
+	top := y + trunk
+	for ly := top - 3; ly <= top; ly++ {
+		radius := 2
+		if ly >= top-1 {
+			radius = 1
+		}
+		for dz := -radius; dz <= radius; dz++ {
+			for dx := -radius; dx <= radius; dx++ {
+				// round the corners off at random
+				if (dx == -radius || dx == radius) && (dz == -radius || dz == radius) && r.Intn(2) == 0 {
+					continue
+				}
+				if c.GetBlock(x+dx, ly, z+dz) == chunk.AirState {
+					c.SetBlock(x+dx, ly, z+dz, b.leaves)
+				}
+			}
+		}
+	}
+
+	for ly := y; ly < top; ly++ {
+		c.SetBlock(x, ly, z, b.log)
+	}
+}
@@ -0,0 +1,47 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+func sameBlocks(a, b *chunk.Chunk) bool {
+	for y := 0; y < chunk.Height; y++ {
+		for z := 0; z < 16; z++ {
+			for x := 0; x < 16; x++ {
+				if a.GetBlock(x, y, z) != b.GetBlock(x, y, z) {
+					return false
+				}
+			}
+		}
+	}
+
+	return a.Biomes == b.Biomes
+}
+
+func TestNoiseDeterministic(t *testing.T) {
+	first := NewNoise(1234)
+	second := NewNoise(1234)
+
+	// generate in a different order to make sure chunks do not depend on
+	// each other
+	a := []*chunk.Chunk{first.Generate(0, 0), first.Generate(-5, 3)}
+	b := []*chunk.Chunk{second.Generate(-5, 3), second.Generate(0, 0)}
+
+	if !sameBlocks(a[0], b[1]) || !sameBlocks(a[1], b[0]) {
+		t.Fatalf("same seed generated different chunks")
+	}
+
+	if sameBlocks(a[0], NewNoise(4321).Generate(0, 0)) {
+		t.Fatalf("different seeds generated the same chunk")
+	}
+
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			if a[0].GetBlock(x, 0, z) != defaultState("minecraft:bedrock") {
+				t.Fatalf("no bedrock at %d, %d", x, z)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gen
+
+import (
+	"math"
+	"math/rand"
+)
+
+// perlin is Ken Perlin's improved noise with a seeded permutation.
+type perlin struct {
+	perm [512]int
+	// offsets decorrelate the octaves sharing the origin
+	ox, oy, oz float64
+}
+
+func newPerlin(r *rand.Rand) *perlin {
+	p := &perlin{
+		ox: r.Float64() * 256,
+		oy: r.Float64() * 256,
+		oz: r.Float64() * 256,
+	}
+
+	for i := 0; i < 256; i++ {
+		p.perm[i] = i
+	}
+	for i := 255; i > 0; i-- {
+		j := r.Intn(i + 1)
+		p.perm[i], p.perm[j] = p.perm[j], p.perm[i]
+	}
+	copy(p.perm[256:], p.perm[:256])
+
+	return p
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+func grad(hash int, x, y, z float64) float64 {
+	h := hash & 15
+	u, v := y, z
+	if h < 8 {
+		u = x
+	}
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+
+	return u + v
+}
+
+// noise3 returns a value in about [-1, 1].
+func (p *perlin) noise3(x, y, z float64) float64 {
+	x, y, z = x+p.ox, y+p.oy, z+p.oz
+
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	X, Y, Z := int(fx)&255, int(fy)&255, int(fz)&255
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	a := p.perm[X] + Y
+	aa, ab := p.perm[a]+Z, p.perm[a+1]+Z
+	b := p.perm[X+1] + Y
+	ba, bb := p.perm[b]+Z, p.perm[b+1]+Z
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(p.perm[aa], x, y, z), grad(p.perm[ba], x-1, y, z)),
+			lerp(u, grad(p.perm[ab], x, y-1, z), grad(p.perm[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(p.perm[aa+1], x, y, z-1), grad(p.perm[ba+1], x-1, y, z-1)),
+			lerp(u, grad(p.perm[ab+1], x, y-1, z-1), grad(p.perm[bb+1], x-1, y-1, z-1))))
+}
+
+func (p *perlin) noise2(x, z float64) float64 {
+	return p.noise3(x, 0, z)
+}
+
+// octaves sums layers of noise, each at twice the frequency and half the
+// amplitude of the previous one.
+type octaves []*perlin
+
+func newOctaves(r *rand.Rand, n int) octaves {
+	o := make(octaves, n)
+	for i := range o {
+		o[i] = newPerlin(r)
+	}
+
+	return o
+}
+
+// noise2 returns a value in about [-1, 1].
+func (o octaves) noise2(x, z float64) float64 {
+	sum, amp, freq, total := 0.0, 1.0, 1.0, 0.0
+	for _, p := range o {
+		sum += p.noise2(x*freq, z*freq) * amp
+		total += amp
+		amp /= 2
+		freq *= 2
+	}
+
+	return sum / total
+}
+
+func (o octaves) noise3(x, y, z float64) float64 {
+	sum, amp, freq, total := 0.0, 1.0, 1.0, 0.0
+	for _, p := range o {
+		sum += p.noise3(x*freq, y*freq, z*freq) * amp
+		total += amp
+		amp /= 2
+		freq *= 2
+	}
+
+	return sum / total
+}
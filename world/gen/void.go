@@ -0,0 +1,37 @@
+package gen
+
+import "github.com/skdltmxn/go-mine/world/chunk"
+
+const (
+	platformY      = 63
+	platformRadius = 2
+)
+
+// Void generates an empty world with a small stone platform at the
+// origin to spawn on.
+type Void struct{}
+
+func NewVoid() *Void {
+	return &Void{}
+}
+
+func (v *Void) Generate(chunkX, chunkZ int32) *chunk.Chunk {
+	c := chunk.New(chunkX, chunkZ)
+	c.FillBiome(biomeId("minecraft:the_void"))
+
+	for z := -platformRadius; z <= platformRadius; z++ {
+		for x := -platformRadius; x <= platformRadius; x++ {
+			if int32(x>>4) != chunkX || int32(z>>4) != chunkZ {
+				continue
+			}
+
+			state := defaultState("minecraft:stone")
+			if x == 0 && z == 0 {
+				state = defaultState("minecraft:cobblestone")
+			}
+			c.SetBlock(x&15, platformY, z&15, state)
+		}
+	}
+
+	return c
+}
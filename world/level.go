@@ -0,0 +1,292 @@
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+	"github.com/skdltmxn/go-mine/world/chunk"
+	"github.com/skdltmxn/go-mine/world/gen"
+)
+
+// game rules of a new world; all values are strings in level.dat
+var defaultGameRules = [][2]string{
+	{"doDaylightCycle", "true"},
+	{"doMobSpawning", "true"},
+	{"doWeatherCycle", "true"},
+	{"keepInventory", "false"},
+	{"naturalRegeneration", "true"},
+	{"randomTickSpeed", "3"},
+	{"spawnRadius", "10"},
+}
+
+// level is the content of level.dat. Tags the server does not use are
+// kept so that the file survives a load and save.
+type level struct {
+	root *nbt.Compound
+	data *nbt.Compound
+}
+
+func newLevel(settings Settings) (*level, error) {
+	data := nbt.NewCompound()
+	data.Set("LevelName", nbt.String(settings.Name))
+	data.Set("RandomSeed", nbt.Long(settings.Seed))
+	data.Set("DataVersion", nbt.Int(chunk.DataVersion))
+	data.Set("version", nbt.Int(19133))
+	data.Set("initialized", nbt.Byte(1))
+	data.Set("Time", nbt.Long(0))
+	data.Set("DayTime", nbt.Long(0))
+
+	version := nbt.NewCompound()
+	version.Set("Id", nbt.Int(chunk.DataVersion))
+	version.Set("Name", nbt.String("1.15.2"))
+	version.Set("Snapshot", nbt.Byte(0))
+	data.Set("Version", version)
+
+	rules := nbt.NewCompound()
+	for _, rule := range defaultGameRules {
+		rules.Set(rule[0], nbt.String(rule[1]))
+	}
+	data.Set("GameRules", rules)
+
+	switch settings.Generator {
+	case "", "default":
+		data.Set("generatorName", nbt.String("default"))
+	case "flat":
+		options, err := flatOptions(settings.Layers)
+		if err != nil {
+			return nil, err
+		}
+		data.Set("generatorName", nbt.String("flat"))
+		data.Set("generatorOptions", options)
+	case "void":
+		options, _ := flatOptions(voidLayers)
+		data.Set("generatorName", nbt.String("flat"))
+		data.Set("generatorOptions", options)
+	default:
+		return nil, errors.New("world: unknown generator " + strconv.Quote(settings.Generator))
+	}
+
+	root := nbt.NewCompound()
+	root.Set("Data", data)
+
+	return &level{root, data}, nil
+}
+
+func loadLevel(path string) (*level, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	_, t, err := nbt.ReadTag(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := t.(*nbt.Compound)
+	if !ok {
+		return nil, errors.New("world: level.dat root is not a compound")
+	}
+	data, ok := root.Get("Data").(*nbt.Compound)
+	if !ok {
+		return nil, errors.New("world: level.dat has no Data compound")
+	}
+
+	return &level{root, data}, nil
+}
+
+// encode returns the gzipped level.dat, so that it can be written without
+// holding the world lock.
+func (l *level) encode() ([]byte, error) {
+	l.data.Set("LastPlayed", nbt.Long(time.Now().UnixNano()/int64(time.Millisecond)))
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := nbt.WriteTag(zw, "", l.root); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l *level) save(path string) error {
+	data, err := l.encode()
+	if err != nil {
+		return err
+	}
+
+	return writeLevel(path, data)
+}
+
+// writeLevel writes level.dat through a temporary file and keeps the
+// previous version as level.dat_old like the vanilla server.
+func writeLevel(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "level.dat")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+"_old"); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *level) long(name string) int64 {
+	switch v := l.data.Get(name).(type) {
+	case nbt.Long:
+		return int64(v)
+	case nbt.Int:
+		return int64(v)
+	}
+
+	return 0
+}
+
+func (l *level) int(name string) int32 {
+	v, _ := l.data.Get(name).(nbt.Int)
+	return int32(v)
+}
+
+func (l *level) int8(name string) int8 {
+	v, _ := l.data.Get(name).(nbt.Byte)
+	return int8(v)
+}
+
+func (l *level) string(name string) string {
+	v, _ := l.data.Get(name).(nbt.String)
+	return string(v)
+}
+
+func (l *level) gameRules() *nbt.Compound {
+	rules, ok := l.data.Get("GameRules").(*nbt.Compound)
+	if !ok {
+		rules = nbt.NewCompound()
+		l.data.Set("GameRules", rules)
+	}
+
+	return rules
+}
+
+// generator returns the generator of the world and the level type sent to
+// clients.
+func (l *level) generator() (gen.Generator, string, error) {
+	seed := l.long("RandomSeed")
+
+	switch name := l.string("generatorName"); name {
+	case "", "default":
+		return gen.NewNoise(seed), "default", nil
+	case "flat":
+		layers := gen.DefaultFlatLayers
+		if options, ok := l.data.Get("generatorOptions").(*nbt.Compound); ok {
+			layers = flatLayers(options)
+		}
+		if layers == voidLayers {
+			return gen.NewVoid(), "flat", nil
+		}
+
+		f, err := gen.NewFlat(layers)
+		return f, "flat", err
+	default:
+		return nil, "", errors.New("world: unsupported generator " + strconv.Quote(name))
+	}
+}
+
+// voidLayers is how the vanilla void preset is stored.
+const voidLayers = "minecraft:air;minecraft:the_void"
+
+// flatOptions converts a layer string into the generatorOptions compound
+// of a flat world.
+func flatOptions(layers string) (*nbt.Compound, error) {
+	// validate with the generator
+	if _, err := gen.NewFlat(layers); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(layers, ";")
+
+	list, _ := nbt.NewList(nbt.TagCompound)
+	for _, l := range strings.Split(parts[0], ",") {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+
+		height := 1
+		if star := strings.IndexByte(l, '*'); star >= 0 {
+			height, _ = strconv.Atoi(l[:star])
+			l = l[star+1:]
+		}
+
+		layer := nbt.NewCompound()
+		layer.Set("block", nbt.String(l))
+		layer.Set("height", nbt.Int(height))
+		list.Append(layer)
+	}
+
+	options := nbt.NewCompound()
+	options.Set("layers", list)
+	options.Set("biome", nbt.String("minecraft:plains"))
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		options.Set("biome", nbt.String(strings.TrimSpace(parts[1])))
+	}
+	options.Set("structures", nbt.NewCompound())
+
+	return options, nil
+}
+
+func flatLayers(options *nbt.Compound) string {
+	var layers []string
+	if list, ok := options.Get("layers").(*nbt.List); ok {
+		for i := 0; i < list.Len(); i++ {
+			layer, ok := list.Get(i).(*nbt.Compound)
+			if !ok {
+				continue
+			}
+
+			name, _ := layer.Get("block").(nbt.String)
+			height, _ := layer.Get("height").(nbt.Int)
+			if height > 1 {
+				layers = append(layers, strconv.Itoa(int(height))+"*"+string(name))
+			} else {
+				layers = append(layers, string(name))
+			}
+		}
+	}
+
+	biome, _ := options.Get("biome").(nbt.String)
+	if biome == "" {
+		biome = "minecraft:plains"
+	}
+
+	return strings.Join(layers, ",") + ";" + string(biome)
+}
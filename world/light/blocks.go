@@ -0,0 +1,149 @@
+package light
+
+import (
+	"strings"
+
+	"github.com/skdltmxn/go-mine/world/block"
+)
+
+// The data reports do not say how blocks interact with light, so the
+// values below are kept by hand. Blocks not listed are opaque.
+
+// light emitted by a block when it has no lit property or it is lit
+var emitting = map[string]byte{
+	"minecraft:torch":               14,
+	"minecraft:wall_torch":          14,
+	"minecraft:fire":                15,
+	"minecraft:lava":                15,
+	"minecraft:glowstone":           15,
+	"minecraft:jack_o_lantern":      15,
+	"minecraft:nether_portal":       11,
+	"minecraft:furnace":             13,
+	"minecraft:redstone_torch":      7,
+	"minecraft:redstone_wall_torch": 7,
+	"minecraft:redstone_ore":        9,
+	"minecraft:brown_mushroom":      1,
+}
+
+// blocks that dim light by one like water
+var translucent = map[string]bool{
+	"minecraft:water":         true,
+	"minecraft:ice":           true,
+	"minecraft:cobweb":        true,
+	"minecraft:seagrass":      true,
+	"minecraft:tall_seagrass": true,
+}
+
+// blocks that let light through
+var transparent = map[string]bool{
+	"minecraft:air":           true,
+	"minecraft:void_air":      true,
+	"minecraft:cave_air":      true,
+	"minecraft:glass":         true,
+	"minecraft:snow":          true,
+	"minecraft:cactus":        true,
+	"minecraft:cake":          true,
+	"minecraft:ladder":        true,
+	"minecraft:lever":         true,
+	"minecraft:repeater":      true,
+	"minecraft:rail":          true,
+	"minecraft:spawner":       true,
+	"minecraft:chest":         true,
+	"minecraft:redstone_wire": true,
+}
+
+// transparentSuffixes cover whole families of non-full blocks.
+var transparentSuffixes = []string{
+	"_sapling", "_leaves", "_bed", "_rail", "_door", "_sign", "_stairs", "_fence",
+	"_pressure_plate", "_button", "_torch", "_tulip", "_mushroom", "_stained_glass",
+	"piston_head", "moving_piston",
+}
+
+var plants = map[string]bool{
+	"minecraft:grass":              true,
+	"minecraft:fern":               true,
+	"minecraft:dead_bush":          true,
+	"minecraft:dandelion":          true,
+	"minecraft:poppy":              true,
+	"minecraft:blue_orchid":        true,
+	"minecraft:allium":             true,
+	"minecraft:azure_bluet":        true,
+	"minecraft:oxeye_daisy":        true,
+	"minecraft:cornflower":         true,
+	"minecraft:wither_rose":        true,
+	"minecraft:lily_of_the_valley": true,
+	"minecraft:sugar_cane":         true,
+	"minecraft:wheat":              true,
+	"minecraft:fire":               true,
+	"minecraft:nether_portal":      true,
+}
+
+var (
+	emissions []byte
+	opacities []byte
+)
+
+func init() {
+	blocks := block.All()
+	max := blocks[len(blocks)-1].MaxState()
+
+	emissions = make([]byte, max+1)
+	opacities = make([]byte, max+1)
+	for i := range opacities {
+		opacities[i] = 15
+	}
+
+	for _, b := range blocks {
+		for id := b.MinState; id <= b.MaxState(); id++ {
+			opacities[id] = blockOpacity(b.Name)
+
+			if e, ok := emitting[b.Name]; ok {
+				if lit, ok := b.StateProperties(id)["lit"]; !ok || lit == "true" {
+					emissions[id] = e
+				}
+			}
+		}
+	}
+}
+
+func blockOpacity(name string) byte {
+	switch {
+	case transparent[name], plants[name]:
+		return 0
+	case translucent[name]:
+		return 1
+	case name == "minecraft:lava":
+		// lava lights itself but blocks sky light
+		return 15
+	}
+
+	for _, suffix := range transparentSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			if strings.HasSuffix(name, "_leaves") {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return 15
+}
+
+// Emission returns the light level a block state gives off.
+func Emission(state int32) byte {
+	if state < 0 || int(state) >= len(emissions) {
+		return 0
+	}
+
+	return emissions[state]
+}
+
+// Opacity returns how much a block state dims light passing through it,
+// with 15 blocking it completely.
+func Opacity(state int32) byte {
+	if state < 0 || int(state) >= len(opacities) {
+		return 15
+	}
+
+	return opacities[state]
+}
@@ -0,0 +1,354 @@
+// Package light computes block light and sky light for chunks.
+package light
+
+import "github.com/skdltmxn/go-mine/world/chunk"
+
+const maxLight = 15
+
+// Provider gives the engine access to the loaded chunks around the ones
+// it lights. Light does not spread into chunks that are not loaded; it
+// is pulled in when they are lit in turn.
+type Provider interface {
+	// Chunk returns the chunk or nil if it is not loaded.
+	Chunk(x, z int32) *chunk.Chunk
+}
+
+type ChunkPos struct {
+	X, Z int32
+}
+
+type node struct {
+	x, y, z int
+	level   byte
+}
+
+var directions = [6][3]int{
+	{0, -1, 0}, {0, 1, 0}, {-1, 0, 0}, {1, 0, 0}, {0, 0, -1}, {0, 0, 1},
+}
+
+// Engine propagates light with a breadth first flood fill. It is not safe
+// for concurrent use, and the chunks must not be modified while it runs.
+type Engine struct {
+	p Provider
+
+	// the chunk being lit, which may not be in the provider yet
+	current *chunk.Chunk
+	// last chunk looked up, as most steps stay in the same chunk
+	last    *chunk.Chunk
+	changed map[ChunkPos]bool
+
+	increase []node
+	decrease []node
+}
+
+func NewEngine(p Provider) *Engine {
+	return &Engine{p: p}
+}
+
+func (e *Engine) chunkAt(x, z int) *chunk.Chunk {
+	cx, cz := int32(x>>4), int32(z>>4)
+	if e.last != nil && e.last.X == cx && e.last.Z == cz {
+		return e.last
+	}
+
+	c := e.current
+	if c == nil || c.X != cx || c.Z != cz {
+		if c = e.p.Chunk(cx, cz); c == nil {
+			return nil
+		}
+	}
+
+	e.last = c
+	return c
+}
+
+// array returns the light section holding y, allocating it if needed.
+func array(c *chunk.Chunk, sky bool, y int) chunk.NibbleArray {
+	arrays := &c.BlockLight
+	if sky {
+		arrays = &c.SkyLight
+	}
+
+	i := y>>4 + 1
+	if arrays[i] == nil {
+		arrays[i] = chunk.NewNibbleArray()
+	}
+
+	return arrays[i]
+}
+
+func (e *Engine) get(sky bool, x, y, z int) (byte, bool) {
+	if y < 0 || y >= chunk.Height {
+		// above the world is open sky, below is dark
+		if sky && y >= chunk.Height {
+			return maxLight, false
+		}
+		return 0, false
+	}
+
+	c := e.chunkAt(x, z)
+	if c == nil {
+		return 0, false
+	}
+
+	return array(c, sky, y).Get(x&15, y&15, z&15), true
+}
+
+func (e *Engine) set(sky bool, x, y, z int, level byte) {
+	c := e.chunkAt(x, z)
+	array(c, sky, y).Set(x&15, y&15, z&15, level)
+	e.changed[ChunkPos{c.X, c.Z}] = true
+}
+
+// spread returns the level light of the given level reaches at the
+// neighbor in direction d. Full sky light goes straight down through
+// transparent blocks without dimming.
+func (e *Engine) spread(sky bool, level byte, d int, x, y, z int) byte {
+	o := Opacity(e.chunkAt(x, z).GetBlock(x&15, y, z&15))
+	if sky && level == maxLight && d == 0 && o == 0 {
+		return maxLight
+	}
+	if o == 0 {
+		o = 1
+	}
+	if o >= level {
+		return 0
+	}
+
+	return level - o
+}
+
+func (e *Engine) runIncrease(sky bool) {
+	for i := 0; i < len(e.increase); i++ {
+		n := e.increase[i]
+
+		// the level may have risen since n was queued
+		if cur, _ := e.get(sky, n.x, n.y, n.z); cur > n.level {
+			continue
+		}
+
+		for d, dir := range directions {
+			x, y, z := n.x+dir[0], n.y+dir[1], n.z+dir[2]
+			cur, ok := e.get(sky, x, y, z)
+			if !ok {
+				continue
+			}
+
+			if level := e.spread(sky, n.level, d, x, y, z); level > cur {
+				e.set(sky, x, y, z, level)
+				e.increase = append(e.increase, node{x, y, z, level})
+			}
+		}
+	}
+	e.increase = e.increase[:0]
+}
+
+// runDecrease clears the light that came from the queued nodes, and
+// queues the light sources at the edge of the cleared area to fill it
+// back in. The queue must be processed in order so that every cell is
+// reached along its shortest path first.
+func (e *Engine) runDecrease(sky bool) {
+	for i := 0; i < len(e.decrease); i++ {
+		n := e.decrease[i]
+
+		for d, dir := range directions {
+			x, y, z := n.x+dir[0], n.y+dir[1], n.z+dir[2]
+			cur, ok := e.get(sky, x, y, z)
+			if !ok || cur == 0 {
+				continue
+			}
+
+			fromHere := cur < n.level || (sky && d == 0 && n.level == maxLight && cur == maxLight)
+			if !fromHere {
+				e.increase = append(e.increase, node{x, y, z, cur})
+				continue
+			}
+
+			e.set(sky, x, y, z, 0)
+			e.decrease = append(e.decrease, node{x, y, z, cur})
+
+			// emitters inside the cleared area light it up again
+			if !sky {
+				if level := Emission(e.chunkAt(x, z).GetBlock(x&15, y, z&15)); level > 0 {
+					e.set(sky, x, y, z, level)
+					e.increase = append(e.increase, node{x, y, z, level})
+				}
+			}
+		}
+	}
+	e.decrease = e.decrease[:0]
+}
+
+func (e *Engine) begin(c *chunk.Chunk) {
+	e.current = c
+	e.last = nil
+	e.changed = make(map[ChunkPos]bool)
+}
+
+func (e *Engine) end() []ChunkPos {
+	changed := make([]ChunkPos, 0, len(e.changed))
+	for pos := range e.changed {
+		changed = append(changed, pos)
+	}
+
+	e.current, e.last, e.changed = nil, nil, nil
+	return changed
+}
+
+// LightChunk computes the light of a newly loaded chunk and exchanges
+// light with its loaded neighbors. It returns every chunk whose light
+// changed, including c.
+func (e *Engine) LightChunk(c *chunk.Chunk) []ChunkPos {
+	e.begin(c)
+
+	for i := range c.SkyLight {
+		c.SkyLight[i] = chunk.NewNibbleArray()
+		c.BlockLight[i] = chunk.NewNibbleArray()
+	}
+	// everything above the top section sees the sky
+	for i := range c.SkyLight[chunk.LightSectionCount-1] {
+		c.SkyLight[chunk.LightSectionCount-1][i] = 0xff
+	}
+	e.changed[ChunkPos{c.X, c.Z}] = true
+
+	baseX, baseZ := int(c.X)*16, int(c.Z)*16
+
+	// sky light falls straight down until the first block that dims it
+	var bottom [16][16]int
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			y := chunk.Height - 1
+			for ; y >= 0 && Opacity(c.GetBlock(x, y, z)) == 0; y-- {
+				c.SkyLight[y>>4+1].Set(x, y&15, z, maxLight)
+			}
+			bottom[x][z] = y + 1
+		}
+	}
+
+	// only the lit cells next to a deeper column can spread sideways
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			top := bottom[x][z]
+			for _, dir := range directions[2:] {
+				nx, nz := x+dir[0], z+dir[2]
+				if nx >= 0 && nx < 16 && nz >= 0 && nz < 16 && bottom[nx][nz] > top {
+					top = bottom[nx][nz]
+				}
+			}
+			for y := bottom[x][z]; y <= top && y < chunk.Height; y++ {
+				e.increase = append(e.increase, node{baseX + x, y, baseZ + z, maxLight})
+			}
+		}
+	}
+	e.pullBorders(c, true)
+	e.runIncrease(true)
+
+	for sy, s := range c.Sections {
+		if s == nil {
+			continue
+		}
+		for y := sy * 16; y < sy*16+16; y++ {
+			for z := 0; z < 16; z++ {
+				for x := 0; x < 16; x++ {
+					if level := Emission(c.GetBlock(x, y, z)); level > 0 {
+						c.BlockLight[y>>4+1].Set(x, y&15, z, level)
+						e.increase = append(e.increase, node{baseX + x, y, baseZ + z, level})
+					}
+				}
+			}
+		}
+	}
+	e.pullBorders(c, false)
+	e.runIncrease(false)
+
+	return e.end()
+}
+
+// pullBorders queues the light on both sides of the borders with the
+// loaded neighbors, so that it flows in either direction.
+func (e *Engine) pullBorders(c *chunk.Chunk, sky bool) {
+	baseX, baseZ := int(c.X)*16, int(c.Z)*16
+
+	for _, dir := range directions[2:] {
+		if e.p.Chunk(c.X+int32(dir[0]), c.Z+int32(dir[2])) == nil {
+			continue
+		}
+
+		for i := 0; i < 16; i++ {
+			// the cell on this side of the border
+			x, z := i, i
+			if dir[0] < 0 {
+				x = 0
+			} else if dir[0] > 0 {
+				x = 15
+			} else if dir[2] < 0 {
+				z = 0
+			} else {
+				z = 15
+			}
+
+			for y := 0; y < chunk.Height; y++ {
+				wx, wz := baseX+x, baseZ+z
+				if level, _ := e.get(sky, wx+dir[0], y, wz+dir[2]); level > 1 {
+					e.increase = append(e.increase, node{wx + dir[0], y, wz + dir[2], level})
+				}
+				if level, _ := e.get(sky, wx, y, wz); level > 1 {
+					e.increase = append(e.increase, node{wx, y, wz, level})
+				}
+			}
+		}
+	}
+}
+
+// Update relights the area around a block of a loaded chunk whose state
+// just changed. It returns every chunk whose light changed.
+func (e *Engine) Update(x, y, z int) []ChunkPos {
+	if y < 0 || y >= chunk.Height {
+		return nil
+	}
+
+	c := e.p.Chunk(int32(x>>4), int32(z>>4))
+	if c == nil {
+		return nil
+	}
+
+	e.begin(c)
+	for _, sky := range []bool{true, false} {
+		e.relight(sky, x, y, z)
+	}
+
+	return e.end()
+}
+
+func (e *Engine) relight(sky bool, x, y, z int) {
+	if level, _ := e.get(sky, x, y, z); level > 0 {
+		e.set(sky, x, y, z, 0)
+		e.decrease = append(e.decrease, node{x, y, z, level})
+		e.runDecrease(sky)
+	}
+
+	if !sky {
+		if level := Emission(e.chunkAt(x, z).GetBlock(x&15, y, z&15)); level > 0 {
+			e.set(sky, x, y, z, level)
+			e.increase = append(e.increase, node{x, y, z, level})
+		}
+	}
+
+	// let the neighbors flow back in
+	for _, dir := range directions {
+		nx, ny, nz := x+dir[0], y+dir[1], z+dir[2]
+		if level, ok := e.get(sky, nx, ny, nz); ok && level > 0 {
+			e.increase = append(e.increase, node{nx, ny, nz, level})
+		}
+	}
+
+	// the sky above the world is not stored anywhere
+	if sky && y == chunk.Height-1 {
+		if level := e.spread(sky, maxLight, 0, x, y, z); level > 0 {
+			e.set(sky, x, y, z, level)
+			e.increase = append(e.increase, node{x, y, z, level})
+		}
+	}
+
+	e.runIncrease(sky)
+}
@@ -0,0 +1,133 @@
+package light
+
+import (
+	"testing"
+
+	"github.com/skdltmxn/go-mine/world/block"
+	"github.com/skdltmxn/go-mine/world/chunk"
+)
+
+type testProvider map[ChunkPos]*chunk.Chunk
+
+func (p testProvider) Chunk(x, z int32) *chunk.Chunk {
+	return p[ChunkPos{x, z}]
+}
+
+func (p testProvider) load(e *Engine, c *chunk.Chunk) {
+	e.LightChunk(c)
+	p[ChunkPos{c.X, c.Z}] = c
+}
+
+func mustParse(t *testing.T, s string) int32 {
+	id, err := block.Parse(s)
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+
+	return id
+}
+
+// newFloor returns a chunk with stone up to y=63.
+func newFloor(t *testing.T, x, z int32) *chunk.Chunk {
+	stone := mustParse(t, "stone")
+
+	c := chunk.New(x, z)
+	for y := 0; y < 64; y++ {
+		for bz := 0; bz < 16; bz++ {
+			for bx := 0; bx < 16; bx++ {
+				c.SetBlock(bx, y, bz, stone)
+			}
+		}
+	}
+
+	return c
+}
+
+func skyAt(c *chunk.Chunk, x, y, z int) byte {
+	return c.SkyLight[y>>4+1].Get(x, y&15, z)
+}
+
+func blockAt(c *chunk.Chunk, x, y, z int) byte {
+	return c.BlockLight[y>>4+1].Get(x, y&15, z)
+}
+
+func TestSkyLight(t *testing.T) {
+	p := make(testProvider)
+	e := NewEngine(p)
+	c := newFloor(t, 0, 0)
+	p.load(e, c)
+
+	if l := skyAt(c, 3, 64, 3); l != 15 {
+		t.Fatalf("unexpected sky light above ground: %d", l)
+	}
+	if l := skyAt(c, 3, 63, 3); l != 0 {
+		t.Fatalf("unexpected sky light in ground: %d", l)
+	}
+
+	// a roof dims the column under it, light comes in from the sides
+	c.SetBlock(5, 70, 5, mustParse(t, "stone"))
+	e.Update(5, 70, 5)
+	if l := skyAt(c, 5, 69, 5); l != 14 {
+		t.Fatalf("unexpected sky light under roof: %d", l)
+	}
+
+	c.SetBlock(5, 70, 5, chunk.AirState)
+	e.Update(5, 70, 5)
+	if l := skyAt(c, 5, 64, 5); l != 15 {
+		t.Fatalf("sky light not restored: %d", l)
+	}
+
+	// glass lets full sky light through
+	c.SetBlock(5, 70, 5, mustParse(t, "glass"))
+	e.Update(5, 70, 5)
+	if l := skyAt(c, 5, 64, 5); l != 15 {
+		t.Fatalf("unexpected sky light under glass: %d", l)
+	}
+}
+
+func TestBlockLight(t *testing.T) {
+	p := make(testProvider)
+	e := NewEngine(p)
+	left := newFloor(t, 0, 0)
+	right := newFloor(t, 1, 0)
+	p.load(e, left)
+	p.load(e, right)
+
+	torch := mustParse(t, "torch")
+	left.SetBlock(15, 64, 8, torch)
+	changed := e.Update(15, 64, 8)
+	if len(changed) != 2 {
+		t.Fatalf("expected both chunks to change: %v", changed)
+	}
+
+	if l := blockAt(left, 15, 64, 8); l != 14 {
+		t.Fatalf("unexpected torch light: %d", l)
+	}
+	if l := blockAt(right, 0, 64, 8); l != 13 {
+		t.Fatalf("unexpected light across the border: %d", l)
+	}
+	if l := blockAt(right, 5, 64, 8); l != 8 {
+		t.Fatalf("unexpected light across the border: %d", l)
+	}
+
+	left.SetBlock(15, 64, 8, chunk.AirState)
+	e.Update(15, 64, 8)
+	if l := blockAt(right, 0, 64, 8); l != 0 {
+		t.Fatalf("light not removed: %d", l)
+	}
+
+	// a chunk loaded next to a lit one pulls the light in
+	right.SetBlock(0, 64, 8, torch)
+	e.Update(16, 64, 8)
+	far := newFloor(t, -1, 0)
+	p.load(e, far)
+	if l := blockAt(far, 15, 64, 8); l != 0 {
+		t.Fatalf("unexpected light far from the torch: %d", l)
+	}
+	near := newFloor(t, 2, 0)
+	near.SetBlock(0, 64, 8, torch)
+	p.load(e, near)
+	if l := blockAt(right, 15, 64, 8); l != 13 {
+		t.Fatalf("light not pushed into loaded neighbor: %d", l)
+	}
+}
@@ -0,0 +1,515 @@
+// Package region reads and writes Anvil region files (r.X.Z.mca), each of
+// which stores the NBT of a 32x32 area of chunks.
+package region
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+const (
+	SectorSize = 4096
+
+	// chunks whose data spans more sectors than fit in a location entry
+	// are stored in an external c.X.Z.mcc file
+	maxSectors = 0xff
+
+	headerSectors = 2
+	chunkCount    = 32 * 32
+)
+
+// Compression types of a chunk payload.
+const (
+	CompressionGzip = 1
+	CompressionZlib = 2
+	CompressionNone = 3
+
+	compressionExternal = 0x80
+)
+
+var (
+	ErrNotFound        = errors.New("region: chunk not found")
+	ErrBadCompression  = errors.New("region: unknown compression type")
+	ErrCorruptedHeader = errors.New("region: corrupted chunk header")
+)
+
+// Region is an open region file. It is safe for concurrent use.
+type Region struct {
+	mu          sync.Mutex
+	f           *os.File
+	path        string
+	compression byte
+	locations   [chunkCount]uint32
+	timestamps  [chunkCount]uint32
+	used        []bool
+}
+
+// FileName returns the file name of the region containing the chunk.
+func FileName(chunkX, chunkZ int32) string {
+	return fmt.Sprintf("r.%d.%d.mca", chunkX>>5, chunkZ>>5)
+}
+
+func externalFileName(chunkX, chunkZ int32) string {
+	return fmt.Sprintf("c.%d.%d.mcc", chunkX, chunkZ)
+}
+
+func chunkIndex(chunkX, chunkZ int32) int {
+	return int(chunkX&31) + int(chunkZ&31)*32
+}
+
+// Open opens the region file at path, creating an empty one if it does not
+// exist.
+func Open(path string) (*Region, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Region{f: f, path: path, compression: CompressionZlib}
+	if err := r.readHeader(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Region) readHeader() error {
+	info, err := r.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := info.Size()
+	if size < headerSectors*SectorSize {
+		// new or truncated file
+		if err := r.f.Truncate(headerSectors * SectorSize); err != nil {
+			return err
+		}
+		if _, err := r.f.WriteAt(make([]byte, headerSectors*SectorSize-size), size); err != nil {
+			return err
+		}
+		size = headerSectors * SectorSize
+	}
+
+	header := make([]byte, headerSectors*SectorSize)
+	if _, err := r.f.ReadAt(header, 0); err != nil {
+		return err
+	}
+
+	r.used = make([]bool, (size+SectorSize-1)/SectorSize)
+	r.used[0], r.used[1] = true, true
+
+	for i := 0; i < chunkCount; i++ {
+		r.locations[i] = binary.BigEndian.Uint32(header[i*4:])
+		r.timestamps[i] = binary.BigEndian.Uint32(header[SectorSize+i*4:])
+
+		offset, count := r.sectors(i)
+		if offset < headerSectors || offset+count > len(r.used) {
+			// points into the header or past the end; treat as missing
+			r.locations[i] = 0
+			continue
+		}
+		for s := offset; s < offset+count; s++ {
+			r.used[s] = true
+		}
+	}
+
+	return nil
+}
+
+func (r *Region) sectors(i int) (offset, count int) {
+	return int(r.locations[i] >> 8), int(r.locations[i] & 0xff)
+}
+
+// SetCompression sets the compression used by WriteChunk. The default is
+// zlib, as written by the vanilla server.
+func (r *Region) SetCompression(compression byte) error {
+	switch compression {
+	case CompressionGzip, CompressionZlib, CompressionNone:
+	default:
+		return ErrBadCompression
+	}
+
+	r.mu.Lock()
+	r.compression = compression
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Region) HasChunk(chunkX, chunkZ int32) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.locations[chunkIndex(chunkX, chunkZ)] != 0
+}
+
+// Timestamp returns when the chunk was last saved.
+func (r *Region) Timestamp(chunkX, chunkZ int32) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return time.Unix(int64(r.timestamps[chunkIndex(chunkX, chunkZ)]), 0)
+}
+
+// ReadChunk returns the uncompressed NBT of a chunk.
+func (r *Region) ReadChunk(chunkX, chunkZ int32) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	compression, data, err := r.readRaw(chunkX, chunkZ)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(compression, data)
+}
+
+// ReadChunkTag reads and decodes the NBT of a chunk.
+func (r *Region) ReadChunkTag(chunkX, chunkZ int32) (*nbt.Compound, error) {
+	data, err := r.ReadChunk(chunkX, chunkZ)
+	if err != nil {
+		return nil, err
+	}
+
+	_, t, err := nbt.ReadTag(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := t.(*nbt.Compound)
+	if !ok {
+		return nil, errors.New("region: chunk root is not a compound")
+	}
+
+	return c, nil
+}
+
+// readRaw returns the still compressed payload of a chunk, following it
+// to the external file if needed.
+func (r *Region) readRaw(chunkX, chunkZ int32) (byte, []byte, error) {
+	offset, count := r.sectors(chunkIndex(chunkX, chunkZ))
+	if offset == 0 {
+		return 0, nil, ErrNotFound
+	}
+
+	var header [5]byte
+	if _, err := r.f.ReadAt(header[:], int64(offset)*SectorSize); err != nil {
+		return 0, nil, err
+	}
+
+	length := int(binary.BigEndian.Uint32(header[:]))
+	compression := header[4]
+	if length < 1 || length+4 > count*SectorSize {
+		return 0, nil, ErrCorruptedHeader
+	}
+
+	if compression&compressionExternal != 0 {
+		data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(r.path), externalFileName(chunkX, chunkZ)))
+		if err != nil {
+			return 0, nil, err
+		}
+		return compression &^ compressionExternal, data, nil
+	}
+
+	data := make([]byte, length-1)
+	if _, err := r.f.ReadAt(data, int64(offset)*SectorSize+5); err != nil {
+		return 0, nil, err
+	}
+
+	return compression, data, nil
+}
+
+func decompress(compression byte, data []byte) ([]byte, error) {
+	var zr io.ReadCloser
+	var err error
+
+	switch compression {
+	case CompressionGzip:
+		zr, err = gzip.NewReader(bytes.NewReader(data))
+	case CompressionZlib:
+		zr, err = zlib.NewReader(bytes.NewReader(data))
+	case CompressionNone:
+		return data, nil
+	default:
+		return nil, ErrBadCompression
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return ioutil.ReadAll(zr)
+}
+
+func compress(compression byte, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+
+	switch compression {
+	case CompressionGzip:
+		zw = gzip.NewWriter(&buf)
+	case CompressionZlib:
+		zw = zlib.NewWriter(&buf)
+	case CompressionNone:
+		return data, nil
+	default:
+		return nil, ErrBadCompression
+	}
+
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteChunk compresses and stores the uncompressed NBT of a chunk.
+func (r *Region) WriteChunk(chunkX, chunkZ int32, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	compressed, err := compress(r.compression, data)
+	if err != nil {
+		return err
+	}
+
+	return r.writeRaw(chunkX, chunkZ, r.compression, compressed, time.Now())
+}
+
+// WriteChunkTag encodes and stores the NBT of a chunk.
+func (r *Region) WriteChunkTag(chunkX, chunkZ int32, c *nbt.Compound) error {
+	var buf bytes.Buffer
+	if err := nbt.WriteTag(&buf, "", c); err != nil {
+		return err
+	}
+
+	return r.WriteChunk(chunkX, chunkZ, buf.Bytes())
+}
+
+func (r *Region) writeRaw(chunkX, chunkZ int32, compression byte, data []byte, modified time.Time) error {
+	i := chunkIndex(chunkX, chunkZ)
+	external := filepath.Join(filepath.Dir(r.path), externalFileName(chunkX, chunkZ))
+
+	payload := data
+	count := (len(data) + 5 + SectorSize - 1) / SectorSize
+	if count > maxSectors {
+		if err := ioutil.WriteFile(external, data, 0644); err != nil {
+			return err
+		}
+		compression |= compressionExternal
+		payload = nil
+		count = 1
+	}
+
+	// the old sectors are only released once the new copy is written, so
+	// a failed write leaves the previous chunk readable
+	offset := r.allocate(count)
+
+	buf := make([]byte, count*SectorSize)
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)+1))
+	buf[4] = compression
+	copy(buf[5:], payload)
+
+	if _, err := r.f.WriteAt(buf, int64(offset)*SectorSize); err != nil {
+		r.release(offset, count)
+		return err
+	}
+
+	oldOffset, oldCount := r.sectors(i)
+	if err := r.writeLocation(i, uint32(offset)<<8|uint32(count), uint32(modified.Unix())); err != nil {
+		r.release(offset, count)
+		return err
+	}
+	if oldOffset != 0 {
+		r.release(oldOffset, oldCount)
+	}
+
+	if compression&compressionExternal == 0 {
+		// the chunk may have shrunk back under the limit
+		os.Remove(external)
+	}
+
+	return nil
+}
+
+// allocate returns the first free run of count sectors, growing the
+// file if there is none.
+func (r *Region) allocate(count int) int {
+	run := 0
+	for s := headerSectors; s < len(r.used); s++ {
+		if r.used[s] {
+			run = 0
+			continue
+		}
+		run++
+		if run == count {
+			start := s - count + 1
+			for j := start; j <= s; j++ {
+				r.used[j] = true
+			}
+			return start
+		}
+	}
+
+	start := len(r.used) - run
+	for s := start; s < start+count; s++ {
+		if s < len(r.used) {
+			r.used[s] = true
+		} else {
+			r.used = append(r.used, true)
+		}
+	}
+
+	return start
+}
+
+func (r *Region) release(offset, count int) {
+	for s := offset; s < offset+count && s < len(r.used); s++ {
+		r.used[s] = false
+	}
+}
+
+func (r *Region) writeLocation(i int, location, timestamp uint32) error {
+	var bs [4]byte
+
+	binary.BigEndian.PutUint32(bs[:], location)
+	if _, err := r.f.WriteAt(bs[:], int64(i)*4); err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint32(bs[:], timestamp)
+	if _, err := r.f.WriteAt(bs[:], SectorSize+int64(i)*4); err != nil {
+		return err
+	}
+
+	r.locations[i] = location
+	r.timestamps[i] = timestamp
+	return nil
+}
+
+// DeleteChunk removes a chunk from the region.
+func (r *Region) DeleteChunk(chunkX, chunkZ int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := chunkIndex(chunkX, chunkZ)
+	offset, count := r.sectors(i)
+	if offset == 0 {
+		return ErrNotFound
+	}
+
+	if err := r.writeLocation(i, 0, 0); err != nil {
+		return err
+	}
+	r.release(offset, count)
+	os.Remove(filepath.Join(filepath.Dir(r.path), externalFileName(chunkX, chunkZ)))
+
+	return nil
+}
+
+// Defragment rewrites the file with all chunks packed right after the
+// header and truncates the free space at the end.
+func (r *Region) Defragment() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type chunkData struct {
+		offset, count int
+		data          []byte
+	}
+
+	chunks := make([]chunkData, chunkCount)
+	for i := range chunks {
+		offset, count := r.sectors(i)
+		if offset == 0 {
+			continue
+		}
+
+		data := make([]byte, count*SectorSize)
+		if _, err := r.f.ReadAt(data, int64(offset)*SectorSize); err != nil {
+			return err
+		}
+		chunks[i] = chunkData{data: data, count: count}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(r.path), filepath.Base(r.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	header := make([]byte, headerSectors*SectorSize)
+	next := headerSectors
+	for i, c := range chunks {
+		if c.data == nil {
+			continue
+		}
+		binary.BigEndian.PutUint32(header[i*4:], uint32(next)<<8|uint32(c.count))
+		binary.BigEndian.PutUint32(header[SectorSize+i*4:], r.timestamps[i])
+		chunks[i].offset = next
+		next += c.count
+	}
+
+	if _, err := tmp.Write(header); err != nil {
+		tmp.Close()
+		return err
+	}
+	for _, c := range chunks {
+		if c.data == nil {
+			continue
+		}
+		if _, err := tmp.Write(c.data); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(r.path, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	r.f.Close()
+	r.f = f
+
+	r.used = make([]bool, next)
+	for s := range r.used {
+		r.used[s] = true
+	}
+	for i, c := range chunks {
+		if c.data != nil {
+			r.locations[i] = uint32(c.offset)<<8 | uint32(c.count)
+		}
+	}
+
+	return nil
+}
+
+func (r *Region) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.f.Close()
+}
@@ -0,0 +1,140 @@
+package region
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+)
+
+func openTestRegion(t *testing.T) (*Region, string) {
+	dir, err := ioutil.TempDir("", "region")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+
+	r, err := Open(filepath.Join(dir, FileName(-1, 0)))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Open failed: %s", err)
+	}
+
+	return r, dir
+}
+
+func TestRegionReadWrite(t *testing.T) {
+	r, dir := openTestRegion(t)
+	defer os.RemoveAll(dir)
+	defer r.Close()
+
+	level := nbt.NewCompound()
+	level.Set("xPos", nbt.Int(-1))
+	level.Set("zPos", nbt.Int(0))
+	root := nbt.NewCompound()
+	root.Set("Level", level)
+	root.Set("DataVersion", nbt.Int(2230))
+
+	for _, compression := range []byte{CompressionGzip, CompressionZlib, CompressionNone} {
+		if err := r.SetCompression(compression); err != nil {
+			t.Fatalf("SetCompression failed: %s", err)
+		}
+		if err := r.WriteChunkTag(-1, 0, root); err != nil {
+			t.Fatalf("WriteChunkTag failed: %s", err)
+		}
+
+		c, err := r.ReadChunkTag(-1, 0)
+		if err != nil {
+			t.Fatalf("ReadChunkTag failed: %s", err)
+		}
+		if !nbt.Equal(c, root) {
+			t.Fatalf("chunk mismatch with compression %d: %s", compression, nbt.FormatSNBT(c))
+		}
+	}
+
+	if r.HasChunk(-2, 0) {
+		t.Fatalf("unexpected chunk at -2, 0")
+	}
+	if _, err := r.ReadChunk(-2, 0); err != ErrNotFound {
+		t.Fatalf("ReadChunk of missing chunk returned %v", err)
+	}
+}
+
+func TestRegionExternal(t *testing.T) {
+	r, dir := openTestRegion(t)
+	defer os.RemoveAll(dir)
+	defer r.Close()
+	r.SetCompression(CompressionNone)
+
+	big := make([]byte, (maxSectors+1)*SectorSize)
+	rand.Read(big)
+
+	if err := r.WriteChunk(-32, 5, big); err != nil {
+		t.Fatalf("WriteChunk failed: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.-32.5.mcc")); err != nil {
+		t.Fatalf("external chunk not written: %s", err)
+	}
+
+	data, err := r.ReadChunk(-32, 5)
+	if err != nil {
+		t.Fatalf("ReadChunk failed: %s", err)
+	}
+	if !bytes.Equal(data, big) {
+		t.Fatalf("external chunk mismatch")
+	}
+
+	if err := r.WriteChunk(-32, 5, []byte{1, 2, 3}); err != nil {
+		t.Fatalf("WriteChunk failed: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.-32.5.mcc")); !os.IsNotExist(err) {
+		t.Fatalf("external chunk not removed")
+	}
+}
+
+func TestRegionDefragment(t *testing.T) {
+	r, dir := openTestRegion(t)
+	defer os.RemoveAll(dir)
+	defer r.Close()
+	r.SetCompression(CompressionNone)
+
+	for i := int32(0); i < 4; i++ {
+		if err := r.WriteChunk(i, 0, bytes.Repeat([]byte{byte(i)}, SectorSize*2)); err != nil {
+			t.Fatalf("WriteChunk failed: %s", err)
+		}
+	}
+	r.DeleteChunk(1, 0)
+	r.DeleteChunk(2, 0)
+
+	if err := r.Defragment(); err != nil {
+		t.Fatalf("Defragment failed: %s", err)
+	}
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		t.Fatalf("Stat failed: %s", err)
+	}
+	if info.Size() != (headerSectors+6)*SectorSize {
+		t.Fatalf("unexpected size after defragment: %d", info.Size())
+	}
+
+	// reopen to check the header on disk
+	r.Close()
+	if r, err = Open(r.path); err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	defer r.Close()
+
+	for _, x := range []int32{0, 3} {
+		data, err := r.ReadChunk(x, 0)
+		if err != nil {
+			t.Fatalf("ReadChunk failed: %s", err)
+		}
+		if len(data) != SectorSize*2 || data[0] != byte(x) {
+			t.Fatalf("chunk %d mismatch after defragment", x)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+// Package world manages a world on disk: its level.dat, the region files
+// holding its chunks and the generator filling in the missing ones.
+package world
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/skdltmxn/go-mine/util/nbt"
+	"github.com/skdltmxn/go-mine/world/block"
+	"github.com/skdltmxn/go-mine/world/chunk"
+	"github.com/skdltmxn/go-mine/world/gen"
+	"github.com/skdltmxn/go-mine/world/light"
+	"github.com/skdltmxn/go-mine/world/region"
+)
+
+type ChunkPos = light.ChunkPos
+
+// Settings describe a world to create if there is none yet.
+type Settings struct {
+	Name      string
+	Seed      int64
+	Generator string // default, flat or void
+	Layers    string // of the flat generator
+}
+
+type loadedChunk struct {
+	c     *chunk.Chunk
+	dirty bool
+	refs  int
+}
+
+// World is safe for concurrent use. Chunks are only touched through the
+// callbacks of UseChunk and LoadedChunk, which run with the world locked.
+type World struct {
+	m         sync.Mutex
+	saveLock  sync.Mutex // one Save at a time, as it writes unlocked
+	dir       string
+	level     *level
+	generator gen.Generator
+	levelType string
+	chunks    map[ChunkPos]*loadedChunk
+	regions   map[ChunkPos]*region.Region
+	light     *light.Engine
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open opens the world in dir, creating it with the given settings if it
+// has no level.dat.
+func Open(dir string, settings Settings) (*World, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "region"), 0755); err != nil {
+		return nil, err
+	}
+
+	w := &World{
+		dir:     dir,
+		chunks:  make(map[ChunkPos]*loadedChunk),
+		regions: make(map[ChunkPos]*region.Region),
+	}
+	w.light = light.NewEngine(w)
+
+	var err error
+	path := filepath.Join(dir, "level.dat")
+	created := false
+	if w.level, err = loadLevel(path); os.IsNotExist(err) {
+		if w.level, err = newLevel(settings); err != nil {
+			return nil, err
+		}
+		created = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	if w.generator, w.levelType, err = w.level.generator(); err != nil {
+		return nil, err
+	}
+
+	if created {
+		w.level.data.Set("SpawnX", nbt.Int(0))
+		w.level.data.Set("SpawnY", nbt.Int(w.Height(0, 0)))
+		w.level.data.Set("SpawnZ", nbt.Int(0))
+		if err := w.level.save(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *World) Seed() int64 {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return w.level.long("RandomSeed")
+}
+
+// LevelType returns the level type sent in Join Game.
+func (w *World) LevelType() string {
+	return w.levelType
+}
+
+func (w *World) Spawn() (x, y, z int) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return int(w.level.int("SpawnX")), int(w.level.int("SpawnY")), int(w.level.int("SpawnZ"))
+}
+
+// Time returns the age of the world and the time of day, in ticks.
+func (w *World) Time() (age, dayTime int64) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return w.level.long("Time"), w.level.long("DayTime")
+}
+
+func (w *World) SetTime(age, dayTime int64) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.level.data.Set("Time", nbt.Long(age))
+	w.level.data.Set("DayTime", nbt.Long(dayTime))
+}
+
+// Weather reports whether it rains and thunders.
+func (w *World) Weather() (raining, thundering bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return w.level.int8("raining") != 0, w.level.int8("thundering") != 0
+}
+
+// SetWeather changes the weather for the given number of ticks, after
+// which it clears.
+func (w *World) SetWeather(raining, thundering bool, duration int) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	clear := int32(0)
+	rain, thunder := int32(0), int32(0)
+	if !raining && !thundering {
+		clear = int32(duration)
+	} else {
+		rain = int32(duration)
+		if thundering {
+			thunder = int32(duration)
+		}
+	}
+
+	w.level.data.Set("raining", nbt.Byte(boolByte(raining || thundering)))
+	w.level.data.Set("thundering", nbt.Byte(boolByte(thundering)))
+	w.level.data.Set("clearWeatherTime", nbt.Int(clear))
+	w.level.data.Set("rainTime", nbt.Int(rain))
+	w.level.data.Set("thunderTime", nbt.Int(thunder))
+}
+
+func boolByte(v bool) int8 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// TickWeather counts down the time left of the weather and reports
+// whether the weather changed.
+func (w *World) TickWeather() bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if v, _ := w.level.gameRules().Get("doWeatherCycle").(nbt.String); v == "false" {
+		return false
+	}
+
+	if clear := w.level.int("clearWeatherTime"); clear > 0 {
+		w.level.data.Set("clearWeatherTime", nbt.Int(clear-1))
+	}
+	if w.level.int8("raining") == 0 {
+		return false
+	}
+
+	changed := false
+	rain := w.level.int("rainTime") - 1
+	thunder := w.level.int("thunderTime") - 1
+	w.level.data.Set("rainTime", nbt.Int(rain))
+	w.level.data.Set("thunderTime", nbt.Int(thunder))
+
+	if thunder <= 0 && w.level.int8("thundering") != 0 {
+		w.level.data.Set("thundering", nbt.Byte(0))
+		changed = true
+	}
+	if rain <= 0 {
+		w.level.data.Set("raining", nbt.Byte(0))
+		changed = true
+	}
+
+	return changed
+}
+
+func (w *World) GameRule(name string) (string, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	v, ok := w.level.gameRules().Get(name).(nbt.String)
+	return string(v), ok
+}
+
+func (w *World) SetGameRule(name, value string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.level.gameRules().Set(name, nbt.String(value))
+}
+
+// Chunk implements light.Provider. Must be called with w.m held.
+func (w *World) Chunk(x, z int32) *chunk.Chunk {
+	if lc, ok := w.chunks[ChunkPos{X: x, Z: z}]; ok {
+		return lc.c
+	}
+
+	return nil
+}
+
+func (w *World) region(pos ChunkPos) (*region.Region, error) {
+	key := ChunkPos{X: pos.X >> 5, Z: pos.Z >> 5}
+	if r, ok := w.regions[key]; ok {
+		return r, nil
+	}
+
+	r, err := region.Open(filepath.Join(w.dir, "region", region.FileName(pos.X, pos.Z)))
+	if err != nil {
+		return nil, err
+	}
+	w.regions[key] = r
+
+	return r, nil
+}
+
+// load returns a loaded chunk, reading or generating it if needed, along
+// with the other chunks whose light changed. Must be called with w.m held.
+func (w *World) load(pos ChunkPos) (*loadedChunk, []ChunkPos, error) {
+	if lc, ok := w.chunks[pos]; ok {
+		return lc, nil, nil
+	}
+
+	r, err := w.region(pos)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lc := &loadedChunk{}
+	if r.HasChunk(pos.X, pos.Z) {
+		root, err := r.ReadChunkTag(pos.X, pos.Z)
+		if err != nil {
+			return nil, nil, err
+		}
+		if lc.c, err = chunk.FromNBT(root, block.Registry); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		lc.c = w.generator.Generate(pos.X, pos.Z)
+		lc.dirty = true
+	}
+
+	// light is always recomputed, so that it matches the loaded neighbors
+	var others []ChunkPos
+	for _, p := range w.light.LightChunk(lc.c) {
+		if p != pos {
+			w.chunks[p].dirty = true
+			others = append(others, p)
+		}
+	}
+	w.chunks[pos] = lc
+
+	return lc, others, nil
+}
+
+// UseChunk loads a chunk and calls fn with it. It returns the other
+// chunks whose light changed as the chunk was loaded.
+func (w *World) UseChunk(x, z int32, fn func(c *chunk.Chunk)) ([]ChunkPos, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	lc, changed, err := w.load(ChunkPos{X: x, Z: z})
+	if err != nil {
+		return nil, err
+	}
+
+	fn(lc.c)
+	return changed, nil
+}
+
+// LoadedChunk calls fn with a chunk if it is loaded and reports whether
+// it was.
+func (w *World) LoadedChunk(x, z int32, fn func(c *chunk.Chunk)) bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	lc, ok := w.chunks[ChunkPos{X: x, Z: z}]
+	if ok {
+		fn(lc.c)
+	}
+
+	return ok
+}
+
+// Retain loads a chunk and keeps it loaded until a matching Release. It
+// returns the other chunks whose light changed as the chunk was loaded.
+func (w *World) Retain(x, z int32) ([]ChunkPos, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	lc, changed, err := w.load(ChunkPos{X: x, Z: z})
+	if err != nil {
+		return nil, err
+	}
+
+	lc.refs++
+	return changed, nil
+}
+
+func (w *World) Release(x, z int32) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if lc, ok := w.chunks[ChunkPos{X: x, Z: z}]; ok && lc.refs > 0 {
+		lc.refs--
+	}
+}
+
+// Height returns the y above the highest block of a column, loading its
+// chunk if needed.
+func (w *World) Height(x, z int) int {
+	height := 0
+	w.UseChunk(int32(x>>4), int32(z>>4), func(c *chunk.Chunk) {
+		height = c.MotionBlocking.Get(x&15, z&15)
+	})
+
+	return height
+}
+
+// Block returns the state of a block, or air if its chunk is not loaded.
+func (w *World) Block(x, y, z int) int32 {
+	state := chunk.AirState
+	w.LoadedChunk(int32(x>>4), int32(z>>4), func(c *chunk.Chunk) {
+		state = c.GetBlock(x&15, y, z&15)
+	})
+
+	return state
+}
+
+// SetBlock changes a block of a loaded chunk. It returns the chunks whose
+// light changed and whether the chunk was loaded.
+func (w *World) SetBlock(x, y, z int, state int32) ([]ChunkPos, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	lc, ok := w.chunks[ChunkPos{X: int32(x >> 4), Z: int32(z >> 4)}]
+	if !ok {
+		return nil, false
+	}
+
+	lc.c.SetBlock(x&15, y, z&15, state)
+	lc.dirty = true
+
+	changed := w.light.Update(x, y, z)
+	for _, p := range changed {
+		w.chunks[p].dirty = true
+	}
+
+	return changed, true
+}
+
+// savedChunk is a chunk encoded under the lock, to be written without it.
+type savedChunk struct {
+	pos  ChunkPos
+	r    *region.Region
+	root *nbt.Compound
+}
+
+// Save writes level.dat and the dirty chunks, and unloads the chunks
+// nobody retains. The files are written without holding the world lock,
+// so loading chunks does not wait for the disk.
+func (w *World) Save() error {
+	w.saveLock.Lock()
+	defer w.saveLock.Unlock()
+
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	w.m.Lock()
+	var chunks []savedChunk
+	for pos, lc := range w.chunks {
+		if !lc.dirty {
+			continue
+		}
+
+		sc, err := w.encodeChunk(pos, lc)
+		if err != nil {
+			fail(fmt.Errorf("world: saving chunk %d, %d: %s", pos.X, pos.Z, err))
+			continue
+		}
+		lc.dirty = false
+		chunks = append(chunks, sc)
+	}
+	level, err := w.level.encode()
+	w.m.Unlock()
+
+	saved := 0
+	for _, sc := range chunks {
+		if err := sc.r.WriteChunkTag(sc.pos.X, sc.pos.Z, sc.root); err != nil {
+			fail(fmt.Errorf("world: saving chunk %d, %d: %s", sc.pos.X, sc.pos.Z, err))
+
+			// keep it loaded to try again
+			w.m.Lock()
+			if lc, ok := w.chunks[sc.pos]; ok {
+				lc.dirty = true
+			}
+			w.m.Unlock()
+			continue
+		}
+		saved++
+	}
+
+	if err == nil {
+		err = writeLevel(filepath.Join(w.dir, "level.dat"), level)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	w.m.Lock()
+	w.unload()
+	loaded := len(w.chunks)
+	w.m.Unlock()
+
+	log.Printf("[WORLD] Saved %d chunks, %d loaded", saved, loaded)
+	return firstErr
+}
+
+// encodeChunk must be called with w.m held.
+func (w *World) encodeChunk(pos ChunkPos, lc *loadedChunk) (savedChunk, error) {
+	r, err := w.region(pos)
+	if err != nil {
+		return savedChunk{}, err
+	}
+
+	lc.c.LastUpdate = w.level.long("Time")
+	root, err := lc.c.ToNBT(block.Registry)
+	if err != nil {
+		return savedChunk{}, err
+	}
+
+	return savedChunk{pos, r, root}, nil
+}
+
+// unload drops the saved chunks nobody retains and closes the region
+// files none of the loaded chunks are in. Must be called with w.m held.
+func (w *World) unload() {
+	used := make(map[ChunkPos]bool)
+	for pos, lc := range w.chunks {
+		if lc.refs == 0 && !lc.dirty {
+			delete(w.chunks, pos)
+			continue
+		}
+		used[ChunkPos{X: pos.X >> 5, Z: pos.Z >> 5}] = true
+	}
+
+	for key, r := range w.regions {
+		if !used[key] {
+			r.Close()
+			delete(w.regions, key)
+		}
+	}
+}
+
+// StartAutosave saves the world every interval until Close.
+func (w *World) StartAutosave(interval time.Duration) {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				if err := w.Save(); err != nil {
+					log.Printf("[WORLD] Autosave failed: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the autosave, saves everything and closes the region files.
+func (w *World) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+		w.stop = nil
+	}
+
+	err := w.Save()
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	for key, r := range w.regions {
+		r.Close()
+		delete(w.regions, key)
+	}
+
+	return err
+}
@@ -0,0 +1,116 @@
+package world
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/skdltmxn/go-mine/world/gen"
+)
+
+func TestWorldSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "world")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	settings := Settings{Name: "test", Seed: 1234, Generator: "flat", Layers: "bedrock,2*dirt,grass_block"}
+	w, err := Open(dir, settings)
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+
+	if _, y, _ := w.Spawn(); y != 4 {
+		t.Fatalf("unexpected spawn height: %d", y)
+	}
+	if _, err := w.Retain(-3, 2); err != nil {
+		t.Fatalf("Retain failed: %s", err)
+	}
+	if _, ok := w.SetBlock(-40, 10, 40, 1); !ok {
+		t.Fatalf("SetBlock failed on a loaded chunk")
+	}
+	w.SetTime(100, 200)
+	w.SetGameRule("keepInventory", "true")
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	// settings of an existing world are ignored
+	w, err = Open(dir, Settings{Generator: "void"})
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	defer w.Close()
+
+	if seed := w.Seed(); seed != 1234 {
+		t.Fatalf("unexpected seed: %d", seed)
+	}
+	if lt := w.LevelType(); lt != "flat" {
+		t.Fatalf("unexpected level type: %s", lt)
+	}
+	if age, day := w.Time(); age != 100 || day != 200 {
+		t.Fatalf("unexpected time: %d %d", age, day)
+	}
+	if v, _ := w.GameRule("keepInventory"); v != "true" {
+		t.Fatalf("unexpected game rule: %s", v)
+	}
+
+	w.Retain(-3, 2)
+	if state := w.Block(-40, 10, 40); state != 1 {
+		t.Fatalf("unexpected block: %d", state)
+	}
+	if state := w.Block(-40, 3, 40); state != 9 {
+		t.Fatalf("unexpected block: %d", state)
+	}
+}
+
+func TestVoidLevel(t *testing.T) {
+	l, err := newLevel(Settings{Generator: "void"})
+	if err != nil {
+		t.Fatalf("newLevel failed: %s", err)
+	}
+
+	g, levelType, err := l.generator()
+	if err != nil {
+		t.Fatalf("generator failed: %s", err)
+	}
+	if _, ok := g.(*gen.Void); !ok || levelType != "flat" {
+		t.Fatalf("unexpected generator: %T %s", g, levelType)
+	}
+}
+
+func TestSaveClosesRegions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "world")
+	if err != nil {
+		t.Fatalf("TempDir failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := Open(dir, Settings{Generator: "flat", Layers: "bedrock"})
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	defer w.Close()
+
+	// one chunk in another region stays retained
+	w.Retain(100, 100)
+	w.Retain(0, 0)
+	w.Release(0, 0)
+
+	if err := w.Save(); err != nil {
+		t.Fatalf("Save failed: %s", err)
+	}
+	if len(w.regions) != 1 || len(w.chunks) != 1 {
+		t.Fatalf("unexpected %d regions and %d chunks open", len(w.regions), len(w.chunks))
+	}
+
+	w.Release(100, 100)
+	if err := w.Save(); err != nil {
+		t.Fatalf("Save failed: %s", err)
+	}
+	if len(w.regions) != 0 || len(w.chunks) != 0 {
+		t.Fatalf("unexpected %d regions and %d chunks open", len(w.regions), len(w.chunks))
+	}
+}